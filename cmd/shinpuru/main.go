@@ -17,6 +17,7 @@ import (
 var (
 	flagConfigLocation = flag.String("c", "config.yml", "The location of the main config file")
 	flagDocker         = flag.Bool("docker", false, "wether shinpuru is running in a docker container or not")
+	flagMigrateBackups = flag.String("migrate-backups", "", "Copy all backup files from the passed storage type (e.g. 'local') into the configured backup storage")
 )
 
 func main() {
@@ -53,7 +54,9 @@ func main() {
 
 	lct := inits.InitLTCTimer()
 
-	cmdHandler := inits.InitCommandHandler(session, config, database, tnw, lct)
+	bck := inits.InitGuildBackups(session, config, database, *flagMigrateBackups)
+
+	cmdHandler := inits.InitCommandHandler(session, config, database, tnw, bck, lct)
 	inits.InitDiscordBotSession(session, config, database, cmdHandler, lct)
 	defer func() {
 		util.Log.Info("Shutting down bot session...")
@@ -0,0 +1,264 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdAntiRaid struct {
+	PermLvl int
+}
+
+func (c *CmdAntiRaid) GetInvokes() []string {
+	return []string{"antiraid", "raid", "ar"}
+}
+
+func (c *CmdAntiRaid) GetDescription() string {
+	return "manage automatic raid detection by join rate"
+}
+
+func (c *CmdAntiRaid) GetHelp() string {
+	return "`antiraid` - display current anti raid settings and raid mode state\n" +
+		"`antiraid enable` - enable anti raid with default settings\n" +
+		"`antiraid disable` - disable anti raid\n" +
+		"`antiraid rate <n> <seconds>` - enable raid mode when more than n fresh accounts join in the given time\n" +
+		"`antiraid age <hours>` - accounts younger than this are considered fresh (0 to count all accounts)\n" +
+		"`antiraid action kick` - kick fresh accounts joining during raid mode\n" +
+		"`antiraid action quarantine <roleResolvable>` - assign the given role to fresh accounts joining during raid mode\n" +
+		"`antiraid end` - end the raid mode and restore the previous verification level"
+}
+
+func (c *CmdAntiRaid) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdAntiRaid) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdAntiRaid) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdAntiRaid) Exec(args *CommandArgs) error {
+	settings, err := args.CmdHandler.db.GetGuildAntiRaid(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	if len(args.Args) < 1 {
+		return c.printStatus(args, settings)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+	case "enable", "e", "on":
+		if settings == nil {
+			settings = util.NewDefaultAntiRaidSettings()
+		}
+	case "disable", "d", "off":
+		settings = nil
+	case "rate", "r":
+		if settings == nil {
+			settings = util.NewDefaultAntiRaidSettings()
+		}
+		if ok, err := c.parseRate(args, settings); !ok || err != nil {
+			return err
+		}
+	case "age", "a":
+		if settings == nil {
+			settings = util.NewDefaultAntiRaidSettings()
+		}
+		if ok, err := c.parseAge(args, settings); !ok || err != nil {
+			return err
+		}
+	case "action", "act":
+		if settings == nil {
+			settings = util.NewDefaultAntiRaidSettings()
+		}
+		if ok, err := c.parseAction(args, settings); !ok || err != nil {
+			return err
+		}
+	case "end", "stop":
+		return c.endRaidMode(args)
+	default:
+		return c.printStatus(args, settings)
+	}
+
+	if err = args.CmdHandler.db.SetGuildAntiRaid(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	return c.printStatus(args, settings)
+}
+
+func (c *CmdAntiRaid) parseRate(args *CommandArgs, settings *util.AntiRaidSettings) (bool, error) {
+	if len(args.Args) < 3 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Invalid arguments. Use `help antiraid` to get help about how to use this command.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	n, errN := strconv.Atoi(args.Args[1])
+	secs, errSecs := strconv.Atoi(args.Args[2])
+	if errN != nil || errSecs != nil || n < 1 || secs < 1 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter a valid number of joins and a valid number of seconds *(both larger than 0)*.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	settings.JoinLimit = n
+	settings.JoinInterval = secs
+
+	return true, nil
+}
+
+func (c *CmdAntiRaid) parseAge(args *CommandArgs, settings *util.AntiRaidSettings) (bool, error) {
+	var hours int
+	var err error
+	if len(args.Args) > 1 {
+		hours, err = strconv.Atoi(args.Args[1])
+	}
+	if len(args.Args) < 2 || err != nil || hours < 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter a valid number of hours *(0 or larger)*.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	settings.MinAccountAge = hours
+
+	return true, nil
+}
+
+func (c *CmdAntiRaid) parseAction(args *CommandArgs, settings *util.AntiRaidSettings) (bool, error) {
+	if len(args.Args) < 2 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter one of the following actions: `kick`, `quarantine`.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	switch strings.ToLower(args.Args[1]) {
+	case util.AntiRaidActionKick:
+		settings.Action = util.AntiRaidActionKick
+		settings.QuarantineRoleID = ""
+	case util.AntiRaidActionQuarantine:
+		if len(args.Args) < 3 {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Please specify the role which will be assigned to quarantined members.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return false, err
+		}
+		role, err := util.FetchRole(args.Session, args.Guild.ID, args.Args[2])
+		if err != nil {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Role could not be fetched by passed identifier.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return false, err
+		}
+		settings.Action = util.AntiRaidActionQuarantine
+		settings.QuarantineRoleID = role.ID
+	default:
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter one of the following actions: `kick`, `quarantine`.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *CmdAntiRaid) endRaidMode(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	raidMode, prevLevel, err := db.GetGuildRaidMode(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+	if !raidMode {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Raid mode is currently not active on this guild.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	lvl := discordgo.VerificationLevel(prevLevel)
+	if _, err = args.Session.GuildEdit(args.Guild.ID, discordgo.GuildParams{VerificationLevel: &lvl}); err != nil {
+		return err
+	}
+
+	if err = db.SetGuildRaidMode(args.Guild.ID, false, 0); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		"Raid mode ended and verification level restored.", "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdAntiRaid) printStatus(args *CommandArgs, settings *util.AntiRaidSettings) error {
+	if settings == nil {
+		msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+			"Anti raid is currently **disabled** on this guild.\n\n"+
+				"*You can enable it with the command `antiraid enable`.*", "", util.ColorEmbedOrange)
+		util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+		return err
+	}
+
+	raidMode, _, err := args.CmdHandler.db.GetGuildRaidMode(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	raidModeTxt := "inactive"
+	if raidMode {
+		raidModeTxt = "**active** *(end it with `antiraid end`)*"
+	}
+
+	ageTxt := "*all accounts*"
+	if settings.MinAccountAge > 0 {
+		ageTxt = fmt.Sprintf("younger than `%d` hours", settings.MinAccountAge)
+	}
+
+	actionTxt := settings.Action
+	if settings.Action == util.AntiRaidActionQuarantine {
+		actionTxt = fmt.Sprintf("%s (<@&%s>)", settings.Action, settings.QuarantineRoleID)
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color:       util.ColorEmbedGreen,
+		Title:       "Anti Raid",
+		Description: "Anti raid is currently **enabled** on this guild.",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Raid Mode",
+				Value: raidModeTxt,
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Join Rate",
+				Value: fmt.Sprintf("more than `%d` fresh accounts in `%d` seconds", settings.JoinLimit, settings.JoinInterval),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Fresh Accounts",
+				Value: ageTxt,
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Action",
+				Value: actionTxt,
+			},
+		},
+	}
+
+	msg, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+	util.DeleteMessageLater(args.Session, msg, 15*time.Second)
+	return err
+}
@@ -0,0 +1,173 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+var antiSpamPenalties = []string{"WARN", "MUTE", "KICK", "BAN"}
+
+type CmdAntiSpam struct {
+	PermLvl int
+}
+
+func (c *CmdAntiSpam) GetInvokes() []string {
+	return []string{"antispam", "spam", "as"}
+}
+
+func (c *CmdAntiSpam) GetDescription() string {
+	return "manage automatic detection of message floods and duplicates"
+}
+
+func (c *CmdAntiSpam) GetHelp() string {
+	return "`antispam` - display current anti spam settings\n" +
+		"`antispam enable` - enable anti spam with default settings\n" +
+		"`antispam disable` - disable anti spam\n" +
+		"`antispam rate <n> <seconds>` - penalize members sending more than n messages in the given time (0 to disable)\n" +
+		"`antispam dupes <n> <seconds>` - penalize members sending the same message more than n times in the given time (0 to disable)\n" +
+		"`antispam penalty <" + strings.Join(antiSpamPenalties, "|") + ">` - set the penalty applied to spammers"
+}
+
+func (c *CmdAntiSpam) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdAntiSpam) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdAntiSpam) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdAntiSpam) Exec(args *CommandArgs) error {
+	settings, err := args.CmdHandler.db.GetGuildAntiSpam(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	if len(args.Args) < 1 {
+		return c.printStatus(args, settings)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+	case "enable", "e", "on":
+		if settings == nil {
+			settings = util.NewDefaultAntiSpamSettings()
+		}
+	case "disable", "d", "off":
+		settings = nil
+	case "rate", "r":
+		if settings == nil {
+			settings = util.NewDefaultAntiSpamSettings()
+		}
+		if ok, err := c.parseLimit(args, &settings.RateLimit, &settings.RateInterval); !ok || err != nil {
+			return err
+		}
+	case "dupes", "duplicates", "dup":
+		if settings == nil {
+			settings = util.NewDefaultAntiSpamSettings()
+		}
+		if ok, err := c.parseLimit(args, &settings.DuplicateLimit, &settings.DuplicateInterval); !ok || err != nil {
+			return err
+		}
+	case "penalty", "p":
+		if settings == nil {
+			settings = util.NewDefaultAntiSpamSettings()
+		}
+		if ok, err := c.parsePenalty(args, settings); !ok || err != nil {
+			return err
+		}
+	default:
+		return c.printStatus(args, settings)
+	}
+
+	if err = args.CmdHandler.db.SetGuildAntiSpam(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	return c.printStatus(args, settings)
+}
+
+func (c *CmdAntiSpam) parseLimit(args *CommandArgs, limit, interval *int) (bool, error) {
+	if len(args.Args) < 3 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Invalid arguments. Use `help antispam` to get help about how to use this command.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	n, errN := strconv.Atoi(args.Args[1])
+	secs, errSecs := strconv.Atoi(args.Args[2])
+	if errN != nil || errSecs != nil || n < 0 || secs < 1 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter a valid number of messages *(0 or larger)* and a valid number of seconds *(larger than 0)*.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	*limit = n
+	*interval = secs
+
+	return true, nil
+}
+
+func (c *CmdAntiSpam) parsePenalty(args *CommandArgs, settings *util.AntiSpamSettings) (bool, error) {
+	if len(args.Args) < 2 || util.IndexOfStrArray(strings.ToUpper(args.Args[1]), antiSpamPenalties) < 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter one of the following penalties: `"+strings.Join(antiSpamPenalties, "`, `")+"`.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	settings.PenaltyType = util.IndexOfStrArray(strings.ToUpper(args.Args[1]), util.ReportTypes)
+
+	return true, nil
+}
+
+func (c *CmdAntiSpam) printStatus(args *CommandArgs, settings *util.AntiSpamSettings) error {
+	if settings == nil {
+		msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+			"Anti spam is currently **disabled** on this guild.\n\n"+
+				"*You can enable it with the command `antispam enable`.*", "", util.ColorEmbedOrange)
+		util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+		return err
+	}
+
+	limitTxt := func(limit, interval int) string {
+		if limit == 0 {
+			return "*disabled*"
+		}
+		return fmt.Sprintf("more than `%d` messages in `%d` seconds", limit, interval)
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color:       util.ColorEmbedGreen,
+		Title:       "Anti Spam",
+		Description: "Anti spam is currently **enabled** on this guild.",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Message Rate",
+				Value: limitTxt(settings.RateLimit, settings.RateInterval),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Duplicate Messages",
+				Value: limitTxt(settings.DuplicateLimit, settings.DuplicateInterval),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Penalty",
+				Value: util.ReportTypes[settings.PenaltyType],
+			},
+		},
+	}
+
+	msg, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+	util.DeleteMessageLater(args.Session, msg, 15*time.Second)
+	return err
+}
@@ -1,7 +1,11 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"sort"
 	"strconv"
 	"strings"
@@ -14,8 +18,32 @@ import (
 
 const (
 	timeFormat = time.RFC1123
+
+	backupsPageSize = 10
+	// backupIDMinLen is the minimum length of backup IDs
+	// used to distinguish them from backup indexes.
+	backupIDMinLen = 15
+
+	backupFileMaxSize = 8 * 1024 * 1024
+
+	restoreMaxListedDeletions = 20
 )
 
+var backupHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+var backupActionSymbols = map[string]string{
+	core.BackupActionCreate: "+",
+	core.BackupActionEdit:   "~",
+	core.BackupActionDelete: "-",
+	core.BackupActionSkip:   "!",
+}
+
+var backupChangeSymbols = map[string]string{
+	core.BackupChangeAdded:   "+",
+	core.BackupChangeChanged: "~",
+	core.BackupChangeRemoved: "-",
+}
+
 type CmdBackup struct {
 	PermLvl int
 }
@@ -30,8 +58,23 @@ func (c *CmdBackup) GetDescription() string {
 
 func (c *CmdBackup) GetHelp() string {
 	return "`backup <enable|disable>` - enable or disable backups for your guild\n" +
-		"`backup (list)` - list all saved backups\n" +
-		"`backup restore <id>` - restore a backup"
+		"`backup (list) (<page>)` - list all saved backups, the latest backups are shown by default\n" +
+		"`backup now` - create a backup immediately\n" +
+		"`backup schedule <hours>` - set the interval in which backups are created\n" +
+		"`backup retention <last> (<days>) (<weeks>)` - keep the last n backups, the latest backup of each of the last " +
+		"days and the latest backup of each of the last weeks\n" +
+		"`backup restore <id> (--only <parts>) (--prune) (--dry-run)` - restore a backup\n" +
+		"`backup diff <id> (<id>)` - compare a backup with another backup or with the current state of the guild\n" +
+		"`backup download <id>` - upload the backup file\n" +
+		"`backup import` - import a backup file attached to the message\n" +
+		"`backup clone <id> <guildID>` - copy a backup to another guild to restore it there, for example as template\n\n" +
+		"Parts which can be restored selectively are `guild`, `roles`, `channels`, `members`, `emojis`, `bans`, " +
+		"`webhooks` and `settings` (comma separated). Bans are only added and never lifted, re-created webhooks " +
+		"will have new tokens. " +
+		"Restoring `settings` requires the permissions of the `perms` and `prefix` commands. " +
+		"Roles, channels and emojis which are not part of the backup are only deleted when `--prune` is passed, " +
+		"the channel the command is executed in is never deleted. " +
+		"With `--dry-run`, all changes which would be applied are listed without changing anything."
 }
 
 func (c *CmdBackup) GetGroup() string {
@@ -55,6 +98,20 @@ func (c *CmdBackup) Exec(args *CommandArgs) error {
 			return c.switchStatus(args, false)
 		case "r", "restore":
 			return c.restore(args)
+		case "now", "n", "create":
+			return c.backupNow(args)
+		case "schedule", "interval":
+			return c.schedule(args)
+		case "retention", "keep":
+			return c.retention(args)
+		case "diff", "compare":
+			return c.diff(args)
+		case "download", "dl", "export":
+			return c.download(args)
+		case "import", "upload":
+			return c.importBackup(args)
+		case "clone", "copy":
+			return c.clone(args)
 		default:
 			return c.list(args)
 		}
@@ -69,8 +126,12 @@ func (c *CmdBackup) switchStatus(args *CommandArgs, enable bool) error {
 	}
 
 	if enable {
-		msg, err := util.SendEmbed(args.Session, args.Channel.ID, "Enabled backup for this guild.\nA full guild backup *(incl. Members, Roles, Channels and Guild Settings)* "+
-			"will be created every 12 hours. Only 10 backups per guild will be saved, so you will habe the backup files of the last 5 days.", "", util.ColorEmbedGreen)
+		settings, err := c.getSettings(args)
+		if err != nil {
+			return err
+		}
+		msg, err := util.SendEmbed(args.Session, args.Channel.ID, "Enabled backup for this guild.\nA full guild backup *(incl. Members, Roles, Channels, Emojis, Bans, Webhooks, Guild and shinpuru Settings)* "+
+			fmt.Sprintf("will be created every %d hours. %s", settings.Interval, c.retentionText(settings)), "", util.ColorEmbedGreen)
 		util.DeleteMessageLater(args.Session, msg, 15*time.Second)
 		return err
 	}
@@ -81,38 +142,53 @@ func (c *CmdBackup) switchStatus(args *CommandArgs, enable bool) error {
 	return err
 }
 
-func (c *CmdBackup) getBackupsList(args *CommandArgs) ([]*core.BackupEntry, string, error) {
+// getBackupsList returns all backups of the
+// guild sorted by their creation time.
+func (c *CmdBackup) getBackupsList(args *CommandArgs) ([]*core.BackupEntry, error) {
 	backups, err := args.CmdHandler.db.GetBackups(args.Guild.ID)
-	if err != nil && core.IsErrDatabaseNotFound(err) {
-		return nil, "", err
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return nil, err
 	}
 
-	strBackupAll := "*no backups saved*"
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Timestamp.Before(backups[j].Timestamp)
+	})
 
-	if len(backups) > 0 {
-		sort.Slice(backups, func(i, j int) bool {
-			return backups[i].Timestamp.Before(backups[j].Timestamp)
-		})
+	return backups, nil
+}
 
-		if len(backups) > 10 {
-			backups = backups[0:10]
-		}
+// backupsPageText lists the backups of the passed page
+// with their indexes. If the page is out of range, the
+// page of the latest backups is shown. The number of the
+// shown page and the number of pages are returned as well.
+func backupsPageText(backups []*core.BackupEntry, page int) (string, int, int) {
+	if len(backups) == 0 {
+		return "*no backups saved*", 1, 1
+	}
 
-		strBackups := make([]string, len(backups))
+	pages := (len(backups) + backupsPageSize - 1) / backupsPageSize
+	if page < 1 || page > pages {
+		page = pages
+	}
 
-		for i, b := range backups {
-			strBackups[i] = fmt.Sprintf("`%d` - %s - (ID: `%s`)", i, b.Timestamp.Format(timeFormat), b.FileID)
-		}
+	start := (page - 1) * backupsPageSize
+	end := start + backupsPageSize
+	if end > len(backups) {
+		end = len(backups)
+	}
 
-		strBackupAll = strings.Join(strBackups, "\n")
+	lines := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		b := backups[i]
+		lines = append(lines, fmt.Sprintf("`%d` - %s - (ID: `%s`)", i, b.Timestamp.Format(timeFormat), b.FileID))
 	}
 
-	return backups, strBackupAll, nil
+	return strings.Join(lines, "\n"), page, pages
 }
 
 func (c *CmdBackup) list(args *CommandArgs) error {
 	status, err := args.CmdHandler.db.GetGuildBackup(args.Guild.ID)
-	if err != nil && core.IsErrDatabaseNotFound(err) {
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
 		return err
 	}
 
@@ -121,21 +197,42 @@ func (c *CmdBackup) list(args *CommandArgs) error {
 		strStatus = ":white_check_mark:  Backups **enabled**"
 	}
 
-	_, strBackupAll, err := c.getBackupsList(args)
+	settings, err := c.getSettings(args)
 	if err != nil {
 		return err
 	}
 
+	backups, err := c.getBackupsList(args)
+	if err != nil {
+		return err
+	}
+
+	page := 0
+	if len(args.Args) > 1 {
+		if page, err = strconv.Atoi(args.Args[1]); err != nil || page < 1 {
+			return c.sendError(args, "The page must be a number larger than 0.")
+		}
+	}
+
+	strBackupAll, page, pages := backupsPageText(backups, page)
+
 	emb := &discordgo.MessageEmbed{
 		Color:       util.ColorEmbedDefault,
 		Title:       "Backups",
 		Description: strStatus,
 		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Schedule",
+				Value: fmt.Sprintf("Every %d hours. %s", settings.Interval, c.retentionText(settings)),
+			},
 			&discordgo.MessageEmbedField{
 				Name:  "Saved Backups",
 				Value: strBackupAll,
 			},
 		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d • Use 'backup list <page>' to show other pages.", page, pages),
+		},
 	}
 
 	_, err = args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
@@ -149,45 +246,67 @@ func (c *CmdBackup) restore(args *CommandArgs) error {
 		return err
 	}
 
-	backups, _, err := c.getBackupsList(args)
-	if err != nil {
+	backup, err := c.findBackup(args, args.Args[1])
+	if backup == nil {
 		return err
 	}
 
-	i, err := strconv.ParseInt(args.Args[1], 10, 64)
-	if err != nil {
-		return err
+	parts := core.BackupPartAll
+	partsSelected := false
+	dryRun := false
+	prune := false
+	argv := args.Args[2:]
+	for i := 0; i < len(argv); i++ {
+		a := strings.ToLower(argv[i])
+		switch {
+		case a == "--dry-run":
+			dryRun = true
+		case a == "--prune":
+			prune = true
+		case a == "--only" || strings.HasPrefix(a, "--only="):
+			list := strings.TrimPrefix(a, "--only=")
+			if a == "--only" {
+				if i+1 >= len(argv) {
+					return c.sendError(args, "Please specify the parts to restore after `--only`.")
+				}
+				i++
+				list = argv[i]
+			}
+			if parts, err = core.ParseBackupParts(list); err != nil {
+				return c.sendError(args, fmt.Sprintf("%s.\nAvailable parts are `%s`.",
+					err.Error(), strings.Join(core.BackupPartNames, "`, `")))
+			}
+			partsSelected = true
+		default:
+			return c.sendError(args, fmt.Sprintf("Unknown argument `%s`.", argv[i]))
+		}
 	}
 
-	if i < 0 {
-		msg, err := util.SendEmbedError(args.Session, args.Channel.ID, "Argument must be an index between 0 and 9 or a snowflake ID.")
-		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
-		return err
+	if dryRun {
+		return c.dryRun(args, backup, parts, prune)
 	}
 
-	var backup *core.BackupEntry
-
-	if i < 10 {
-		if int64(len(backups)-1) < i {
-			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-				fmt.Sprintf("There are only %d (index 0 to %d) backups you can chose from.", len(backups), len(backups)-1))
-			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
-			return err
-		}
-		backup = backups[i]
-	} else {
-		for _, b := range backups {
-			if b.FileID == args.Args[1] {
-				backup = b
-			}
+	// Restoring the settings changes the prefix and role
+	// permission levels, so the permissions of the commands
+	// managing them are required as well.
+	settingsNote := ""
+	if parts&core.BackupPartSettings != 0 && !c.canRestoreSettings(args) {
+		if partsSelected {
+			return c.sendError(args, "You need the permissions of the `perms` and `prefix` commands to restore `settings`.")
 		}
+		parts &^= core.BackupPartSettings
+		settingsNote = "\n\n`settings` are not restored because you lack the permissions " +
+			"of the `perms` and `prefix` commands."
 	}
 
-	if backup == nil {
-		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-			fmt.Sprintf("Could not find any backup by this specifier: ```\n%s\n```", args.Args[1]))
-		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
-		return err
+	deletions := "Roles, channels and emojis which are not part of the backup are **kept**. " +
+		"Use `--prune` to delete them."
+	if prune {
+		actions, err := args.CmdHandler.bck.PlanRestore(args.Guild.ID, backup.FileID, parts, true, args.Channel.ID)
+		if err != nil {
+			return err
+		}
+		deletions = deletionsText(actions)
 	}
 
 	accMsg := &util.AcceptMessage{
@@ -198,14 +317,15 @@ func (c *CmdBackup) restore(args *CommandArgs) error {
 			Color: util.ColorEmbedOrange,
 			Description: fmt.Sprintf(":warning:  **WARNING**  :warning:\n\n"+
 				"By pressing :white_check_mark:, the structure of this guild will be **reset** to the selected backup:\n\n"+
-				"%s - (ID: `%s`)", backup.Timestamp.Format(timeFormat), backup.FileID),
+				"%s - (ID: `%s`)\n\nRestored parts: **%s**%s\n\n%s", backup.Timestamp.Format(timeFormat), backup.FileID,
+				core.BackupPartsString(parts), settingsNote, deletions),
 		},
 		DeclineFunc: func(m *discordgo.Message) {
 			cMsg, _ := util.SendEmbedError(args.Session, args.Channel.ID, "Canceled.")
 			util.DeleteMessageLater(args.Session, cMsg, 6*time.Second)
 		},
 		AcceptFunc: func(m *discordgo.Message) {
-			c.proceedRestore(args, backup.FileID)
+			c.proceedRestore(args, backup.FileID, parts, prune)
 		},
 	}
 
@@ -213,42 +333,507 @@ func (c *CmdBackup) restore(args *CommandArgs) error {
 	return err
 }
 
-func (c *CmdBackup) proceedRestore(args *CommandArgs, fileID string) {
+// findBackup returns the backup entry by the passed index
+// or ID. If no backup was found, an error message is sent
+// and nil is returned.
+func (c *CmdBackup) findBackup(args *CommandArgs, spec string) (*core.BackupEntry, error) {
+	backups, err := c.getBackupsList(args)
+	if err != nil {
+		return nil, err
+	}
+
+	i, err := strconv.ParseInt(spec, 10, 64)
+	if err != nil || i < 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID, "Argument must be an index or a snowflake ID.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return nil, err
+	}
+
+	var backup *core.BackupEntry
+
+	if i < int64(len(backups)) {
+		backup = backups[i]
+	} else {
+		for _, b := range backups {
+			if b.FileID == spec {
+				backup = b
+			}
+		}
+	}
+
+	if backup == nil && len(spec) < backupIDMinLen {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			fmt.Sprintf("There are only %d (index 0 to %d) backups you can chose from.", len(backups), len(backups)-1))
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return nil, err
+	}
+
+	if backup == nil {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			fmt.Sprintf("Could not find any backup by this specifier: ```\n%s\n```", spec))
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+// canRestoreSettings returns true if the executor has the
+// permissions of all commands managing restored settings.
+func (c *CmdBackup) canRestoreSettings(args *CommandArgs) bool {
+	for _, invoke := range []string{"perms", "prefix"} {
+		if !args.CmdHandler.CommandPermissionFunc(args.Session, invoke)(args.Guild.ID, args.User.ID) {
+			return false
+		}
+	}
+	return true
+}
+
+// deletionsText returns a list of all deletions of the
+// passed restore plan, shortened to restoreMaxListedDeletions
+// entries.
+func deletionsText(actions []*core.BackupPlanAction) string {
+	lines := make([]string, 0)
+	for _, a := range actions {
+		if a.Action == core.BackupActionDelete {
+			lines = append(lines, fmt.Sprintf("%s: %s", a.Part, a.Name))
+		}
+	}
+
+	if len(lines) == 0 {
+		return "Nothing will be deleted."
+	}
+
+	n := len(lines)
+	more := ""
+	if n > restoreMaxListedDeletions {
+		more = fmt.Sprintf("\n... and %d more", n-restoreMaxListedDeletions)
+		lines = lines[:restoreMaxListedDeletions]
+	}
+
+	return fmt.Sprintf("The following **%d** objects will be **deleted**:\n```\n%s%s\n```",
+		n, strings.Join(lines, "\n"), more)
+}
+
+func (c *CmdBackup) dryRun(args *CommandArgs, backup *core.BackupEntry, parts int, prune bool) error {
+	actions, err := args.CmdHandler.bck.PlanRestore(args.Guild.ID, backup.FileID, parts, prune, args.Channel.ID)
+	if err != nil {
+		return err
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Restore Plan",
+		Description: fmt.Sprintf("Changes which would be applied by restoring **%s** of backup `%s`:",
+			core.BackupPartsString(parts), backup.FileID),
+	}
+
+	if len(actions) == 0 {
+		emb.Description = "Restoring this backup would not change anything."
+		_, err = args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+		return err
+	}
+
+	lines := make(map[string][]string)
+	for _, a := range actions {
+		lines[a.Part] = append(lines[a.Part], changeLine(backupActionSymbols[a.Action], a.Name, a.Details))
+	}
+
+	return sendChanges(args, emb, fmt.Sprintf("restore-plan-%s.txt", backup.FileID), lines)
+}
+
+func (c *CmdBackup) diff(args *CommandArgs) error {
+	if len(args.Args) < 2 {
+		return c.sendError(args, "Please specify the index or the ID of the backup to compare.")
+	}
+
+	backupA, err := c.findBackup(args, args.Args[1])
+	if backupA == nil {
+		return err
+	}
+
+	var fileIDB string
+	nameB := "the current state of the guild"
+	if len(args.Args) > 2 {
+		backupB, err := c.findBackup(args, args.Args[2])
+		if backupB == nil {
+			return err
+		}
+		fileIDB = backupB.FileID
+		nameB = fmt.Sprintf("backup `%s` (%s)", backupB.FileID, backupB.Timestamp.Format(timeFormat))
+	}
+
+	diff, err := args.CmdHandler.bck.DiffBackup(args.Guild.ID, backupA.FileID, fileIDB)
+	if err != nil {
+		return err
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Backup Diff",
+		Description: fmt.Sprintf("Differences of backup `%s` (%s) to %s:",
+			backupA.FileID, backupA.Timestamp.Format(timeFormat), nameB),
+	}
+
+	if len(diff) == 0 {
+		emb.Description = fmt.Sprintf("There are no differences between backup `%s` and %s.", backupA.FileID, nameB)
+		_, err = args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+		return err
+	}
+
+	lines := make(map[string][]string)
+	for _, d := range diff {
+		name := d.Name
+		if d.Part == "members" {
+			name = c.memberName(args, d.Name)
+		}
+		lines[d.Part] = append(lines[d.Part], changeLine(backupChangeSymbols[d.Change], name, d.Details))
+	}
+
+	return sendChanges(args, emb, fmt.Sprintf("backup-diff-%s.txt", backupA.FileID), lines)
+}
+
+func (c *CmdBackup) download(args *CommandArgs) error {
+	if len(args.Args) < 2 {
+		return c.sendError(args, "Please specify the index or the ID of the backup to download.")
+	}
+
+	backup, err := c.findBackup(args, args.Args[1])
+	if backup == nil {
+		return err
+	}
+
+	data, err := args.CmdHandler.bck.BackupFile(args.Guild.ID, backup.FileID)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > backupFileMaxSize {
+		return c.sendError(args, "The backup file is too large to be uploaded.")
+	}
+
+	_, err = args.Session.ChannelMessageSendComplex(args.Channel.ID, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Color: util.ColorEmbedDefault,
+			Description: fmt.Sprintf("Backup `%s` from %s.\nUse `backup import` with this file attached "+
+				"to import it into another guild.", backup.FileID, backup.Timestamp.Format(timeFormat)),
+		},
+		Files: []*discordgo.File{
+			&discordgo.File{
+				Name:        fmt.Sprintf("backup-%s-%s.bck", args.Guild.ID, backup.FileID),
+				ContentType: "application/octet-stream",
+				Reader:      bytes.NewReader(data),
+			},
+		},
+	})
+	return err
+}
+
+func (c *CmdBackup) importBackup(args *CommandArgs) error {
+	if len(args.Message.Attachments) == 0 {
+		return c.sendError(args, "Please attach the backup file to the message.")
+	}
+
+	att := args.Message.Attachments[0]
+	if att.Size > backupFileMaxSize {
+		return c.sendError(args, "The attached file is too large.")
+	}
+
+	resp, err := backupHTTPClient.Get(att.URL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed downloading attachment: %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, backupFileMaxSize))
+	if err != nil {
+		return err
+	}
+
+	backupID, err := args.CmdHandler.bck.ImportBackup(args.Guild.ID, data)
+	if err != nil {
+		return c.sendError(args, "The attached file is not a valid backup: ```\n"+err.Error()+"\n```")
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Backup imported with ID `%s`.\nUse `backup restore %s` to restore it.", backupID, backupID),
+		"", util.ColorEmbedGreen)
+	util.DeleteMessageLater(args.Session, msg, 15*time.Second)
+	return err
+}
+
+func (c *CmdBackup) clone(args *CommandArgs) error {
+	if len(args.Args) < 3 {
+		return c.sendError(args, "Please specify the index or the ID of the backup and the ID of the target guild.")
+	}
+
+	backup, err := c.findBackup(args, args.Args[1])
+	if backup == nil {
+		return err
+	}
+
+	target, err := args.Session.State.Guild(args.Args[2])
+	if err != nil {
+		return c.sendError(args, "The target guild could not be found. Make sure that shinpuru is a member of it.")
+	}
+	if target.ID == args.Guild.ID {
+		return c.sendError(args, "The target guild must be a different guild.")
+	}
+
+	permLvl, err := args.CmdHandler.GetPermissionLevel(args.Session, target.ID, args.User.ID)
+	if err != nil {
+		return err
+	}
+	if permLvl < c.PermLvl {
+		return c.sendError(args, "You need the permission to manage backups on the target guild.")
+	}
+
+	backupID, err := args.CmdHandler.bck.CloneBackup(args.Guild.ID, backup.FileID, target.ID)
+	if err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Backup copied to **%s** with ID `%s`.\nUse `backup restore %s` on this guild to apply it. "+
+			"Roles and channels are matched by their names, so the backup can be used as a template.",
+			target.Name, backupID, backupID),
+		"", util.ColorEmbedGreen)
+	util.DeleteMessageLater(args.Session, msg, 20*time.Second)
+	return err
+}
+
+func (c *CmdBackup) memberName(args *CommandArgs, userID string) string {
+	if m, err := args.Session.State.Member(args.Guild.ID, userID); err == nil && m.User != nil {
+		return m.User.String()
+	}
+	return userID
+}
+
+func changeLine(symbol, name, details string) string {
+	line := fmt.Sprintf("%s %s", symbol, name)
+	if details != "" {
+		line += " (" + details + ")"
+	}
+	return line
+}
+
+// sendChanges sends the embed with the change lines grouped by
+// backup parts as fields. If the lines exceed the embed limits,
+// the full list is attached as text file.
+func sendChanges(args *CommandArgs, emb *discordgo.MessageEmbed, fileName string, lines map[string][]string) error {
+	fits := true
+	for _, part := range core.BackupPartNames {
+		if l, ok := lines[part]; ok {
+			value := "```diff\n" + strings.Join(l, "\n") + "\n```"
+			if len(value) > 1024 {
+				fits = false
+				value = fmt.Sprintf("*%d changes*", len(l))
+			}
+			emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+				Name:  strings.Title(part),
+				Value: value,
+			})
+		}
+	}
+
+	if fits {
+		_, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+		return err
+	}
+
+	var file strings.Builder
+	for _, part := range core.BackupPartNames {
+		if l, ok := lines[part]; ok {
+			file.WriteString(fmt.Sprintf("[%s]\n%s\n\n", part, strings.Join(l, "\n")))
+		}
+	}
+
+	emb.Footer = &discordgo.MessageEmbedFooter{
+		Text: "The full list is attached as file.",
+	}
+	_, err := args.Session.ChannelMessageSendComplex(args.Channel.ID, &discordgo.MessageSend{
+		Embed: emb,
+		Files: []*discordgo.File{
+			&discordgo.File{
+				Name:        fileName,
+				ContentType: "text/plain",
+				Reader:      strings.NewReader(file.String()),
+			},
+		},
+	})
+	return err
+}
+
+func (c *CmdBackup) sendError(args *CommandArgs, txt string) error {
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+	util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+	return err
+}
+
+func (c *CmdBackup) proceedRestore(args *CommandArgs, fileID string, parts int, prune bool) {
 	statusChan := make(chan string)
 	errorsChan := make(chan error)
 
+	watchRestoreStatus(args, "initializing backup restoring...", "restoring backup", statusChan, errorsChan)
+
+	err := args.CmdHandler.bck.RestoreBackup(args.Guild.ID, fileID, parts, prune, args.Channel.ID, statusChan, errorsChan)
+	if err != nil {
+		util.SendEmbedError(args.Session, args.Channel.ID,
+			fmt.Sprintf("An unexpected error occured while restoring backup: ```\n%s\n```", err.Error()))
+	}
+}
+
+// watchRestoreStatus sends a status message which is updated
+// with the statuses received from statusChan. Errors received
+// from errorsChan are sent as error messages.
+func watchRestoreStatus(args *CommandArgs, initStatus, process string, statusChan chan string, errorsChan chan error) {
 	statusMsg, _ := args.Session.ChannelMessageSendEmbed(args.Channel.ID,
 		&discordgo.MessageEmbed{
 			Color:       util.ColorEmbedGray,
-			Description: "initializing backup restoring...",
+			Description: initStatus,
 		})
 
-	if statusMsg != nil {
-		go func() {
-			for {
-				select {
-				case status, ok := <-statusChan:
-					if !ok {
-						continue
-					}
-					args.Session.ChannelMessageEditEmbed(statusMsg.ChannelID, statusMsg.ID, &discordgo.MessageEmbed{
-						Color:       util.ColorEmbedGray,
-						Description: status + "...",
-					})
-				case err, ok := <-errorsChan:
-					if !ok || err == nil {
-						continue
-					}
+	if statusMsg == nil {
+		return
+	}
+
+	go func() {
+		for statusChan != nil || errorsChan != nil {
+			select {
+			case status, ok := <-statusChan:
+				if !ok {
+					statusChan = nil
+					continue
+				}
+				args.Session.ChannelMessageEditEmbed(statusMsg.ChannelID, statusMsg.ID, &discordgo.MessageEmbed{
+					Color:       util.ColorEmbedGray,
+					Description: status + "...",
+				})
+			case err, ok := <-errorsChan:
+				if !ok {
+					errorsChan = nil
+					continue
+				}
+				if err != nil {
 					util.SendEmbedError(args.Session, args.Channel.ID,
-						"An unexpected error occured while restoring backup (process will not be aborted): ```\n"+err.Error()+"\n```")
+						"An unexpected error occured while "+process+" (process will not be aborted): ```\n"+err.Error()+"\n```")
 				}
 			}
-		}()
+		}
+	}()
+}
+
+func (c *CmdBackup) getSettings(args *CommandArgs) (*util.BackupSettings, error) {
+	settings, err := args.CmdHandler.db.GetGuildBackupSettings(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		return util.NewDefaultBackupSettings(), nil
 	}
+	return settings, err
+}
 
-	err := args.CmdHandler.bck.RestoreBackup(args.Guild.ID, fileID, statusChan, errorsChan)
+func (c *CmdBackup) retentionText(settings *util.BackupSettings) string {
+	txt := fmt.Sprintf("The last %d backups", settings.KeepLast)
+	if settings.KeepDaily > 0 {
+		txt += fmt.Sprintf(", one backup per day for %d days", settings.KeepDaily)
+	}
+	if settings.KeepWeekly > 0 {
+		txt += fmt.Sprintf(", one backup per week for %d weeks", settings.KeepWeekly)
+	}
+	return txt + " will be kept."
+}
+
+func (c *CmdBackup) backupNow(args *CommandArgs) error {
+	statusMsg, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, &discordgo.MessageEmbed{
+		Color:       util.ColorEmbedGray,
+		Description: "creating backup...",
+	})
 	if err != nil {
-		util.SendEmbedError(args.Session, args.Channel.ID,
-			fmt.Sprintf("An unexpected error occured while restoring backup: ```\n%s\n```", err.Error()))
+		return err
+	}
+
+	if err = args.CmdHandler.bck.BackupGuild(args.Guild.ID); err != nil {
+		args.Session.ChannelMessageDelete(statusMsg.ChannelID, statusMsg.ID)
+		return err
+	}
+
+	_, err = args.Session.ChannelMessageEditEmbed(statusMsg.ChannelID, statusMsg.ID, &discordgo.MessageEmbed{
+		Color:       util.ColorEmbedGreen,
+		Description: "Backup created. Use `backup list` to see all saved backups.",
+	})
+	util.DeleteMessageLater(args.Session, statusMsg, 8*time.Second)
+	return err
+}
+
+func (c *CmdBackup) schedule(args *CommandArgs) error {
+	var hours int
+	var err error
+	if len(args.Args) > 1 {
+		hours, err = strconv.Atoi(args.Args[1])
 	}
+	if len(args.Args) < 2 || err != nil || hours < 1 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter a valid interval in hours larger than 0.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	settings, err := c.getSettings(args)
+	if err != nil {
+		return err
+	}
+
+	settings.Interval = hours
+	if err = args.CmdHandler.db.SetGuildBackupSettings(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Backups will now be created every %d hours.", hours), "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdBackup) retention(args *CommandArgs) error {
+	if len(args.Args) < 2 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter the number of last backups to keep.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	values := make([]int, 3)
+	for i, a := range args.Args[1:] {
+		if i >= len(values) {
+			break
+		}
+		v, err := strconv.Atoi(a)
+		if err != nil || v < 0 || (i == 0 && v < 1) {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Please enter valid numbers. The number of last backups must be larger than 0.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return err
+		}
+		values[i] = v
+	}
+
+	settings, err := c.getSettings(args)
+	if err != nil {
+		return err
+	}
+
+	settings.KeepLast = values[0]
+	settings.KeepDaily = values[1]
+	settings.KeepWeekly = values[2]
+	if err = args.CmdHandler.db.SetGuildBackupSettings(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		"Updated backup retention. "+c.retentionText(settings), "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
 	"github.com/zekroTJA/shinpuru/internal/util"
 )
 
@@ -52,22 +53,7 @@ func (c *CmdBan) Exec(args *CommandArgs) error {
 		return err
 	}
 
-	if victim.User.ID == args.User.ID {
-		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-			"You can not ban yourself...")
-		util.DeleteMessageLater(args.Session, msg, 6*time.Second)
-		return err
-	}
-
-	authorMemb, err := args.Session.GuildMember(args.Guild.ID, args.User.ID)
-	if err != nil {
-		return err
-	}
-
-	if util.RolePosDiff(victim, authorMemb, args.Guild) >= 0 {
-		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-			"You can only ban members with lower permissions than yours.")
-		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	if ok, err := checkModTarget(args, victim, "ban", true); !ok || err != nil {
 		return err
 	}
 
@@ -144,6 +130,10 @@ func (c *CmdBan) Exec(args *CommandArgs) error {
 					"Failed creating ban: ```\n"+err.Error()+"\n```")
 				return
 			}
+			if err = core.PublishBan(args.Session, args.CmdHandler.db, rep,
+				args.CmdHandler.BotModTargetFunc(args.Session, "ban")); err != nil {
+				util.Log.Errorf("failed publishing ban of %s on guild %s: %s", rep.VictimID, rep.GuildID, err.Error())
+			}
 		},
 	}
 
@@ -151,3 +141,32 @@ func (c *CmdBan) Exec(args *CommandArgs) error {
 
 	return err
 }
+
+// banWithReport creates a report of the passed type executed
+// by the command's author and bans the user afterwards, so
+// that the victim still receives the report DM. If the ban
+// fails, the report is deleted again.
+func banWithReport(args *CommandArgs, userID, repTypeName, reason string, deleteDays int) (*util.Report, error) {
+	repType := util.IndexOfStrArray(repTypeName, util.ReportTypes)
+	rep := &util.Report{
+		ID:         util.NodesReport[repType].Generate(),
+		Type:       repType,
+		GuildID:    args.Guild.ID,
+		ExecutorID: args.User.ID,
+		VictimID:   userID,
+		Msg:        reason,
+	}
+
+	if err := core.PushReport(args.Session, args.CmdHandler.db, rep); err != nil {
+		return nil, err
+	}
+
+	if err := args.Session.GuildBanCreateWithReason(args.Guild.ID, userID, reason, deleteDays); err != nil {
+		if dErr := args.CmdHandler.db.DeleteReport(rep.ID); dErr != nil {
+			util.Log.Errorf("failed deleting %s report of %s on guild %s: %s", repTypeName, userID, args.Guild.ID, dErr.Error())
+		}
+		return nil, err
+	}
+
+	return rep, nil
+}
@@ -0,0 +1,315 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdBanList struct {
+	PermLvl int
+}
+
+func (c *CmdBanList) GetInvokes() []string {
+	return []string{"banlist", "bl", "sharedbans"}
+}
+
+func (c *CmdBanList) GetDescription() string {
+	return "publish and subscribe to ban lists shared across guilds"
+}
+
+func (c *CmdBanList) GetHelp() string {
+	return "`banlist` - display publish state and subscriptions of this guild\n" +
+		"`banlist publish` - publish bans of this guild so that other guilds can subscribe to them\n" +
+		"`banlist unpublish` - stop publishing bans of this guild\n" +
+		"`banlist subscribe <guildID|global> (auto|confirm)` - subscribe to a ban list " +
+		"*(`auto` bans directly, `confirm` sends an alert to the mod log channel; default: `confirm`)*\n" +
+		"`banlist unsubscribe <guildID|global>` - unsubscribe from a ban list\n" +
+		"`banlist show (<guildID|global>)` - display the latest entries of a published ban list\n" +
+		"`banlist global <userID> (<reason>)` - add a user to the global ban list *(bot owner only)*"
+}
+
+func (c *CmdBanList) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdBanList) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdBanList) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdBanList) Exec(args *CommandArgs) error {
+	if len(args.Args) < 1 {
+		return c.printStatus(args)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+	case "publish", "pub":
+		return c.setPublished(args, true)
+	case "unpublish", "unpub":
+		return c.setPublished(args, false)
+	case "subscribe", "sub":
+		return c.subscribe(args)
+	case "unsubscribe", "unsub":
+		return c.unsubscribe(args)
+	case "show", "list", "ls":
+		return c.show(args)
+	case "global":
+		return c.addGlobal(args)
+	default:
+		return c.printStatus(args)
+	}
+}
+
+func (c *CmdBanList) setPublished(args *CommandArgs, published bool) error {
+	if err := args.CmdHandler.db.SetGuildBanListPublished(args.Guild.ID, published); err != nil {
+		return err
+	}
+
+	txt := "Bans on this guild will now be published to the ban list of this guild.\n" +
+		"Other guilds can subscribe with the command `banlist subscribe " + args.Guild.ID + "`."
+	if !published {
+		txt = "Bans on this guild will not be published anymore."
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID, txt, "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+	return err
+}
+
+func (c *CmdBanList) subscribe(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	if len(args.Args) < 2 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please specify the ID of the guild which ban list you want to subscribe to or `global`.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	sourceID := strings.ToLower(args.Args[1])
+	if sourceID == args.Guild.ID {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"You can not subscribe to the ban list of this guild.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	if sourceID != core.BanListGlobal {
+		published, err := db.GetGuildBanListPublished(sourceID)
+		if err != nil && !core.IsErrDatabaseNotFound(err) {
+			return err
+		}
+		if !published {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"The guild with this ID does not publish its ban list.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return err
+		}
+	}
+
+	mode := core.BanListModeConfirm
+	if len(args.Args) > 2 {
+		mode = strings.ToLower(args.Args[2])
+		if mode != core.BanListModeAuto && mode != core.BanListModeConfirm {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Please enter one of the following modes: `auto`, `confirm`.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return err
+		}
+	}
+
+	err := db.SetBanListSubscription(&core.BanListSubscription{
+		GuildID:  args.Guild.ID,
+		SourceID: sourceID,
+		Mode:     mode,
+	})
+	if err != nil {
+		return err
+	}
+
+	txt := fmt.Sprintf("Subscribed to the ban list of **%s** in `%s` mode.",
+		core.BanListName(args.Session, sourceID), mode)
+	if mode == core.BanListModeConfirm {
+		txt += "\n\n*Alerts will be sent to the mod log channel, so please make sure it is set.*"
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID, txt, "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+	return err
+}
+
+func (c *CmdBanList) unsubscribe(args *CommandArgs) error {
+	if len(args.Args) < 2 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please specify the ID of the guild which ban list you want to unsubscribe from or `global`.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	sourceID := strings.ToLower(args.Args[1])
+	if err := args.CmdHandler.db.DeleteBanListSubscription(args.Guild.ID, sourceID); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Unsubscribed from the ban list of **%s**.", core.BanListName(args.Session, sourceID)),
+		"", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdBanList) show(args *CommandArgs) error {
+	sourceID := args.Guild.ID
+	if len(args.Args) > 1 {
+		sourceID = strings.ToLower(args.Args[1])
+	}
+
+	// Ban lists of other guilds can only be viewed if they
+	// are published or if the author is a member of the guild.
+	if sourceID != core.BanListGlobal && sourceID != args.Guild.ID {
+		published, err := args.CmdHandler.db.GetGuildBanListPublished(sourceID)
+		if err != nil && !core.IsErrDatabaseNotFound(err) {
+			return err
+		}
+		if !published {
+			if _, err = args.Session.GuildMember(sourceID, args.User.ID); err != nil {
+				msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+					"The guild with this ID does not publish its ban list.")
+				util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+				return err
+			}
+		}
+	}
+
+	entries, err := args.CmdHandler.db.GetBanListEntries(sourceID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Ban List of " + core.BanListName(args.Session, sourceID),
+	}
+
+	if len(entries) == 0 {
+		emb.Description = "*This ban list is empty.*"
+	}
+
+	for i, e := range entries {
+		if i >= 20 {
+			emb.Footer = &discordgo.MessageEmbedFooter{
+				Text: fmt.Sprintf("Showing the latest 20 of %d entries.", len(entries)),
+			}
+			break
+		}
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name: e.UserID,
+			Value: fmt.Sprintf("<@%s> banned by <@%s> on %s\n%s",
+				e.UserID, e.ExecutorID, e.Timestamp.Format(time.RFC1123), util.EnsureNotEmpty(e.Reason, "*no reason specified*")),
+		})
+	}
+
+	_, err = args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+	return err
+}
+
+func (c *CmdBanList) addGlobal(args *CommandArgs) error {
+	if args.User.ID != args.CmdHandler.config.Discord.OwnerID {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Only the bot owner can add users to the global ban list.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	if len(args.Args) < 2 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please specify the ID of the user you want to add to the global ban list.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	user, err := args.Session.User(args.Args[1])
+	if err != nil {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Could not find any user with this ID.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	entry := &core.BanListEntry{
+		SourceID:   core.BanListGlobal,
+		UserID:     user.ID,
+		ExecutorID: args.User.ID,
+		Reason:     strings.Join(args.Args[2:], " "),
+		Timestamp:  time.Now(),
+	}
+
+	if err = args.CmdHandler.db.AddBanListEntry(entry); err != nil {
+		return err
+	}
+
+	if err = core.PropagateBan(args.Session, args.CmdHandler.db, entry,
+		args.CmdHandler.BotModTargetFunc(args.Session, "ban")); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Added %s to the global ban list.", user.String()), "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdBanList) printStatus(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	published, err := db.GetGuildBanListPublished(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	subs, err := db.GetBanListSubscriptions(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	publishedTxt := "not published"
+	if published {
+		publishedTxt = "**published** *(guild ID: `" + args.Guild.ID + "`)*"
+	}
+
+	subsTxt := "*no subscriptions*"
+	if len(subs) > 0 {
+		lines := make([]string, len(subs))
+		for i, sub := range subs {
+			lines[i] = fmt.Sprintf("**%s** *(`%s`)* - `%s`", core.BanListName(args.Session, sub.SourceID), sub.SourceID, sub.Mode)
+		}
+		subsTxt = strings.Join(lines, "\n")
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Ban Lists",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Ban List of this Guild",
+				Value: publishedTxt,
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Subscriptions",
+				Value: subsTxt,
+			},
+		},
+	}
+
+	msg, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+	util.DeleteMessageLater(args.Session, msg, 15*time.Second)
+	return err
+}
@@ -2,17 +2,40 @@ package commands
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekroTJA/shinpuru/internal/util"
 )
 
+var rxClearLink = regexp.MustCompile(`(?i)https?://\S+`)
+
+var clearDateLayouts = []string{"2006-01-02T15:04", "2006-01-02"}
+
+// clearMaxMessageAge is the maximum age of messages which
+// can be bulk deleted. Discord rejects bulk deletions of
+// messages older than 14 days, so a small margin is kept.
+const clearMaxMessageAge = 14*24*time.Hour - time.Hour
+
 type CmdClear struct {
 	PermLvl int
 }
 
+type clearFilter struct {
+	member      *discordgo.Member
+	bots        bool
+	attachments bool
+	links       bool
+	rx          *regexp.Regexp
+	afterID     string
+	from        time.Time
+	to          time.Time
+	html        bool
+}
+
 func (c *CmdClear) GetInvokes() []string {
 	return []string{"clear", "c", "purge"}
 }
@@ -24,7 +47,16 @@ func (c *CmdClear) GetDescription() string {
 func (c *CmdClear) GetHelp() string {
 	return "`clear` - delete last message\n" +
 		"`clear <n>` - clear an ammount of messages\n" +
-		"`clear <n> <userResolvable>` - clear an ammount of messages by a specific user"
+		"`clear <n> <userResolvable>` - clear an ammount of messages by a specific user\n" +
+		"`clear <n> (<userResolvable>) <filters...>` - clear messages of the last n messages matching all passed filters\n\n" +
+		"**Filters:**\n" +
+		"`--bots` - only messages sent by bots\n" +
+		"`--attachments` - only messages with attachments\n" +
+		"`--links` - only messages containing links\n" +
+		"`--regex \"<pattern>\"` - only messages matching the regular expression\n" +
+		"`--after <messageID>` - only messages sent after the given message\n" +
+		"`--from <date>` / `--to <date>` - only messages sent in the date range *(format: `2006-01-02` or `2006-01-02T15:04`, UTC)*\n" +
+		"`--html` - archive the cleared messages as HTML instead of text transcript in the mod log channel"
 }
 
 func (c *CmdClear) GetGroup() string {
@@ -41,51 +73,51 @@ func (c *CmdClear) SetPermission(permLvl int) {
 
 func (c *CmdClear) Exec(args *CommandArgs) error {
 	var msgsStructs []*discordgo.Message
-	var err error
+	filter := new(clearFilter)
 
 	if len(args.Args) == 0 {
+		var err error
 		msgsStructs, err = args.Session.ChannelMessages(args.Channel.ID, 1, "", "", "")
-	} else {
-		var memb *discordgo.Member
-		n, err := strconv.Atoi(args.Args[0])
 		if err != nil {
-			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-				"Sorry, but the member can not be found on this guild. :cry:")
-			util.DeleteMessageLater(args.Session, msg, 10*time.Second)
 			return err
-		} else if n < 0 || n > 100 {
+		}
+	} else {
+		n, err := strconv.Atoi(args.Args[0])
+		if err != nil || n < 0 || n > 100 {
 			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
 				"Number of messages is invald and must be between *(including)* 0 and 100.")
 			util.DeleteMessageLater(args.Session, msg, 10*time.Second)
 			return err
 		}
 
-		if len(args.Args) >= 2 {
-			memb, err = util.FetchMember(args.Session, args.Guild.ID, args.Args[1])
-			if err != nil {
-				msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-					"Sorry, but the member can not be found on this guild. :cry:")
-				util.DeleteMessageLater(args.Session, msg, 10*time.Second)
-				return err
-			}
+		if ok, err := c.parseFilter(args, filter); !ok || err != nil {
+			return err
 		}
-		msgsStructsUnsorted, err := args.Session.ChannelMessages(args.Channel.ID, n, "", "", "")
+
+		msgsStructsUnfiltered, err := args.Session.ChannelMessages(args.Channel.ID, n, "", filter.afterID, "")
 		if err != nil {
 			return err
 		}
 
-		if memb != nil {
-			for _, m := range msgsStructsUnsorted {
-				if m.Author.ID == memb.User.ID {
-					msgsStructs = append(msgsStructs, m)
-				}
+		for _, m := range msgsStructsUnfiltered {
+			if filter.matches(m) {
+				msgsStructs = append(msgsStructs, m)
 			}
-		} else {
-			msgsStructs = msgsStructsUnsorted
 		}
 	}
 
-	if err != nil {
+	if len(msgsStructs) == 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"No messages found matching the passed filters.")
+		util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+		return err
+	}
+
+	msgsStructs, nTooOld := filterClearableMessages(msgsStructs, time.Now())
+	if len(msgsStructs) == 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"All matching messages are older than 14 days and can not be deleted in bulk.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
 		return err
 	}
 
@@ -94,19 +126,208 @@ func (c *CmdClear) Exec(args *CommandArgs) error {
 		msgs[i] = m.ID
 	}
 
-	err = args.Session.ChannelMessagesBulkDelete(args.Channel.ID, msgs)
+	err := args.Session.ChannelMessagesBulkDelete(args.Channel.ID, msgs)
 	if err != nil {
 		return err
 	}
 
+	c.archive(args, msgsStructs, filter.html)
+
 	multipleMsgs := ""
 	if len(msgs) > 1 {
 		multipleMsgs = "s"
 	}
 
-	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
-		fmt.Sprintf("Deleted %d message%s.", len(msgs), multipleMsgs), "", util.ColorEmbedUpdated)
+	txt := fmt.Sprintf("Deleted %d message%s.", len(msgs), multipleMsgs)
+	if nTooOld > 0 {
+		txt += fmt.Sprintf("\n\n*%d matching message(s) were skipped because they are older than 14 days "+
+			"and can not be deleted in bulk.*", nTooOld)
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID, txt, "", util.ColorEmbedUpdated)
 	util.DeleteMessageLater(args.Session, msg, 6*time.Second)
 
 	return err
 }
+
+func (c *CmdClear) parseFilter(args *CommandArgs, filter *clearFilter) (bool, error) {
+	sendErr := func(txt string) (bool, error) {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+		util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+		return false, err
+	}
+
+	var memberResolvable []string
+	argv := args.Args[1:]
+
+	for i := 0; i < len(argv); i++ {
+		a := strings.ToLower(argv[i])
+
+		needsValue := a == "--regex" || a == "--after" || a == "--from" || a == "--to"
+		if needsValue && i+1 >= len(argv) {
+			return sendErr(fmt.Sprintf("The filter `%s` requires a value.", a))
+		}
+
+		switch a {
+		case "--bots":
+			filter.bots = true
+		case "--attachments":
+			filter.attachments = true
+		case "--links":
+			filter.links = true
+		case "--html":
+			filter.html = true
+		case "--regex":
+			i++
+			rx, err := regexp.Compile(argv[i])
+			if err != nil {
+				return sendErr("Invalid regular expression: ```\n" + err.Error() + "\n```")
+			}
+			filter.rx = rx
+		case "--after":
+			i++
+			if _, err := strconv.ParseUint(argv[i], 10, 64); err != nil {
+				return sendErr("Please enter a valid message ID.")
+			}
+			filter.afterID = argv[i]
+		case "--from", "--to":
+			i++
+			t, err := parseClearDate(argv[i], a == "--to")
+			if err != nil {
+				return sendErr("Invalid date format. Please use `2006-01-02` or `2006-01-02T15:04`.")
+			}
+			if a == "--from" {
+				filter.from = t
+			} else {
+				filter.to = t
+			}
+		default:
+			if strings.HasPrefix(a, "--") {
+				return sendErr(fmt.Sprintf("Unknown filter `%s`. Use `help clear` to see all available filters.", a))
+			}
+			memberResolvable = append(memberResolvable, argv[i])
+		}
+	}
+
+	if len(memberResolvable) > 0 {
+		memb, err := util.FetchMember(args.Session, args.Guild.ID, strings.Join(memberResolvable, " "))
+		if err != nil {
+			return sendErr("Sorry, but the member can not be found on this guild. :cry:")
+		}
+		if memb.User.ID != args.User.ID {
+			if ok, err := checkModTarget(args, memb, "clear messages of", false); !ok || err != nil {
+				return false, err
+			}
+		}
+		filter.member = memb
+	}
+
+	return true, nil
+}
+
+// archive uploads a transcript of the cleared messages
+// into the guild's mod log channel, if set.
+func (c *CmdClear) archive(args *CommandArgs, msgs []*discordgo.Message, asHTML bool) {
+	modlogChan, err := args.CmdHandler.db.GetGuildModLog(args.Guild.ID)
+	if err != nil || modlogChan == "" {
+		return
+	}
+
+	// Messages are fetched from newest to oldest, but
+	// the transcript should be read chronologically.
+	sorted := make([]*discordgo.Message, len(msgs))
+	for i, m := range msgs {
+		sorted[len(msgs)-1-i] = m
+	}
+
+	title := fmt.Sprintf("Cleared messages in #%s", args.Channel.Name)
+	file := &discordgo.File{
+		Name:        fmt.Sprintf("cleared-%s-%d.txt", args.Channel.ID, time.Now().Unix()),
+		ContentType: "text/plain",
+		Reader:      strings.NewReader(util.MessagesTranscript(sorted)),
+	}
+	if asHTML {
+		file.Name = strings.TrimSuffix(file.Name, ".txt") + ".html"
+		file.ContentType = "text/html"
+		file.Reader = strings.NewReader(util.MessagesTranscriptHTML(title, sorted))
+	}
+
+	_, err = args.Session.ChannelMessageSendComplex(modlogChan, &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Color: util.ColorEmbedGray,
+			Title: "Messages Cleared",
+			Description: fmt.Sprintf("%s cleared `%d` messages in <#%s>.",
+				args.User.Mention(), len(msgs), args.Channel.ID),
+			Timestamp: time.Now().Format(time.RFC3339),
+		},
+		Files: []*discordgo.File{file},
+	})
+	if err != nil {
+		util.Log.Errorf("failed archiving cleared messages of channel %s: %s", args.Channel.ID, err.Error())
+	}
+}
+
+func (f *clearFilter) matches(m *discordgo.Message) bool {
+	if f.member != nil && (m.Author == nil || m.Author.ID != f.member.User.ID) {
+		return false
+	}
+	if f.bots && (m.Author == nil || !m.Author.Bot) {
+		return false
+	}
+	if f.attachments && len(m.Attachments) == 0 {
+		return false
+	}
+	if f.links && !rxClearLink.MatchString(m.Content) {
+		return false
+	}
+	if f.rx != nil && !f.rx.MatchString(m.Content) {
+		return false
+	}
+
+	if !f.from.IsZero() || !f.to.IsZero() {
+		created, err := util.GetDiscordSnowflakeCreationTime(m.ID)
+		if err != nil {
+			return false
+		}
+		if !f.from.IsZero() && created.Before(f.from) {
+			return false
+		}
+		if !f.to.IsZero() && created.After(f.to) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterClearableMessages returns the messages which are
+// young enough to be bulk deleted and the number of
+// messages which were dropped because of their age.
+func filterClearableMessages(msgs []*discordgo.Message, now time.Time) ([]*discordgo.Message, int) {
+	clearable := make([]*discordgo.Message, 0, len(msgs))
+	for _, m := range msgs {
+		created, err := util.GetDiscordSnowflakeCreationTime(m.ID)
+		if err != nil || now.Sub(created) > clearMaxMessageAge {
+			continue
+		}
+		clearable = append(clearable, m)
+	}
+	return clearable, len(msgs) - len(clearable)
+}
+
+// parseClearDate parses the passed date string. If isEnd is
+// true and the date has no time specified, the end of the
+// day is returned.
+func parseClearDate(s string, isEnd bool) (time.Time, error) {
+	var err error
+	for _, layout := range clearDateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			if isEnd && layout == "2006-01-02" {
+				t = t.Add(24*time.Hour - time.Nanosecond)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
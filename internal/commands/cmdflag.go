@@ -0,0 +1,110 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+var rxMessageLink = regexp.MustCompile(`^https?:\/\/(?:\w+\.)?discord(?:app)?\.com\/channels\/(\d+)\/(\d+)\/(\d+)\/?$`)
+
+type CmdFlag struct {
+	PermLvl int
+}
+
+func (c *CmdFlag) GetInvokes() []string {
+	return []string{"flag"}
+}
+
+func (c *CmdFlag) GetDescription() string {
+	return "flag a message or member for review by the moderators"
+}
+
+func (c *CmdFlag) GetHelp() string {
+	return "`flag <messageLink> <reason>` - flag a message\n" +
+		"`flag <userResolvable> <reason>` - flag a member\n\n" +
+		"*You can also flag messages by reacting with " + core.FlagEmoji + " to them.*"
+}
+
+func (c *CmdFlag) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdFlag) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdFlag) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdFlag) Exec(args *CommandArgs) error {
+	// The invoking message is removed so that the
+	// flagged member does not see who flagged them.
+	args.Session.ChannelMessageDelete(args.Channel.ID, args.Message.ID)
+
+	if len(args.Args) < 2 {
+		return c.sendError(args, "Please specify a message link or member and a reason.")
+	}
+
+	flag := &core.Flag{
+		GuildID:    args.Guild.ID,
+		ReporterID: args.User.ID,
+		Reason:     strings.Join(args.Args[1:], " "),
+	}
+
+	if m := rxMessageLink.FindStringSubmatch(args.Args[0]); m != nil {
+		if m[1] != args.Guild.ID {
+			return c.sendError(args, "You can only flag messages of this guild.")
+		}
+		ch, err := args.Session.State.Channel(m[2])
+		if err != nil || ch.GuildID != args.Guild.ID {
+			return c.sendError(args, "You can only flag messages of this guild.")
+		}
+		perms, err := args.Session.State.UserChannelPermissions(args.User.ID, ch.ID)
+		if err != nil || perms&discordgo.PermissionReadMessages == 0 {
+			return c.sendError(args, "The message could not be found.")
+		}
+		msg, err := args.Session.ChannelMessage(ch.ID, m[3])
+		if err != nil || msg.Author == nil {
+			return c.sendError(args, "The message could not be found.")
+		}
+		flag.ChannelID = msg.ChannelID
+		flag.MessageID = msg.ID
+		flag.VictimID = msg.Author.ID
+		flag.Content = msg.Content
+	} else {
+		memb, err := util.FetchMember(args.Session, args.Guild.ID, args.Args[0])
+		if err != nil {
+			return c.sendError(args, "Sorry, but the member can not be found on this guild. :cry:")
+		}
+		flag.VictimID = memb.User.ID
+	}
+
+	if flag.VictimID == args.User.ID {
+		return c.sendError(args, "You can not flag yourself.")
+	}
+
+	err := core.PostFlag(args.Session, args.CmdHandler.db, flag)
+	if err == core.ErrFlagQueueNotSet {
+		return c.sendError(args, "Flagging is not set up on this guild.")
+	}
+	if err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		"Thank you! Your flag was submitted to the moderators.", "", util.ColorEmbedGreen)
+	util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+	return err
+}
+
+func (c *CmdFlag) sendError(args *CommandArgs, txt string) error {
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdFlagQueue struct {
+	PermLvl int
+}
+
+func (c *CmdFlagQueue) GetInvokes() []string {
+	return []string{"flagqueue", "flagq", "fq"}
+}
+
+func (c *CmdFlagQueue) GetDescription() string {
+	return "set the moderator queue channel for flagged messages and members"
+}
+
+func (c *CmdFlagQueue) GetHelp() string {
+	return "`flagqueue` - display current flag queue channel\n" +
+		"`flagqueue set` - set this channel as flag queue channel\n" +
+		"`flagqueue set <chanResolvable>` - set any text channel as flag queue channel\n" +
+		"`flagqueue reset` - reset flag queue channel and disable flagging\n\n" +
+		"*Flags can be resolved in the queue channel by reacting with ❌ (dismiss), ⚠ (warn), 🔇 (mute) or 🔨 (ban). " +
+		"Resolving requires the permission level of the `report` command.*"
+}
+
+func (c *CmdFlagQueue) GetGroup() string {
+	return GroupGuildConfig
+}
+
+func (c *CmdFlagQueue) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdFlagQueue) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdFlagQueue) Exec(args *CommandArgs) error {
+	if len(args.Args) < 1 {
+		return c.printStatus(args)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+	case "set", "s":
+		return c.set(args)
+	case "reset", "r":
+		return c.reset(args)
+	default:
+		return c.printStatus(args)
+	}
+}
+
+func (c *CmdFlagQueue) set(args *CommandArgs) error {
+	queueChan := args.Channel
+	if len(args.Args) > 1 {
+		var err error
+		queueChan, err = util.FetchChannel(args.Session, args.Guild.ID, args.Args[1], func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildText
+		})
+		if err != nil {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Could not find any channel on this guild passing this resolvable.")
+			util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+			return err
+		}
+	}
+
+	if err := args.CmdHandler.db.SetGuildFlagQueue(args.Guild.ID, queueChan.ID); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Set <#%s> as flag queue channel.", queueChan.ID), "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+	return err
+}
+
+func (c *CmdFlagQueue) reset(args *CommandArgs) error {
+	if err := args.CmdHandler.db.SetGuildFlagQueue(args.Guild.ID, ""); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		"Flag queue channel reset. Flagging is now disabled.", "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 5*time.Second)
+	return err
+}
+
+func (c *CmdFlagQueue) printStatus(args *CommandArgs) error {
+	queueChan, err := args.CmdHandler.db.GetGuildFlagQueue(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	desc := "Flag queue channel is currently **not set**, so flagging is disabled."
+	if queueChan != "" {
+		desc = fmt.Sprintf("Flag queue channel is currently set to <#%s>.", queueChan)
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID, desc, "", 0)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
@@ -0,0 +1,200 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+var rxUserID = regexp.MustCompile(`^<?@?!?(\d{17,20})>?$`)
+
+type CmdHackban struct {
+	PermLvl int
+}
+
+func (c *CmdHackban) GetInvokes() []string {
+	return []string{"hackban", "hban", "idban"}
+}
+
+func (c *CmdHackban) GetDescription() string {
+	return "ban users by ID which are not members of the guild"
+}
+
+func (c *CmdHackban) GetHelp() string {
+	return "`hackban <userID...> <reason>` - ban one or more users by their IDs"
+}
+
+func (c *CmdHackban) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdHackban) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdHackban) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdHackban) Exec(args *CommandArgs) error {
+	ids, rest := parseUserIDs(args.Args)
+	reason := strings.Join(rest, " ")
+
+	if len(ids) == 0 || reason == "" {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Invalid command arguments. Please use `help hackban` to see how to use this command.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	users := make([]*discordgo.User, 0, len(ids))
+	var skipped []string
+	for _, id := range ids {
+		if errTxt := banTargetError(args, id); errTxt != "" {
+			skipped = append(skipped, fmt.Sprintf("`%s`: %s", id, errTxt))
+			continue
+		}
+		user, err := args.Session.User(id)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("`%s`: user not found", id))
+			continue
+		}
+		users = append(users, user)
+	}
+
+	if len(users) == 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"None of the passed IDs can be banned.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	userList := make([]string, len(users))
+	for i, u := range users {
+		userList[i] = fmt.Sprintf("<@%s> (%s)", u.ID, u.String())
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color:       util.ReportColors[util.IndexOfStrArray("BAN", util.ReportTypes)],
+		Title:       "Hackban Check",
+		Description: "Is everything okay so far?",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Victims",
+				Value: truncateList(userList, 1024),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Description",
+				Value: reason,
+			},
+		},
+	}
+	if len(skipped) > 0 {
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name:  "Skipped",
+			Value: truncateList(skipped, 1024),
+		})
+	}
+
+	acceptMsg := &util.AcceptMessage{
+		Embed:          emb,
+		Session:        args.Session,
+		UserID:         args.User.ID,
+		DeleteMsgAfter: true,
+		AcceptFunc: func(msg *discordgo.Message) {
+			ids := make([]string, len(users))
+			for i, u := range users {
+				ids[i] = u.ID
+			}
+			executeBans(args, ids, reason)
+		},
+	}
+
+	_, err := acceptMsg.Send(args.Channel.ID)
+	return err
+}
+
+// parseUserIDs splits the passed arguments into the
+// leading user IDs or mentions and the remaining arguments.
+func parseUserIDs(argv []string) ([]string, []string) {
+	ids := make([]string, 0)
+	for i, a := range argv {
+		m := rxUserID.FindStringSubmatch(a)
+		if m == nil {
+			return ids, argv[i:]
+		}
+		if util.IndexOfStrArray(m[1], ids) < 0 {
+			ids = append(ids, m[1])
+		}
+	}
+	return ids, []string{}
+}
+
+// banTargetError returns the moderation guard's error
+// description for banning the user with the passed ID.
+func banTargetError(args *CommandArgs, userID string) string {
+	if victim, err := args.Session.State.Member(args.Guild.ID, userID); err == nil {
+		return modTargetError(args, victim, "ban", true)
+	}
+	return modTargetIDError(args, userID, "ban")
+}
+
+// executeBans bans all users by the passed IDs, creating a
+// report for each of them, and sends a summary to the
+// command channel.
+func executeBans(args *CommandArgs, ids []string, reason string) {
+	var nBanned int
+	var failed []string
+
+	for _, id := range ids {
+		rep, err := banWithReport(args, id, "BAN", reason, 0)
+		if err != nil {
+			util.Log.Errorf("failed banning %s on guild %s: %s", id, args.Guild.ID, err.Error())
+			failed = append(failed, fmt.Sprintf("<@%s>: `%s`", id, err.Error()))
+			continue
+		}
+		nBanned++
+		if err = core.PublishBan(args.Session, args.CmdHandler.db, rep,
+			args.CmdHandler.BotModTargetFunc(args.Session, "ban")); err != nil {
+			util.Log.Errorf("failed publishing ban of %s on guild %s: %s", id, args.Guild.ID, err.Error())
+		}
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color:       util.ColorEmbedUpdated,
+		Description: fmt.Sprintf("Banned `%d` of `%d` users.", nBanned, len(ids)),
+	}
+	if len(failed) > 0 {
+		emb.Color = util.ColorEmbedOrange
+		emb.Fields = []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Failed",
+				Value: truncateList(failed, 1024),
+			},
+		}
+	}
+
+	args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+}
+
+// truncateList joins the passed lines and cuts off lines
+// which would exceed the passed maximum length.
+func truncateList(lines []string, maxLen int) string {
+	var res string
+	for i, l := range lines {
+		more := fmt.Sprintf("\n*... and %d more*", len(lines)-i)
+		if len(res)+len(l)+1+len(more) > maxLen {
+			return res + more
+		}
+		if res != "" {
+			res += "\n"
+		}
+		res += l
+	}
+	return res
+}
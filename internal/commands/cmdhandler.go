@@ -35,7 +35,7 @@ type CmdHandler struct {
 	notifiedCmdMsgs *timedmap.TimedMap
 }
 
-func NewCmdHandler(s *discordgo.Session, db core.Database, config *core.Config, tnw *core.TwitchNotifyWorker, lct *core.LCTimer) *CmdHandler {
+func NewCmdHandler(s *discordgo.Session, db core.Database, config *core.Config, tnw *core.TwitchNotifyWorker, bck *core.GuildBackups, lct *core.LCTimer) *CmdHandler {
 	return &CmdHandler{
 		registeredCmds:         make(map[string]Command),
 		registeredCmdInstances: make([]Command, 0),
@@ -43,7 +43,7 @@ func NewCmdHandler(s *discordgo.Session, db core.Database, config *core.Config,
 		config:                 config,
 		tnw:                    tnw,
 		lct:                    lct,
-		bck:                    core.NewGuildBackups(s, db),
+		bck:                    bck,
 		notifiedCmdMsgs:        timedmap.New(notifiedCmdsCleanupDelay),
 	}
 }
@@ -97,6 +97,20 @@ func (c *CmdHandler) GetPermissionLevel(s *discordgo.Session, guildID, userID st
 	return permLvl, nil
 }
 
+// CommandPermissionFunc returns a function which reports whether
+// a user has the permission level of the command with the passed
+// invoke on a guild. Unknown commands are permitted to nobody.
+func (c *CmdHandler) CommandPermissionFunc(s *discordgo.Session, invoke string) core.PermissionFunc {
+	return func(guildID, userID string) bool {
+		cmd, ok := c.GetCommand(invoke)
+		if !ok {
+			return false
+		}
+		permLvl, err := c.GetPermissionLevel(s, guildID, userID)
+		return err == nil && permLvl >= cmd.GetPermission()
+	}
+}
+
 func (c *CmdHandler) ExportCommandManual(fileName string) error {
 	document := "> Auto generated command manual | " + time.Now().Format(time.RFC1123) + "\n\n" +
 		"# Command List\n\n"
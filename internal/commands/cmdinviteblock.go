@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bwmarrin/discordgo"
+
 	"github.com/zekroTJA/shinpuru/internal/core"
 	"github.com/zekroTJA/shinpuru/internal/util"
 )
@@ -24,7 +26,12 @@ func (c *CmdInviteBlock) GetDescription() string {
 
 func (c *CmdInviteBlock) GetHelp() string {
 	return "`inv enable <permLvL>` - enable invite link blocking for members with permission level below passed level\n" +
-		"`inv disable` - disable link blocking"
+		"`inv disable` - disable link blocking\n" +
+		"`inv action <delete|warn|mute|kick>` - set the consequence for posting blocked invites\n" +
+		"`inv whitelist` - list whitelisted guilds\n" +
+		"`inv whitelist add <guildID|invite>` - allow invites to the specified guild\n" +
+		"`inv whitelist remove <guildID>` - remove a guild from the whitelist\n" +
+		"`inv offences <userResolvable> (reset)` - display or reset the count of blocked invites posted by a member"
 }
 
 func (c *CmdInviteBlock) GetGroup() string {
@@ -49,6 +56,12 @@ func (c *CmdInviteBlock) Exec(args *CommandArgs) error {
 		return c.enable(args)
 	case "disable", "d", "off":
 		return c.disable(args)
+	case "action", "a":
+		return c.setAction(args)
+	case "whitelist", "wl":
+		return c.whitelist(args)
+	case "offences", "offence", "o":
+		return c.offences(args)
 	default:
 		return c.printStatus(args)
 	}
@@ -60,6 +73,14 @@ func (c *CmdInviteBlock) printStatus(args *CommandArgs) error {
 		return err
 	}
 
+	settings, err := args.CmdHandler.db.GetGuildInviteBlockSettings(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		settings, err = util.NewDefaultInviteBlockSettings(), nil
+	}
+	if err != nil {
+		return err
+	}
+
 	strStat := "disabled"
 	color := util.ColorEmbedOrange
 	if status != "" {
@@ -68,8 +89,10 @@ func (c *CmdInviteBlock) printStatus(args *CommandArgs) error {
 	}
 
 	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
-		fmt.Sprintf("Discord invite link blocking is currently **%s** on this guild.\n\n"+
-			"*You can enable or disable this with the command `inv enable` or `inv disable`*.", strStat),
+		fmt.Sprintf("Discord invite link blocking is currently **%s** on this guild.\n"+
+			"Consequence: `%s`\nWhitelisted guilds: `%d`\n\n"+
+			"*You can enable or disable this with the command `inv enable` or `inv disable`*.",
+			strStat, settings.Action, len(settings.Whitelist)),
 		"", color)
 	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
 	return err
@@ -121,3 +144,183 @@ func (c *CmdInviteBlock) disable(args *CommandArgs) error {
 	util.DeleteMessageLater(args.Session, msg, 6*time.Second)
 	return err
 }
+
+func (c *CmdInviteBlock) setAction(args *CommandArgs) error {
+	if len(args.Args) < 2 || util.IndexOfStrArray(strings.ToLower(args.Args[1]), util.InviteBlockActions) < 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			fmt.Sprintf("Please enter a valid action. Available actions are: `%s`.",
+				strings.Join(util.InviteBlockActions, "`, `")))
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	settings, err := args.CmdHandler.db.GetGuildInviteBlockSettings(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		settings, err = util.NewDefaultInviteBlockSettings(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	settings.Action = strings.ToLower(args.Args[1])
+	if err = args.CmdHandler.db.SetGuildInviteBlockSettings(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	if settings.Action == util.InviteBlockActionMute {
+		if muteRoleID, err := args.CmdHandler.db.GetMuteRoleGuild(args.Guild.ID); err != nil || muteRoleID == "" {
+			msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+				"Set consequence to `mute`.\n\n**Attention:** The mute role is not set up yet. "+
+					"Please enter `mute setup` to make this action work.", "", util.ColorEmbedOrange)
+			util.DeleteMessageLater(args.Session, msg, 12*time.Second)
+			return err
+		}
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Set consequence for posting blocked invites to `%s`.", settings.Action),
+		"", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdInviteBlock) whitelist(args *CommandArgs) error {
+	settings, err := args.CmdHandler.db.GetGuildInviteBlockSettings(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		settings, err = util.NewDefaultInviteBlockSettings(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(args.Args) < 2 {
+		lines := make([]string, len(settings.Whitelist))
+		for i, guildID := range settings.Whitelist {
+			lines[i] = fmt.Sprintf("`%s`", guildID)
+			if g, err := args.Session.State.Guild(guildID); err == nil {
+				lines[i] += " - " + g.Name
+			}
+		}
+
+		_, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, &discordgo.MessageEmbed{
+			Color:       util.ColorEmbedDefault,
+			Title:       "Invite Block Whitelist",
+			Description: util.EnsureNotEmpty(truncateList(lines, 2048), "*no guilds whitelisted*"),
+		})
+		return err
+	}
+
+	if len(args.Args) < 3 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please specify a guild ID or invite. Use `help inv` for further information.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	var guildID, guildName string
+
+	switch strings.ToLower(args.Args[1]) {
+
+	case "add", "a", "set":
+		guildID, guildName = c.resolveGuild(args, args.Args[2])
+		if guildID == "" {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Please enter a valid guild ID or invite.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return err
+		}
+		if !settings.IsWhitelisted(guildID) {
+			settings.Whitelist = append(settings.Whitelist, guildID)
+		}
+
+	case "remove", "rem", "r", "delete", "del":
+		guildID = args.Args[2]
+		i := util.IndexOfStrArray(guildID, settings.Whitelist)
+		if i < 0 {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"This guild is not whitelisted.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return err
+		}
+		settings.Whitelist = append(settings.Whitelist[:i], settings.Whitelist[i+1:]...)
+
+	default:
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Invalid argument. Use `help inv` for further information.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	if err = args.CmdHandler.db.SetGuildInviteBlockSettings(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	if guildName == "" {
+		guildName = guildID
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Updated whitelist. `%d` guilds are now whitelisted.\n*(Guild: %s)*",
+			len(settings.Whitelist), guildName),
+		"", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdInviteBlock) offences(args *CommandArgs) error {
+	if len(args.Args) < 2 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please specify a member.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	memb, err := util.FetchMember(args.Session, args.Guild.ID, args.Args[1])
+	if err != nil {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Sorry, but the member can not be found on this guild. :cry:")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	if len(args.Args) > 2 && strings.ToLower(args.Args[2]) == "reset" {
+		if err = args.CmdHandler.db.SetInviteBlockOffences(args.Guild.ID, memb.User.ID, 0); err != nil {
+			return err
+		}
+		msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+			fmt.Sprintf("Reset invite block offences of %s.", memb.User.Mention()),
+			"", util.ColorEmbedUpdated)
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	offences, err := args.CmdHandler.db.GetInviteBlockOffences(args.Guild.ID, memb.User.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("%s has posted `%d` blocked invites.", memb.User.Mention(), offences),
+		"", 0)
+	return err
+}
+
+// resolveGuild returns the ID and name of the guild specified
+// by either a guild ID or an invite link or code.
+func (c *CmdInviteBlock) resolveGuild(args *CommandArgs, resolvable string) (string, string) {
+	if _, err := strconv.ParseUint(resolvable, 10, 64); err == nil {
+		return resolvable, ""
+	}
+
+	code := strings.TrimRight(resolvable, "/")
+	if i := strings.LastIndex(code, "/"); i > -1 {
+		code = code[i+1:]
+	}
+
+	inv, err := args.Session.Invite(code)
+	if err != nil || inv.Guild == nil {
+		return "", ""
+	}
+
+	return inv.Guild.ID, inv.Guild.Name
+}
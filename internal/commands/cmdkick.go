@@ -52,22 +52,7 @@ func (c *CmdKick) Exec(args *CommandArgs) error {
 		return err
 	}
 
-	if victim.User.ID == args.User.ID {
-		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-			"You can not kick yourself...")
-		util.DeleteMessageLater(args.Session, msg, 6*time.Second)
-		return err
-	}
-
-	authorMemb, err := args.Session.GuildMember(args.Guild.ID, args.User.ID)
-	if err != nil {
-		return err
-	}
-
-	if util.RolePosDiff(victim, authorMemb, args.Guild) >= 0 {
-		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-			"You can only kick members with lower permissions than yours.")
-		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	if ok, err := checkModTarget(args, victim, "kick", true); !ok || err != nil {
 		return err
 	}
 
@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+const layoutFileMaxSize = 1024 * 1024
+
+type CmdLayout struct {
+	PermLvl int
+}
+
+func (c *CmdLayout) GetInvokes() []string {
+	return []string{"layout", "guildlayout"}
+}
+
+func (c *CmdLayout) GetDescription() string {
+	return "plan and apply guild layouts declared in YAML files"
+}
+
+func (c *CmdLayout) GetHelp() string {
+	return "`layout plan (--prune)` - show the changes which would be applied by the attached layout file\n" +
+		"`layout apply (--prune)` - apply the attached layout file to the guild\n\n" +
+		"Roles and channels which are not declared in the layout are only deleted when `--prune` is passed. " +
+		"The channel the command is executed in is never deleted. " +
+		"Properties which are not set in the layout keep their current values.\n\n" +
+		"Example layout:\n" +
+		"```yaml\n" +
+		"roles:\n" +
+		"  - name: Moderator\n" +
+		"    color: '#e91e63'\n" +
+		"    hoist: true\n" +
+		"    permissions: 268561478\n" +
+		"categories:\n" +
+		"  - name: Staff\n" +
+		"    overwrites:\n" +
+		"      - role: '@everyone'\n" +
+		"        deny: 1024\n" +
+		"      - role: Moderator\n" +
+		"        allow: 1024\n" +
+		"    channels:\n" +
+		"      - name: mod-chat\n" +
+		"        topic: Moderator chat\n" +
+		"        slowmode: 5\n" +
+		"      - name: Mod Voice\n" +
+		"        type: voice\n" +
+		"channels:\n" +
+		"  - name: general\n" +
+		"```"
+}
+
+func (c *CmdLayout) GetGroup() string {
+	return GroupGuildAdmin
+}
+
+func (c *CmdLayout) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdLayout) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdLayout) Exec(args *CommandArgs) error {
+	if len(args.Args) < 1 {
+		return c.sendError(args, "Please use `plan` or `apply`. Use `help layout` for more information.")
+	}
+
+	prune := false
+	for _, a := range args.Args[1:] {
+		if strings.ToLower(a) != "--prune" {
+			return c.sendError(args, fmt.Sprintf("Unknown argument `%s`.", a))
+		}
+		prune = true
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+	case "plan", "diff":
+		return c.plan(args, prune)
+	case "apply":
+		return c.apply(args, prune)
+	default:
+		return c.sendError(args, "Please use `plan` or `apply`. Use `help layout` for more information.")
+	}
+}
+
+// readLayout downloads and parses the layout file attached
+// to the command message. If the layout could not be read,
+// an error message is sent and nil is returned.
+func (c *CmdLayout) readLayout(args *CommandArgs) (*core.GuildLayout, error) {
+	if len(args.Message.Attachments) == 0 {
+		return nil, c.sendError(args, "Please attach the layout file to the message.")
+	}
+
+	att := args.Message.Attachments[0]
+	if att.Size > layoutFileMaxSize {
+		return nil, c.sendError(args, "The attached file is too large.")
+	}
+
+	resp, err := backupHTTPClient.Get(att.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed downloading attachment: %s", resp.Status)
+	}
+
+	layout, err := core.ParseGuildLayout(io.LimitReader(resp.Body, layoutFileMaxSize))
+	if err != nil {
+		return nil, c.sendError(args, "The attached file is not a valid layout: ```\n"+err.Error()+"\n```")
+	}
+
+	return layout, nil
+}
+
+// planLayout sends the changes which would be applied by the
+// layout and returns the number of changes. If the layout could
+// not be planned, an error message is sent and -1 is returned.
+func (c *CmdLayout) planLayout(args *CommandArgs, layout *core.GuildLayout, prune bool) (int, error) {
+	actions, err := args.CmdHandler.bck.PlanLayout(args.Guild.ID, layout, prune, args.Channel.ID)
+	if err != nil {
+		return -1, c.sendError(args, "The layout could not be applied to this guild: ```\n"+err.Error()+"\n```")
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color:       util.ColorEmbedDefault,
+		Title:       "Layout Plan",
+		Description: "Changes which would be applied by the layout:",
+	}
+
+	if len(actions) == 0 {
+		emb.Description = "The guild already matches the layout."
+		_, err = args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+		return 0, err
+	}
+
+	lines := make(map[string][]string)
+	for _, a := range actions {
+		lines[a.Part] = append(lines[a.Part], changeLine(backupActionSymbols[a.Action], a.Name, a.Details))
+	}
+
+	return len(actions), sendChanges(args, emb, "layout-plan.txt", lines)
+}
+
+func (c *CmdLayout) plan(args *CommandArgs, prune bool) error {
+	layout, err := c.readLayout(args)
+	if layout == nil {
+		return err
+	}
+
+	_, err = c.planLayout(args, layout, prune)
+	return err
+}
+
+func (c *CmdLayout) apply(args *CommandArgs, prune bool) error {
+	layout, err := c.readLayout(args)
+	if layout == nil {
+		return err
+	}
+
+	n, err := c.planLayout(args, layout, prune)
+	if n < 1 || err != nil {
+		return err
+	}
+
+	accMsg := &util.AcceptMessage{
+		Session:        args.Session,
+		DeleteMsgAfter: true,
+		UserID:         args.User.ID,
+		Embed: &discordgo.MessageEmbed{
+			Color: util.ColorEmbedOrange,
+			Description: fmt.Sprintf(":warning:  **WARNING**  :warning:\n\n"+
+				"By pressing :white_check_mark:, the **%d** changes listed above will be applied to this guild.", n),
+		},
+		DeclineFunc: func(m *discordgo.Message) {
+			cMsg, _ := util.SendEmbedError(args.Session, args.Channel.ID, "Canceled.")
+			util.DeleteMessageLater(args.Session, cMsg, 6*time.Second)
+		},
+		AcceptFunc: func(m *discordgo.Message) {
+			c.proceedApply(args, layout, prune)
+		},
+	}
+
+	_, err = accMsg.Send(args.Channel.ID)
+	return err
+}
+
+func (c *CmdLayout) proceedApply(args *CommandArgs, layout *core.GuildLayout, prune bool) {
+	statusChan := make(chan string)
+	errorsChan := make(chan error)
+
+	watchRestoreStatus(args, "initializing layout...", "applying layout", statusChan, errorsChan)
+
+	err := args.CmdHandler.bck.ApplyLayout(args.Guild.ID, layout, prune, args.Channel.ID, statusChan, errorsChan)
+	if err != nil {
+		util.SendEmbedError(args.Session, args.Channel.ID,
+			fmt.Sprintf("An unexpected error occured while applying layout: ```\n%s\n```", err.Error()))
+		return
+	}
+
+	msg, _ := util.SendEmbed(args.Session, args.Channel.ID, "Layout applied.", "", util.ColorEmbedGreen)
+	util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+}
+
+func (c *CmdLayout) sendError(args *CommandArgs, txt string) error {
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+	util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+	return err
+}
@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdLinkFilter struct {
+	PermLvl int
+}
+
+func (c *CmdLinkFilter) GetInvokes() []string {
+	return []string{"linkfilter", "links", "lf"}
+}
+
+func (c *CmdLinkFilter) GetDescription() string {
+	return "manage the domain allow or deny list for links in chat"
+}
+
+func (c *CmdLinkFilter) GetHelp() string {
+	return "`linkfilter` - display current link filter settings\n" +
+		"`linkfilter <enable|disable>` - enable or disable the link filter\n" +
+		"`linkfilter mode <deny|allow>` - block only listed domains or allow only listed domains\n" +
+		"`linkfilter add <domain> (<domain> ...)` - add domains to the list\n" +
+		"`linkfilter remove <domain> (<domain> ...)` - remove domains from the list\n" +
+		"`linkfilter exception <channelResolvable>` - add or remove a channel where links are not filtered\n" +
+		"`linkfilter bypass <permLvl>` - set the permission level members need to bypass the filter\n\n" +
+		"*Listed domains also match their subdomains. Redirects of links are followed and checked as well.*"
+}
+
+func (c *CmdLinkFilter) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdLinkFilter) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdLinkFilter) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdLinkFilter) Exec(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	settings, err := db.GetGuildLinkFilter(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		settings, err = util.NewDefaultLinkFilterSettings(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(args.Args) < 1 {
+		return c.printStatus(args, settings)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+
+	case "enable", "e", "on":
+		settings.Enabled = true
+
+	case "disable", "d", "off":
+		settings.Enabled = false
+
+	case "mode", "m":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify either `deny` or `allow`.")
+		}
+		switch strings.ToLower(args.Args[1]) {
+		case util.LinkFilterModeDeny, "denylist", "blacklist":
+			settings.Mode = util.LinkFilterModeDeny
+		case util.LinkFilterModeAllow, "allowlist", "whitelist":
+			settings.Mode = util.LinkFilterModeAllow
+		default:
+			return c.sendError(args, "Please specify either `deny` or `allow`.")
+		}
+
+	case "add", "a":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify at least one domain.")
+		}
+		for _, d := range args.Args[1:] {
+			d = normalizeDomain(d)
+			if d != "" && util.IndexOfStrArray(d, settings.Domains) < 0 {
+				settings.Domains = append(settings.Domains, d)
+			}
+		}
+
+	case "remove", "rem", "r", "delete", "del":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify at least one domain.")
+		}
+		for _, d := range args.Args[1:] {
+			if i := util.IndexOfStrArray(normalizeDomain(d), settings.Domains); i > -1 {
+				settings.Domains = append(settings.Domains[:i], settings.Domains[i+1:]...)
+			}
+		}
+
+	case "exception", "exceptions", "except", "ex":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a channel.")
+		}
+		ch, err := util.FetchChannel(args.Session, args.Guild.ID, args.Args[1])
+		if err != nil {
+			return c.sendError(args, "Channel could not be fetched by passed identifier.")
+		}
+		if i := util.IndexOfStrArray(ch.ID, settings.ChannelExceptions); i > -1 {
+			settings.ChannelExceptions = append(settings.ChannelExceptions[:i], settings.ChannelExceptions[i+1:]...)
+		} else {
+			settings.ChannelExceptions = append(settings.ChannelExceptions, ch.ID)
+		}
+
+	case "bypass", "b":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a permission level.")
+		}
+		lvl, err := strconv.Atoi(args.Args[1])
+		if err != nil || lvl < 1 {
+			return c.sendError(args, "Please enter a valid permission level larger than 0.")
+		}
+		settings.BypassPermLvl = lvl
+
+	default:
+		return c.sendError(args, "Invalid argument. Use `help linkfilter` for further information.")
+	}
+
+	if err = db.SetGuildLinkFilter(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	return c.printStatus(args, settings)
+}
+
+func (c *CmdLinkFilter) sendError(args *CommandArgs, txt string) error {
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdLinkFilter) printStatus(args *CommandArgs, settings *util.LinkFilterSettings) error {
+	status := "disabled"
+	color := util.ColorEmbedOrange
+	if settings.Enabled {
+		status = "enabled"
+		color = util.ColorEmbedGreen
+	}
+
+	domains := make([]string, len(settings.Domains))
+	for i, d := range settings.Domains {
+		domains[i] = fmt.Sprintf("`%s`", d)
+	}
+
+	channels := make([]string, len(settings.ChannelExceptions))
+	for i, chID := range settings.ChannelExceptions {
+		channels[i] = fmt.Sprintf("<#%s>", chID)
+	}
+
+	_, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, &discordgo.MessageEmbed{
+		Color: color,
+		Title: "Link Filter",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Status",
+				Value:  status,
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Mode",
+				Value:  settings.Mode,
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Bypass Permission Level",
+				Value:  strconv.Itoa(settings.BypassPermLvl),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Domains",
+				Value: util.EnsureNotEmpty(truncateList(domains, 1024), "*no domains listed*"),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Channel Exceptions",
+				Value: util.EnsureNotEmpty(truncateList(channels, 1024), "*no exceptions*"),
+			},
+		},
+	})
+	return err
+}
+
+// normalizeDomain returns the lower case host name
+// of the passed domain or URL.
+func normalizeDomain(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	if !strings.Contains(d, "://") {
+		d = "http://" + d
+	}
+	u, err := url.Parse(d)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
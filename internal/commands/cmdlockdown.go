@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdLockdown struct {
+	PermLvl int
+}
+
+func (c *CmdLockdown) GetInvokes() []string {
+	return []string{"lockdown", "lock", "ld"}
+}
+
+func (c *CmdLockdown) GetDescription() string {
+	return "toggle lockdown of all text channels for everyone"
+}
+
+func (c *CmdLockdown) GetHelp() string {
+	return "`lockdown` - deny sending messages for @everyone in all text channels or restore the previous permissions"
+}
+
+func (c *CmdLockdown) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdLockdown) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdLockdown) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdLockdown) Exec(args *CommandArgs) error {
+	prevOverwrites, err := args.CmdHandler.db.GetGuildLockdown(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	if prevOverwrites != nil {
+		return c.unlock(args, prevOverwrites)
+	}
+
+	return c.lock(args)
+}
+
+func (c *CmdLockdown) lock(args *CommandArgs) error {
+	prevOverwrites := make(map[string]*discordgo.PermissionOverwrite)
+	var nFailed int
+
+	for _, ch := range args.Guild.Channels {
+		if ch.Type != discordgo.ChannelTypeGuildText {
+			continue
+		}
+
+		var prev *discordgo.PermissionOverwrite
+		for _, po := range ch.PermissionOverwrites {
+			if po.ID == args.Guild.ID {
+				prev = po
+				break
+			}
+		}
+
+		allow, deny := 0, 0
+		if prev != nil {
+			allow, deny = prev.Allow, prev.Deny
+		}
+
+		err := args.Session.ChannelPermissionSet(ch.ID, args.Guild.ID, "role",
+			allow&^discordgo.PermissionSendMessages, deny|discordgo.PermissionSendMessages)
+		if err != nil {
+			util.Log.Errorf("Failed locking channel '%s': %s", ch.ID, err.Error())
+			nFailed++
+			continue
+		}
+
+		prevOverwrites[ch.ID] = prev
+	}
+
+	if err := args.CmdHandler.db.SetGuildLockdown(args.Guild.ID, prevOverwrites); err != nil {
+		return err
+	}
+
+	return c.sendResult(args, "Lockdown **enabled**. Members can not send messages in text channels anymore.\n\n"+
+		"*Use the `lockdown` command again to restore the previous permissions.*", nFailed)
+}
+
+func (c *CmdLockdown) unlock(args *CommandArgs, prevOverwrites map[string]*discordgo.PermissionOverwrite) error {
+	var nFailed int
+
+	for chID, prev := range prevOverwrites {
+		var err error
+		if prev == nil {
+			err = args.Session.ChannelPermissionDelete(chID, args.Guild.ID)
+		} else {
+			err = args.Session.ChannelPermissionSet(chID, args.Guild.ID, "role", prev.Allow, prev.Deny)
+		}
+		if err != nil {
+			util.Log.Errorf("Failed unlocking channel '%s': %s", chID, err.Error())
+			nFailed++
+		}
+	}
+
+	if err := args.CmdHandler.db.SetGuildLockdown(args.Guild.ID, nil); err != nil {
+		return err
+	}
+
+	return c.sendResult(args, "Lockdown **disabled**. The previous permissions were restored.", nFailed)
+}
+
+func (c *CmdLockdown) sendResult(args *CommandArgs, desc string, nFailed int) error {
+	color := util.ColorEmbedUpdated
+	if nFailed > 0 {
+		color = util.ColorEmbedOrange
+		desc += fmt.Sprintf("\n\n*Failed updating permissions of %d channels.*", nFailed)
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID, desc, "", color)
+	util.DeleteMessageLater(args.Session, msg, 15*time.Second)
+	return err
+}
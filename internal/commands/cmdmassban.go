@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdMassban struct {
+	PermLvl int
+}
+
+func (c *CmdMassban) GetInvokes() []string {
+	return []string{"massban", "mban"}
+}
+
+func (c *CmdMassban) GetDescription() string {
+	return "ban a list of users or all members who joined recently"
+}
+
+func (c *CmdMassban) GetHelp() string {
+	return "`massban <userID...> <reason>` - ban all users by the passed IDs\n" +
+		"`massban joined <minutes> <reason>` - ban all members who joined in the last n minutes"
+}
+
+func (c *CmdMassban) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdMassban) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdMassban) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdMassban) Exec(args *CommandArgs) error {
+	var ids, rest []string
+
+	if len(args.Args) > 0 && strings.ToLower(args.Args[0]) == "joined" {
+		minutes := -1
+		if len(args.Args) > 1 {
+			minutes, _ = strconv.Atoi(args.Args[1])
+		}
+		if minutes < 1 {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Please enter a valid number of minutes *(larger than 0)*.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return err
+		}
+		ids = c.joinedSince(args, time.Duration(minutes)*time.Minute)
+		rest = args.Args[2:]
+	} else {
+		ids, rest = parseUserIDs(args.Args)
+	}
+
+	reason := strings.Join(rest, " ")
+	if reason == "" {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Invalid command arguments. Please use `help massban` to see how to use this command.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	targets := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if banTargetError(args, id) == "" {
+			targets = append(targets, id)
+		}
+	}
+
+	if len(targets) == 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"No users found which could be banned.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	targetList := make([]string, len(targets))
+	for i, id := range targets {
+		targetList[i] = fmt.Sprintf("<@%s> (`%s`)", id, id)
+	}
+
+	acceptMsg := &util.AcceptMessage{
+		Embed: &discordgo.MessageEmbed{
+			Color: util.ReportColors[util.IndexOfStrArray("BAN", util.ReportTypes)],
+			Title: "Massban Check",
+			Description: fmt.Sprintf("Do you really want to ban `%d` users? A report will be created for each of them.\n\n"+
+				"*Users which you are not allowed to ban are excluded.*", len(targets)),
+			Fields: []*discordgo.MessageEmbedField{
+				&discordgo.MessageEmbedField{
+					Name:  "Victims",
+					Value: truncateList(targetList, 1024),
+				},
+				&discordgo.MessageEmbedField{
+					Name:  "Description",
+					Value: reason,
+				},
+			},
+		},
+		Session:        args.Session,
+		UserID:         args.User.ID,
+		DeleteMsgAfter: true,
+		AcceptFunc: func(msg *discordgo.Message) {
+			executeBans(args, targets, reason)
+		},
+	}
+
+	_, err := acceptMsg.Send(args.Channel.ID)
+	return err
+}
+
+func (c *CmdMassban) joinedSince(args *CommandArgs, d time.Duration) []string {
+	ids := make([]string, 0)
+	for _, m := range args.Guild.Members {
+		if m.User == nil || m.User.Bot {
+			continue
+		}
+		joinedAt, err := m.JoinedAt.Parse()
+		if err != nil || time.Since(joinedAt) > d {
+			continue
+		}
+		ids = append(ids, m.User.ID)
+	}
+	return ids
+}
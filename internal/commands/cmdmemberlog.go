@@ -0,0 +1,183 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdMemberLog struct {
+	PermLvl int
+}
+
+func (c *CmdMemberLog) GetInvokes() []string {
+	return []string{"memberlog", "mlog", "membl"}
+}
+
+func (c *CmdMemberLog) GetDescription() string {
+	return "set the log channel for member events"
+}
+
+func (c *CmdMemberLog) GetHelp() string {
+	return "`memberlog` - display current member log settings\n" +
+		"`memberlog set` - set this channel as member log channel\n" +
+		"`memberlog set <chanResolvable>` - set any text channel as member log channel\n" +
+		"`memberlog reset` - reset member log channel\n" +
+		"`memberlog enable <event>` - enable logging of an event\n" +
+		"`memberlog disable <event>` - disable logging of an event\n\n" +
+		"Available events: `" + strings.Join(util.MemberLogEventNames, "`, `") + "`, `all`"
+}
+
+func (c *CmdMemberLog) GetGroup() string {
+	return GroupGuildConfig
+}
+
+func (c *CmdMemberLog) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdMemberLog) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdMemberLog) Exec(args *CommandArgs) error {
+	if len(args.Args) < 1 {
+		return c.printStatus(args)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+	case "set", "s":
+		return c.set(args)
+	case "reset", "r":
+		return c.reset(args)
+	case "enable", "e", "on":
+		return c.toggleEvent(args, true)
+	case "disable", "d", "off":
+		return c.toggleEvent(args, false)
+	default:
+		return c.printStatus(args)
+	}
+}
+
+func (c *CmdMemberLog) set(args *CommandArgs) error {
+	logChan := args.Channel
+	if len(args.Args) > 1 {
+		var err error
+		logChan, err = util.FetchChannel(args.Session, args.Guild.ID, args.Args[1], func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildText
+		})
+		if err != nil {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Could not find any channel on this guild passing this resolvable.")
+			util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+			return err
+		}
+	}
+
+	if err := args.CmdHandler.db.SetGuildMemberLog(args.Guild.ID, logChan.ID); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Set <#%s> as member log channel.", logChan.ID), "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+	return err
+}
+
+func (c *CmdMemberLog) reset(args *CommandArgs) error {
+	if err := args.CmdHandler.db.SetGuildMemberLog(args.Guild.ID, ""); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		"Member log channel reset.", "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 5*time.Second)
+	return err
+}
+
+func (c *CmdMemberLog) toggleEvent(args *CommandArgs, enable bool) error {
+	var flag int
+	if len(args.Args) > 1 {
+		name := strings.ToLower(args.Args[1])
+		if name == "all" {
+			flag = util.MemberLogEventsAll
+		} else {
+			flag = util.MemberLogEvents[name]
+		}
+	}
+
+	if flag == 0 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please enter one of the following events: `"+strings.Join(util.MemberLogEventNames, "`, `")+"`, `all`.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	events, err := args.CmdHandler.db.GetGuildMemberLogEvents(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	if enable {
+		events |= flag
+	} else {
+		events &^= flag
+	}
+
+	if err = args.CmdHandler.db.SetGuildMemberLogEvents(args.Guild.ID, events); err != nil {
+		return err
+	}
+
+	return c.printStatus(args)
+}
+
+func (c *CmdMemberLog) printStatus(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	logChan, err := db.GetGuildMemberLog(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	events, err := db.GetGuildMemberLogEvents(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	logChanTxt := "*not set*"
+	if logChan != "" {
+		logChanTxt = fmt.Sprintf("<#%s>", logChan)
+	}
+
+	var eventsTxt strings.Builder
+	for _, name := range util.MemberLogEventNames {
+		state := ":x:"
+		if events&util.MemberLogEvents[name] != 0 {
+			state = ":white_check_mark:"
+		}
+		eventsTxt.WriteString(fmt.Sprintf("%s `%s`\n", state, name))
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Member Log",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Channel",
+				Value: logChanTxt,
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Events",
+				Value: eventsTxt.String(),
+			},
+		},
+	}
+
+	msg, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+	util.DeleteMessageLater(args.Session, msg, 15*time.Second)
+	return err
+}
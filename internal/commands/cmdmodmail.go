@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+var rxModmailArgs = regexp.MustCompile(`^\s*\S+\s+\S+\s*`)
+
+type CmdModmail struct {
+	PermLvl int
+}
+
+func (c *CmdModmail) GetInvokes() []string {
+	return []string{"modmail", "mm"}
+}
+
+func (c *CmdModmail) GetDescription() string {
+	return "reply to and close modmail threads"
+}
+
+func (c *CmdModmail) GetHelp() string {
+	return "`modmail reply <message>` - reply to the user of the current modmail thread\n" +
+		"`modmail anon <message>` - reply anonymously to the user of the current modmail thread\n" +
+		"`modmail close (<reason>)` - close the current modmail thread\n\n" +
+		"*Attachments of the command message are forwarded as well. Use `modmailconfig` to set up modmail.*"
+}
+
+func (c *CmdModmail) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdModmail) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdModmail) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdModmail) Exec(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	thread, err := db.GetModmailThreadByChannel(args.Channel.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		return c.sendError(args, "This command can only be used in modmail thread channels.")
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(args.Args) < 1 {
+		return c.sendError(args, "Invalid arguments. Use `help modmail` to get help about how to use this command.")
+	}
+
+	// The raw message content is used instead of the parsed
+	// arguments to preserve line breaks and quotes.
+	content := rxModmailArgs.ReplaceAllString(args.Message.Content, "")
+	if len(args.Args) < 2 {
+		content = ""
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+
+	case "reply", "r", "anon", "a":
+		if content == "" && len(args.Message.Attachments) == 0 {
+			return c.sendError(args, "Please enter a message.")
+		}
+		anonymous := strings.HasPrefix(strings.ToLower(args.Args[0]), "a")
+		err = core.ModmailRelayToUser(args.Session, db, thread, args.User, content, args.Message.Attachments, anonymous)
+		if err != nil {
+			return c.sendError(args, "Failed sending message to user: ```\n"+err.Error()+"\n```")
+		}
+		return args.Session.ChannelMessageDelete(args.Channel.ID, args.Message.ID)
+
+	case "close", "c":
+		return core.ModmailCloseThread(args.Session, db, thread, args.User, content)
+
+	default:
+		return c.sendError(args, "Invalid arguments. Use `help modmail` to get help about how to use this command.")
+	}
+}
+
+func (c *CmdModmail) sendError(args *CommandArgs, txt string) error {
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdModmailConfig struct {
+	PermLvl int
+}
+
+func (c *CmdModmailConfig) GetInvokes() []string {
+	return []string{"modmailconfig", "mmconfig", "mmc"}
+}
+
+func (c *CmdModmailConfig) GetDescription() string {
+	return "set up modmail threads for members direct messaging the bot"
+}
+
+func (c *CmdModmailConfig) GetHelp() string {
+	return "`modmailconfig` - display current modmail settings\n" +
+		"`modmailconfig category <categoryResolvable>` - set the category where thread channels are created and enable modmail\n" +
+		"`modmailconfig log <chanResolvable>` - set the channel where transcripts of closed threads are posted\n" +
+		"`modmailconfig disable` - disable modmail"
+}
+
+func (c *CmdModmailConfig) GetGroup() string {
+	return GroupGuildConfig
+}
+
+func (c *CmdModmailConfig) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdModmailConfig) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdModmailConfig) Exec(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	settings, err := db.GetGuildModmail(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		settings, err = util.NewDefaultModmailSettings(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(args.Args) < 1 {
+		return c.printStatus(args, settings)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+
+	case "category", "cat":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a category.")
+		}
+		ch, err := util.FetchChannel(args.Session, args.Guild.ID, strings.Join(args.Args[1:], " "), func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildCategory
+		})
+		if err != nil {
+			return c.sendError(args, "Could not find any category on this guild passing this resolvable.")
+		}
+		settings.CategoryID = ch.ID
+
+	case "log", "l":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a channel.")
+		}
+		ch, err := util.FetchChannel(args.Session, args.Guild.ID, args.Args[1], func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildText
+		})
+		if err != nil {
+			return c.sendError(args, "Could not find any channel on this guild passing this resolvable.")
+		}
+		settings.LogChannelID = ch.ID
+
+	case "disable", "d", "off":
+		settings = util.NewDefaultModmailSettings()
+
+	default:
+		return c.sendError(args, "Invalid arguments. Use `help modmailconfig` to get help about how to use this command.")
+	}
+
+	if err = db.SetGuildModmail(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	return c.printStatus(args, settings)
+}
+
+func (c *CmdModmailConfig) sendError(args *CommandArgs, txt string) error {
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdModmailConfig) printStatus(args *CommandArgs, settings *util.ModmailSettings) error {
+	status := "disabled"
+	color := util.ColorEmbedOrange
+	category := "*not set*"
+	if settings.IsEnabled() {
+		status = "enabled"
+		color = util.ColorEmbedGreen
+		category = fmt.Sprintf("<#%s>", settings.CategoryID)
+	}
+
+	logChan := "*not set*"
+	if settings.LogChannelID != "" {
+		logChan = fmt.Sprintf("<#%s>", settings.LogChannelID)
+	}
+
+	_, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, &discordgo.MessageEmbed{
+		Color: color,
+		Title: "Modmail",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Status",
+				Value:  status,
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Category",
+				Value:  category,
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Log Channel",
+				Value:  logChan,
+			},
+		},
+	})
+	return err
+}
@@ -0,0 +1,214 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdMsgLog struct {
+	PermLvl int
+}
+
+func (c *CmdMsgLog) GetInvokes() []string {
+	return []string{"msglog", "messagelog", "ml"}
+}
+
+func (c *CmdMsgLog) GetDescription() string {
+	return "set the log channel for edited and deleted messages"
+}
+
+func (c *CmdMsgLog) GetHelp() string {
+	return "`msglog` - display current message log settings\n" +
+		"`msglog set` - set this channel as message log channel\n" +
+		"`msglog set <chanResolvable>` - set any text channel as message log channel\n" +
+		"`msglog reset` - reset message log channel\n" +
+		"`msglog ignore channel <chanResolvable>` - add or remove a channel from the ignore list\n" +
+		"`msglog ignore user <userResolvable>` - add or remove a user from the ignore list"
+}
+
+func (c *CmdMsgLog) GetGroup() string {
+	return GroupGuildConfig
+}
+
+func (c *CmdMsgLog) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdMsgLog) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdMsgLog) Exec(args *CommandArgs) error {
+	if len(args.Args) < 1 {
+		return c.printStatus(args)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+	case "set", "s":
+		return c.set(args)
+	case "reset", "r":
+		return c.reset(args)
+	case "ignore", "i":
+		return c.ignore(args)
+	default:
+		return c.printStatus(args)
+	}
+}
+
+func (c *CmdMsgLog) set(args *CommandArgs) error {
+	logChan := args.Channel
+	if len(args.Args) > 1 {
+		var err error
+		logChan, err = util.FetchChannel(args.Session, args.Guild.ID, args.Args[1], func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildText
+		})
+		if err != nil {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Could not find any channel on this guild passing this resolvable.")
+			util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+			return err
+		}
+	}
+
+	if err := args.CmdHandler.db.SetGuildMessageLog(args.Guild.ID, logChan.ID); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Set <#%s> as message log channel.", logChan.ID), "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+	return err
+}
+
+func (c *CmdMsgLog) reset(args *CommandArgs) error {
+	if err := args.CmdHandler.db.SetGuildMessageLog(args.Guild.ID, ""); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		"Message log channel reset.", "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 5*time.Second)
+	return err
+}
+
+func (c *CmdMsgLog) ignore(args *CommandArgs) error {
+	if len(args.Args) < 3 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Invalid arguments. Use `help msglog` to get help about how to use this command.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	db := args.CmdHandler.db
+
+	ignoredChans, ignoredUsers, err := db.GetGuildMessageLogIgnores(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	var id, mention string
+	var list *[]string
+
+	switch strings.ToLower(args.Args[1]) {
+	case "channel", "chan", "c":
+		ch, err := util.FetchChannel(args.Session, args.Guild.ID, args.Args[2], func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildText
+		})
+		if err != nil {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Could not find any channel on this guild passing this resolvable.")
+			util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+			return err
+		}
+		id, mention, list = ch.ID, ch.Mention(), &ignoredChans
+	case "user", "member", "u":
+		member, err := util.FetchMember(args.Session, args.Guild.ID, args.Args[2])
+		if err != nil {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Could not fetch any member by the passed resolvable.")
+			util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+			return err
+		}
+		id, mention, list = member.User.ID, member.User.Mention(), &ignoredUsers
+	default:
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please specify if you want to ignore a `channel` or a `user`.")
+		util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+		return err
+	}
+
+	var resTxt string
+	if i := util.IndexOfStrArray(id, *list); i > -1 {
+		*list = append((*list)[:i], (*list)[i+1:]...)
+		resTxt = fmt.Sprintf("Removed %s from the message log ignore list.", mention)
+	} else {
+		*list = append(*list, id)
+		resTxt = fmt.Sprintf("Added %s to the message log ignore list.", mention)
+	}
+
+	if err = db.SetGuildMessageLogIgnores(args.Guild.ID, ignoredChans, ignoredUsers); err != nil {
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID, resTxt, "", util.ColorEmbedUpdated)
+	util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+	return err
+}
+
+func (c *CmdMsgLog) printStatus(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	logChan, err := db.GetGuildMessageLog(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	ignoredChans, ignoredUsers, err := db.GetGuildMessageLogIgnores(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return err
+	}
+
+	logChanTxt := "*not set*"
+	if logChan != "" {
+		logChanTxt = fmt.Sprintf("<#%s>", logChan)
+	}
+
+	mentions := func(ids []string, format string) string {
+		if len(ids) == 0 {
+			return "*none*"
+		}
+		res := make([]string, len(ids))
+		for i, id := range ids {
+			res[i] = fmt.Sprintf(format, id)
+		}
+		return strings.Join(res, ", ")
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Message Log",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Channel",
+				Value: logChanTxt,
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Ignored Channels",
+				Value: mentions(ignoredChans, "<#%s>"),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Ignored Users",
+				Value: mentions(ignoredUsers, "<@%s>"),
+			},
+		},
+	}
+
+	msg, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+	util.DeleteMessageLater(args.Session, msg, 15*time.Second)
+	return err
+}
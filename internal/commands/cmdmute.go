@@ -24,6 +24,7 @@ func (c *CmdMute) GetDescription() string {
 
 func (c *CmdMute) GetHelp() string {
 	return "`mute setup (<roleResolvable>)` - creates (or uses given) mute role and sets this role in every channel as muted\n" +
+		"`mute check` - check all channels for missing mute role permission overwrites and repair them\n" +
 		"`mute <userResolvable>` - mute/unmute a user\n" +
 		"`mute list` - display muted users on this guild\n" +
 		"`mute` - display currently set mute role"
@@ -51,6 +52,8 @@ func (c *CmdMute) Exec(args *CommandArgs) error {
 		return c.setup(args)
 	case "list":
 		return c.list(args)
+	case "check":
+		return c.check(args)
 	default:
 		return c.muteUnmute(args)
 	}
@@ -80,7 +83,8 @@ func (c *CmdMute) setup(args *CommandArgs) error {
 			Color: util.ColorEmbedDefault,
 			Title: "Warning",
 			Description: desc + " Also, all channels *(which the bot has access to)* will be permission-overwritten that " +
-				"members with this role will not be able to write in these channels anymore.",
+				"members with this role will not be able to write or add reactions in text channels and to " +
+				"connect or speak in voice channels anymore.",
 		},
 		UserID:         args.User.ID,
 		DeleteMsgAfter: true,
@@ -145,6 +149,78 @@ func (c *CmdMute) setup(args *CommandArgs) error {
 	return err
 }
 
+func (c *CmdMute) check(args *CommandArgs) error {
+	muteRoleID, err := args.CmdHandler.db.GetMuteRoleGuild(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) || (err == nil && muteRoleID == "") {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Mute command is not set up. Please enter `mute setup`.")
+		util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	unset := util.MuteGetUnsetChannels(args.Guild, muteRoleID)
+	if len(unset) == 0 {
+		msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+			"All channels are set up correctly for the mute role.", "", util.ColorEmbedGreen)
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+
+	chanList := make([]string, len(unset))
+	for i, ch := range unset {
+		chanList[i] = fmt.Sprintf("`%s` *(%s)*", ch.Name, ch.ID)
+	}
+
+	acmsg := &util.AcceptMessage{
+		Session: args.Session,
+		Embed: &discordgo.MessageEmbed{
+			Color: util.ColorEmbedOrange,
+			Title: "Mute Check",
+			Description: fmt.Sprintf("The permission overwrite of the mute role is missing or incomplete in `%d` channels. "+
+				"Do you want to repair them?", len(unset)),
+			Fields: []*discordgo.MessageEmbedField{
+				&discordgo.MessageEmbedField{
+					Name:  "Channels",
+					Value: truncateList(chanList, 1024),
+				},
+			},
+		},
+		UserID:         args.User.ID,
+		DeleteMsgAfter: true,
+		AcceptFunc: func(msg *discordgo.Message) {
+			denies := make([]int, len(unset))
+			for i, ch := range unset {
+				denies[i] = util.MuteChannelDeny(args.Guild, ch)
+			}
+
+			var nFailed int
+			for i, ch := range unset {
+				err := util.MuteSetupChannel(args.Session, ch, muteRoleID, denies[i])
+				if err != nil {
+					util.Log.Errorf("Failed repairing mute role overwrite in channel '%s': %s", ch.ID, err.Error())
+					nFailed++
+				}
+			}
+
+			if nFailed > 0 {
+				msg, _ := util.SendEmbedError(args.Session, args.Channel.ID,
+					fmt.Sprintf("Failed repairing %d of %d channels. Please check the permissions of the bot.", nFailed, len(unset)))
+				util.DeleteMessageLater(args.Session, msg, 12*time.Second)
+				return
+			}
+
+			msg, _ = util.SendEmbed(args.Session, args.Channel.ID,
+				fmt.Sprintf("Repaired %d channels.", len(unset)), "", util.ColorEmbedUpdated)
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		},
+	}
+
+	_, err = acmsg.Send(args.Channel.ID)
+	return err
+}
+
 func (c *CmdMute) muteUnmute(args *CommandArgs) error {
 	victim, err := util.FetchMember(args.Session, args.Guild.ID, args.Args[0])
 	if err != nil {
@@ -154,10 +230,7 @@ func (c *CmdMute) muteUnmute(args *CommandArgs) error {
 		return err
 	}
 
-	if victim.User.ID == args.User.ID {
-		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-			"You can not mute yourself...")
-		util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+	if ok, err := checkModTarget(args, victim, "mute", true); !ok || err != nil {
 		return err
 	}
 
@@ -78,13 +78,29 @@ func (c *CmdMvall) Exec(args *CommandArgs) error {
 		return err
 	}
 
+	var nSkipped int
 	for _, vs := range args.Guild.VoiceStates {
-		if vs.ChannelID == currVC {
-			err := args.Session.GuildMemberMove(args.Guild.ID, vs.UserID, toVC.ID)
-			if err != nil {
-				return err
+		if vs.ChannelID != currVC {
+			continue
+		}
+		if vs.UserID != args.User.ID {
+			memb, err := args.Session.State.Member(args.Guild.ID, vs.UserID)
+			if err != nil || modTargetError(args, memb, "move", false) != "" {
+				nSkipped++
+				continue
 			}
 		}
+		err := args.Session.GuildMemberMove(args.Guild.ID, vs.UserID, toVC.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if nSkipped > 0 {
+		msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+			fmt.Sprintf("Skipped %d members which you are not allowed to move.", nSkipped), "", util.ColorEmbedOrange)
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
 	}
 
 	return nil
@@ -102,10 +102,7 @@ func (c *CmdReport) Exec(args *CommandArgs) error {
 		repType = minType
 	}
 
-	if victim.User.ID == args.User.ID {
-		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
-			"You can not report yourself...")
-		util.DeleteMessageLater(args.Session, msg, 6*time.Second)
+	if ok, err := checkModTarget(args, victim, "report", false); !ok || err != nil {
 		return err
 	}
 
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdSoftban struct {
+	PermLvl int
+}
+
+func (c *CmdSoftban) GetInvokes() []string {
+	return []string{"softban", "sban"}
+}
+
+func (c *CmdSoftban) GetDescription() string {
+	return "kick members and delete their messages of the last 7 days"
+}
+
+func (c *CmdSoftban) GetHelp() string {
+	return "`softban <UserResolvable> <Reason>` - ban and directly unban a member to purge their messages"
+}
+
+func (c *CmdSoftban) GetGroup() string {
+	return GroupModeration
+}
+
+func (c *CmdSoftban) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdSoftban) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdSoftban) Exec(args *CommandArgs) error {
+	if len(args.Args) < 2 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Invalid command arguments. Please use `help softban` to see how to use this command.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return err
+	}
+	victim, err := util.FetchMember(args.Session, args.Guild.ID, args.Args[0])
+	if err != nil || victim == nil {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Sorry, could not find any member :cry:")
+		util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+		return err
+	}
+
+	if ok, err := checkModTarget(args, victim, "softban", true); !ok || err != nil {
+		return err
+	}
+
+	repMsg := "[SOFTBAN] " + strings.Join(args.Args[1:], " ")
+	repType := util.IndexOfStrArray("KICK", util.ReportTypes)
+
+	acceptMsg := util.AcceptMessage{
+		Embed: &discordgo.MessageEmbed{
+			Color:       util.ReportColors[repType],
+			Title:       "Softban Check",
+			Description: "Is everything okay so far?",
+			Fields: []*discordgo.MessageEmbedField{
+				&discordgo.MessageEmbedField{
+					Name: "Victim",
+					Value: fmt.Sprintf("<@%s> (%s#%s)",
+						victim.User.ID, victim.User.Username, victim.User.Discriminator),
+				},
+				&discordgo.MessageEmbedField{
+					Name:  "Type",
+					Value: util.ReportTypes[repType],
+				},
+				&discordgo.MessageEmbedField{
+					Name:  "Description",
+					Value: repMsg,
+				},
+			},
+		},
+		Session:        args.Session,
+		UserID:         args.User.ID,
+		DeleteMsgAfter: true,
+		AcceptFunc: func(msg *discordgo.Message) {
+			rep, err := banWithReport(args, victim.User.ID, "KICK", repMsg, 7)
+			if err != nil {
+				util.SendEmbedError(args.Session, args.Channel.ID,
+					"Failed softbanning member: ```\n"+err.Error()+"\n```")
+				return
+			}
+			args.Session.ChannelMessageSendEmbed(args.Channel.ID, rep.AsEmbed())
+			if err = args.Session.GuildBanDelete(args.Guild.ID, victim.User.ID); err != nil {
+				util.SendEmbedError(args.Session, args.Channel.ID,
+					"Failed unbanning member: ```\n"+err.Error()+"\n```")
+			}
+		},
+	}
+
+	_, err = acceptMsg.Send(args.Channel.ID)
+
+	return err
+}
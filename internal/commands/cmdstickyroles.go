@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdStickyRoles struct {
+	PermLvl int
+}
+
+func (c *CmdStickyRoles) GetInvokes() []string {
+	return []string{"stickyroles", "sticky", "sr"}
+}
+
+func (c *CmdStickyRoles) GetDescription() string {
+	return "manage roles which are restored when members rejoin the guild"
+}
+
+func (c *CmdStickyRoles) GetHelp() string {
+	return "`stickyroles` - display current sticky roles settings\n" +
+		"`stickyroles <roleResolvable>` - add or remove a role from the sticky roles\n" +
+		"`stickyroles mute <on|off>` - set if the mute role is sticky *(default: on)*\n" +
+		"`stickyroles all <on|off>` - set if all roles of members are restored on rejoin *(default: off)*"
+}
+
+func (c *CmdStickyRoles) GetGroup() string {
+	return GroupGuildConfig
+}
+
+func (c *CmdStickyRoles) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdStickyRoles) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdStickyRoles) Exec(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	settings, err := db.GetGuildStickyRoles(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		settings, err = util.NewDefaultStickyRoleSettings(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(args.Args) < 1 {
+		return c.printStatus(args, settings)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+	case "mute":
+		if ok, err := c.parseSwitch(args, &settings.StickyMute); !ok || err != nil {
+			return err
+		}
+	case "all":
+		if ok, err := c.parseSwitch(args, &settings.RestoreAll); !ok || err != nil {
+			return err
+		}
+	default:
+		role, err := util.FetchRole(args.Session, args.Guild.ID, strings.Join(args.Args, " "))
+		if err != nil {
+			msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+				"Role could not be fetched by passed identifier.")
+			util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+			return err
+		}
+		if i := util.IndexOfStrArray(role.ID, settings.RoleIDs); i > -1 {
+			settings.RoleIDs = append(settings.RoleIDs[:i], settings.RoleIDs[i+1:]...)
+		} else {
+			settings.RoleIDs = append(settings.RoleIDs, role.ID)
+		}
+	}
+
+	if err = db.SetGuildStickyRoles(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	return c.printStatus(args, settings)
+}
+
+func (c *CmdStickyRoles) parseSwitch(args *CommandArgs, val *bool) (bool, error) {
+	if len(args.Args) < 2 {
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please specify either `on` or `off`.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	switch strings.ToLower(args.Args[1]) {
+	case "on", "enable", "true":
+		*val = true
+	case "off", "disable", "false":
+		*val = false
+	default:
+		msg, err := util.SendEmbedError(args.Session, args.Channel.ID,
+			"Please specify either `on` or `off`.")
+		util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (c *CmdStickyRoles) printStatus(args *CommandArgs, settings *util.StickyRoleSettings) error {
+	onOff := func(v bool) string {
+		if v {
+			return "on"
+		}
+		return "off"
+	}
+
+	rolesTxt := "*none*"
+	if len(settings.RoleIDs) > 0 {
+		roles := make([]string, len(settings.RoleIDs))
+		for i, rID := range settings.RoleIDs {
+			roles[i] = fmt.Sprintf("<@&%s>", rID)
+		}
+		rolesTxt = strings.Join(roles, ", ")
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Sticky Roles",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Roles",
+				Value: rolesTxt,
+			},
+			&discordgo.MessageEmbedField{
+				Name:   "Sticky Mute Role",
+				Value:  onOff(settings.StickyMute),
+				Inline: true,
+			},
+			&discordgo.MessageEmbedField{
+				Name:   "Restore All Roles",
+				Value:  onOff(settings.RestoreAll),
+				Inline: true,
+			},
+		},
+	}
+
+	msg, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, emb)
+	util.DeleteMessageLater(args.Session, msg, 15*time.Second)
+	return err
+}
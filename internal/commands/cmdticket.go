@@ -0,0 +1,188 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdTicket struct {
+	PermLvl int
+}
+
+func (c *CmdTicket) GetInvokes() []string {
+	return []string{"ticket", "tickets", "t"}
+}
+
+func (c *CmdTicket) GetDescription() string {
+	return "open and manage support tickets"
+}
+
+func (c *CmdTicket) GetHelp() string {
+	return "`ticket open` - open a new ticket\n" +
+		"`ticket claim` - claim the current ticket *(staff only)*\n" +
+		"`ticket add <userResolvable>` - add a member to the current ticket *(staff only)*\n" +
+		"`ticket remove <userResolvable>` - remove a member from the current ticket *(staff only)*\n" +
+		"`ticket close (--html) (<reason>)` - close the current ticket *(staff and ticket owner only)*\n\n" +
+		"*Staff are members with a staff role or the permission level of the `ticketconfig` command.*"
+}
+
+func (c *CmdTicket) GetGroup() string {
+	return GroupChat
+}
+
+func (c *CmdTicket) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdTicket) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdTicket) Exec(args *CommandArgs) error {
+	if len(args.Args) < 1 {
+		return c.sendError(args, "Invalid arguments. Use `help ticket` to get help about how to use this command.")
+	}
+
+	if strings.ToLower(args.Args[0]) == "open" || strings.ToLower(args.Args[0]) == "new" {
+		return c.open(args)
+	}
+
+	db := args.CmdHandler.db
+
+	ticket, err := db.GetTicketByChannel(args.Channel.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		return c.sendError(args, "This command can only be used in ticket channels.")
+	}
+	if err != nil {
+		return err
+	}
+
+	isStaff, err := c.isStaff(args)
+	if err != nil {
+		return err
+	}
+
+	subCmd := strings.ToLower(args.Args[0])
+
+	if !isStaff && !(subCmd == "close" && args.User.ID == ticket.OwnerID) {
+		return c.sendError(args, "Only staff members are allowed to do this.")
+	}
+
+	switch subCmd {
+
+	case "claim":
+		if ticket.ClaimedBy != "" {
+			return c.sendError(args, fmt.Sprintf("This ticket is already claimed by <@%s>.", ticket.ClaimedBy))
+		}
+		ticket.ClaimedBy = args.User.ID
+		if err = db.UpdateTicket(ticket); err != nil {
+			return err
+		}
+		_, err = util.SendEmbed(args.Session, args.Channel.ID,
+			fmt.Sprintf("%s claimed this ticket.", args.User.Mention()), "", util.ColorEmbedGreen)
+		return err
+
+	case "add", "remove", "rem":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a member.")
+		}
+		memb, err := util.FetchMember(args.Session, args.Guild.ID, strings.Join(args.Args[1:], " "))
+		if err != nil {
+			return c.sendError(args, "Sorry, but the member can not be found on this guild. :cry:")
+		}
+		if subCmd == "add" {
+			err = core.TicketAddMember(args.Session, db, ticket, memb.User.ID)
+		} else {
+			err = core.TicketRemoveMember(args.Session, db, ticket, memb.User.ID)
+		}
+		switch err {
+		case nil:
+		case core.ErrTicketMemberDuplicate:
+			return c.sendError(args, "This member already has access to this ticket.")
+		case core.ErrTicketMemberNotAdded:
+			return c.sendError(args, "This member was not added to this ticket.")
+		default:
+			return err
+		}
+		action := "added to"
+		if subCmd != "add" {
+			action = "removed from"
+		}
+		_, err = util.SendEmbed(args.Session, args.Channel.ID,
+			fmt.Sprintf("%s was %s this ticket.", memb.User.Mention(), action), "", util.ColorEmbedUpdated)
+		return err
+
+	case "close":
+		var asHTML bool
+		reasonArgs := make([]string, 0, len(args.Args)-1)
+		for _, a := range args.Args[1:] {
+			if strings.ToLower(a) == "--html" {
+				asHTML = true
+			} else {
+				reasonArgs = append(reasonArgs, a)
+			}
+		}
+		return core.TicketClose(args.Session, db, ticket, args.User, strings.Join(reasonArgs, " "), asHTML)
+
+	default:
+		return c.sendError(args, "Invalid arguments. Use `help ticket` to get help about how to use this command.")
+	}
+}
+
+func (c *CmdTicket) open(args *CommandArgs) error {
+	ticket, err := core.TicketOpen(args.Session, args.CmdHandler.db, args.Guild.ID, args.User)
+	switch err {
+	case nil:
+	case core.ErrTicketsNotEnabled:
+		return c.sendError(args, "Tickets are not set up on this guild.")
+	case core.ErrTicketLimitReached:
+		return c.sendError(args, "You have reached the limit of open tickets on this guild.")
+	default:
+		return err
+	}
+
+	msg, err := util.SendEmbed(args.Session, args.Channel.ID,
+		fmt.Sprintf("Your ticket was opened: <#%s>", ticket.ChannelID), "", util.ColorEmbedGreen)
+	util.DeleteMessageLater(args.Session, msg, 10*time.Second)
+	return err
+}
+
+// isStaff returns true if the executor has any of the guild's
+// ticket staff roles or at least the permission level of the
+// ticketconfig command.
+func (c *CmdTicket) isStaff(args *CommandArgs) (bool, error) {
+	settings, err := args.CmdHandler.db.GetGuildTickets(args.Guild.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		return false, err
+	}
+
+	if settings != nil {
+		memb, err := args.Session.GuildMember(args.Guild.ID, args.User.ID)
+		if err != nil {
+			return false, err
+		}
+		for _, roleID := range memb.Roles {
+			if util.IndexOfStrArray(roleID, settings.StaffRoleIDs) > -1 {
+				return true, nil
+			}
+		}
+	}
+
+	cmd, ok := args.CmdHandler.GetCommand("ticketconfig")
+	if !ok {
+		return false, nil
+	}
+
+	permLvl, err := args.CmdHandler.GetPermissionLevel(args.Session, args.Guild.ID, args.User.ID)
+	return permLvl >= cmd.GetPermission(), err
+}
+
+func (c *CmdTicket) sendError(args *CommandArgs, txt string) error {
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
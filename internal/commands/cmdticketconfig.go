@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type CmdTicketConfig struct {
+	PermLvl int
+}
+
+func (c *CmdTicketConfig) GetInvokes() []string {
+	return []string{"ticketconfig", "ticketcfg", "tc"}
+}
+
+func (c *CmdTicketConfig) GetDescription() string {
+	return "set up the support ticket system"
+}
+
+func (c *CmdTicketConfig) GetHelp() string {
+	return "`ticketconfig` - display current ticket settings\n" +
+		"`ticketconfig category <categoryResolvable>` - set the category for ticket channels and enable tickets\n" +
+		"`ticketconfig log <chanResolvable>` - set the channel where transcripts of closed tickets are posted\n" +
+		"`ticketconfig staff <roleResolvable>` - add or remove a staff role which has access to all tickets\n" +
+		"`ticketconfig limit <n>` - set the maximum number of open tickets per member *(0 = unlimited)*\n" +
+		"`ticketconfig panel (<chanResolvable>) (<text>)` - post the panel message members can react to for opening tickets\n" +
+		"`ticketconfig disable` - disable tickets"
+}
+
+func (c *CmdTicketConfig) GetGroup() string {
+	return GroupGuildConfig
+}
+
+func (c *CmdTicketConfig) GetPermission() int {
+	return c.PermLvl
+}
+
+func (c *CmdTicketConfig) SetPermission(permLvl int) {
+	c.PermLvl = permLvl
+}
+
+func (c *CmdTicketConfig) Exec(args *CommandArgs) error {
+	db := args.CmdHandler.db
+
+	settings, err := db.GetGuildTickets(args.Guild.ID)
+	if core.IsErrDatabaseNotFound(err) {
+		settings, err = util.NewDefaultTicketSettings(), nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(args.Args) < 1 {
+		return c.printStatus(args, settings)
+	}
+
+	switch strings.ToLower(args.Args[0]) {
+
+	case "category", "cat":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a category.")
+		}
+		ch, err := util.FetchChannel(args.Session, args.Guild.ID, strings.Join(args.Args[1:], " "), func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildCategory
+		})
+		if err != nil {
+			return c.sendError(args, "Could not find any category on this guild passing this resolvable.")
+		}
+		settings.CategoryID = ch.ID
+
+	case "log", "l":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a channel.")
+		}
+		ch, err := util.FetchChannel(args.Session, args.Guild.ID, args.Args[1], func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildText
+		})
+		if err != nil {
+			return c.sendError(args, "Could not find any channel on this guild passing this resolvable.")
+		}
+		settings.LogChannelID = ch.ID
+
+	case "staff", "s":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a role.")
+		}
+		role, err := util.FetchRole(args.Session, args.Guild.ID, strings.Join(args.Args[1:], " "))
+		if err != nil {
+			return c.sendError(args, "Role could not be fetched by passed identifier.")
+		}
+		if i := util.IndexOfStrArray(role.ID, settings.StaffRoleIDs); i > -1 {
+			settings.StaffRoleIDs = append(settings.StaffRoleIDs[:i], settings.StaffRoleIDs[i+1:]...)
+		} else {
+			settings.StaffRoleIDs = append(settings.StaffRoleIDs, role.ID)
+		}
+
+	case "limit":
+		if len(args.Args) < 2 {
+			return c.sendError(args, "Please specify a limit.")
+		}
+		limit, err := strconv.Atoi(args.Args[1])
+		if err != nil || limit < 0 {
+			return c.sendError(args, "Please enter a valid number larger or equal 0.")
+		}
+		settings.MaxOpen = limit
+
+	case "panel", "p":
+		if ok, err := c.postPanel(args, settings); !ok || err != nil {
+			return err
+		}
+
+	case "disable", "d", "off":
+		settings = util.NewDefaultTicketSettings()
+
+	default:
+		return c.sendError(args, "Invalid arguments. Use `help ticketconfig` to get help about how to use this command.")
+	}
+
+	if err = db.SetGuildTickets(args.Guild.ID, settings); err != nil {
+		return err
+	}
+
+	return c.printStatus(args, settings)
+}
+
+func (c *CmdTicketConfig) postPanel(args *CommandArgs, settings *util.TicketSettings) (bool, error) {
+	if !settings.IsEnabled() {
+		return false, c.sendError(args, "Please set a ticket category first.")
+	}
+
+	panelChan := args.Channel
+	textArgs := args.Args[1:]
+	if len(textArgs) > 0 {
+		ch, err := util.FetchChannel(args.Session, args.Guild.ID, textArgs[0], func(c *discordgo.Channel) bool {
+			return c.Type == discordgo.ChannelTypeGuildText
+		})
+		if err == nil {
+			panelChan = ch
+			textArgs = textArgs[1:]
+		}
+	}
+
+	text := strings.Join(textArgs, " ")
+	if text == "" {
+		text = fmt.Sprintf("React with %s to open a support ticket.", core.TicketEmoji)
+	}
+
+	msg, err := args.Session.ChannelMessageSendEmbed(panelChan.ID, &discordgo.MessageEmbed{
+		Color:       util.ColorEmbedDefault,
+		Title:       "Support Tickets",
+		Description: text,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if err = args.Session.MessageReactionAdd(msg.ChannelID, msg.ID, core.TicketEmoji); err != nil {
+		return false, err
+	}
+
+	if settings.PanelMessageID != "" {
+		args.Session.ChannelMessageDelete(settings.PanelChannelID, settings.PanelMessageID)
+	}
+
+	settings.PanelChannelID = msg.ChannelID
+	settings.PanelMessageID = msg.ID
+
+	return true, nil
+}
+
+func (c *CmdTicketConfig) sendError(args *CommandArgs, txt string) error {
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, txt)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return err
+}
+
+func (c *CmdTicketConfig) printStatus(args *CommandArgs, settings *util.TicketSettings) error {
+	status := "disabled"
+	color := util.ColorEmbedOrange
+	category := "*not set*"
+	if settings.IsEnabled() {
+		status = "enabled"
+		color = util.ColorEmbedGreen
+		category = fmt.Sprintf("<#%s>", settings.CategoryID)
+	}
+
+	logChan := "*not set*"
+	if settings.LogChannelID != "" {
+		logChan = fmt.Sprintf("<#%s>", settings.LogChannelID)
+	}
+
+	panel := "*not posted*"
+	if settings.PanelMessageID != "" {
+		panel = fmt.Sprintf("[message](https://discordapp.com/channels/%s/%s/%s) in <#%s>",
+			args.Guild.ID, settings.PanelChannelID, settings.PanelMessageID, settings.PanelChannelID)
+	}
+
+	roles := make([]string, len(settings.StaffRoleIDs))
+	for i, roleID := range settings.StaffRoleIDs {
+		roles[i] = fmt.Sprintf("<@&%s>", roleID)
+	}
+
+	limit := "unlimited"
+	if settings.MaxOpen > 0 {
+		limit = strconv.Itoa(settings.MaxOpen)
+	}
+
+	_, err := args.Session.ChannelMessageSendEmbed(args.Channel.ID, &discordgo.MessageEmbed{
+		Color: color,
+		Title: "Tickets",
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Status",
+				Value:  status,
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Category",
+				Value:  category,
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Log Channel",
+				Value:  logChan,
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Open Tickets per Member",
+				Value:  limit,
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Panel",
+				Value:  panel,
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Staff Roles",
+				Value: util.EnsureNotEmpty(strings.Join(roles, ", "), "*no staff roles*"),
+			},
+		},
+	})
+	return err
+}
@@ -57,14 +57,31 @@ func (c *CmdTwitchNotify) Exec(args *CommandArgs) error {
 			return err
 		}
 
-		notsStr := ""
-
+		guildNots := make([]*core.TwitchNotifyDBEntry, 0)
+		userIDs := make([]string, 0)
 		for _, not := range nots {
 			if not.GuildID == args.Guild.ID {
-				if tUser, err := tnw.GetUser(not.TwitchUserID, core.TwitchNotifyIdentID); err == nil {
-					notsStr += fmt.Sprintf(":white_small_square:  **%s** in <#%s>\n",
-						tUser.DisplayName, not.ChannelID)
-				}
+				guildNots = append(guildNots, not)
+				userIDs = append(userIDs, not.TwitchUserID)
+			}
+		}
+
+		tUsers, err := tnw.GetUsers(userIDs, core.TwitchNotifyIdentID)
+		if err != nil {
+			return err
+		}
+
+		tUsersByID := make(map[string]*core.TwitchNotifyUser)
+		for _, tUser := range tUsers {
+			tUsersByID[tUser.ID] = tUser
+		}
+
+		notsStr := ""
+
+		for _, not := range guildNots {
+			if tUser, ok := tUsersByID[not.TwitchUserID]; ok {
+				notsStr += fmt.Sprintf(":white_small_square:  **%s** in <#%s>\n",
+					tUser.DisplayName, not.ChannelID)
 			}
 		}
 
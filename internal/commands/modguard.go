@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+// checkModTarget checks if the command's author is allowed to perform
+// the passed moderation action on the victim. If botAction is true,
+// it is also checked if the bot's highest role is above the victim's.
+// If the check fails, an error message is sent into the command
+// channel and false is returned.
+func checkModTarget(args *CommandArgs, victim *discordgo.Member, action string, botAction bool) (bool, error) {
+	errTxt := modTargetError(args, victim, action, botAction)
+	if errTxt == "" {
+		return true, nil
+	}
+
+	msg, err := util.SendEmbedError(args.Session, args.Channel.ID, errTxt)
+	util.DeleteMessageLater(args.Session, msg, 8*time.Second)
+	return false, err
+}
+
+// modTargetError returns a description why the command's author is
+// not allowed to perform the passed moderation action on the victim
+// or an empty string, if the action is allowed.
+func modTargetError(args *CommandArgs, victim *discordgo.Member, action string, botAction bool) string {
+	if errTxt := modTargetIDError(args, victim.User.ID, action); errTxt != "" {
+		return errTxt
+	}
+
+	if args.User.ID != args.Guild.OwnerID {
+		authorMemb, err := args.Session.GuildMember(args.Guild.ID, args.User.ID)
+		if err != nil || util.RolePosDiff(victim, authorMemb, args.Guild) >= 0 {
+			return fmt.Sprintf("You can only %s members with lower permissions than yours.", action)
+		}
+	}
+
+	if botAction {
+		botMemb, err := args.Session.GuildMember(args.Guild.ID, args.Session.State.User.ID)
+		if err != nil || util.RolePosDiff(victim, botMemb, args.Guild) >= 0 {
+			return fmt.Sprintf("I can not %s this member because their highest role is not below mine.", action)
+		}
+	}
+
+	return ""
+}
+
+// ModTargetError returns a description why the user is not allowed
+// to perform the moderation action on the victim or an empty string,
+// if the action is allowed. It is used for moderation actions which
+// are not executed by commands.
+func (c *CmdHandler) ModTargetError(s *discordgo.Session, guild *discordgo.Guild, user *discordgo.User,
+	victimID, action string, botAction bool) string {
+
+	args := &CommandArgs{
+		Session:    s,
+		Guild:      guild,
+		User:       user,
+		CmdHandler: c,
+	}
+
+	victim, err := s.GuildMember(guild.ID, victimID)
+	if err != nil {
+		return modTargetIDError(args, victimID, action)
+	}
+	return modTargetError(args, victim, action, botAction)
+}
+
+// modTargetIDError checks the passed user ID against protected
+// users and returns a description why the moderation action is
+// not allowed or an empty string, if it is allowed. Use this for
+// users which are not members of the guild.
+func modTargetIDError(args *CommandArgs, userID, action string) string {
+	switch userID {
+	case args.User.ID:
+		return fmt.Sprintf("You can not %s yourself...", action)
+	case args.Guild.OwnerID:
+		return fmt.Sprintf("You can not %s the owner of this guild.", action)
+	case args.CmdHandler.config.Discord.OwnerID:
+		return fmt.Sprintf("You can not %s the owner of this bot.", action)
+	case args.Session.State.User.ID:
+		return fmt.Sprintf("I can not %s myself.", action)
+	}
+	return ""
+}
+
+// BotModTargetFunc returns a function which checks if the bot
+// itself is allowed to perform the moderation action on the
+// victim. It is used for automatically executed actions.
+func (c *CmdHandler) BotModTargetFunc(s *discordgo.Session, action string) core.ModTargetFunc {
+	return func(guildID, victimID string) string {
+		guild, err := s.Guild(guildID)
+		if err != nil {
+			return fmt.Sprintf("I can not %s this member because the guild could not be fetched.", action)
+		}
+		return c.ModTargetError(s, guild, s.State.User, victimID, action, true)
+	}
+}
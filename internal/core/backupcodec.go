@@ -0,0 +1,215 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+const (
+	BackupCompressionNone = "none"
+	BackupCompressionGzip = "gzip"
+)
+
+const (
+	backupFormatVersion = 1
+
+	backupFlagGzip      = 1 << 0
+	backupFlagEncrypted = 1 << 1
+
+	// backupMaxDecompressedSize is the maximum size of
+	// the decompressed JSON data of a backup.
+	backupMaxDecompressedSize = 128 * 1024 * 1024
+)
+
+// backupMagic prefixes all encoded backups. Backups
+// without this prefix are treated as plain JSON
+// backups created by earlier versions.
+var backupMagic = []byte("SPBK")
+
+var (
+	ErrBackupChecksumMismatch = errors.New("backup checksum mismatch")
+	ErrBackupEncrypted        = errors.New("backup is encrypted but no encryption key is configured")
+	ErrBackupMalformed        = errors.New("malformed backup data")
+	ErrBackupTooLarge         = errors.New("decompressed backup data exceeds the size limit")
+)
+
+// BackupCodec encodes backup objects to compressed
+// and optionally encrypted binary blobs and decodes
+// them back.
+type BackupCodec struct {
+	compression string
+	key         []byte
+}
+
+// NewBackupCodec creates a BackupCodec from the passed
+// backup config. The AES-256 key is derived from the
+// configured encryption key by hashing it with SHA-256.
+func NewBackupCodec(cfg *ConfigBackups) (*BackupCodec, error) {
+	c := &BackupCodec{
+		compression: strings.ToLower(cfg.Compression),
+	}
+
+	switch c.compression {
+	case "":
+		c.compression = BackupCompressionGzip
+	case BackupCompressionGzip, BackupCompressionNone:
+	default:
+		return nil, fmt.Errorf("unsupported backup compression '%s'", cfg.Compression)
+	}
+
+	if cfg.EncryptionKey != "" {
+		key := sha256.Sum256([]byte(cfg.EncryptionKey))
+		c.key = key[:]
+	}
+
+	return c, nil
+}
+
+// BackupChecksum returns the hex encoded SHA-256
+// hash of the passed encoded backup data.
+func BackupChecksum(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// Encode serializes the backup object and compresses
+// and encrypts it depending on the codec's settings.
+func (c *BackupCodec) Encode(backup *BackupObject) ([]byte, error) {
+	data, err := json.Marshal(backup)
+	if err != nil {
+		return nil, err
+	}
+
+	var flags byte
+
+	if c.compression == BackupCompressionGzip {
+		buf := new(bytes.Buffer)
+		zw := gzip.NewWriter(buf)
+		if _, err = zw.Write(data); err != nil {
+			return nil, err
+		}
+		if err = zw.Close(); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+		flags |= backupFlagGzip
+	}
+
+	if c.key != nil {
+		if data, err = c.encrypt(data); err != nil {
+			return nil, err
+		}
+		flags |= backupFlagEncrypted
+	}
+
+	res := make([]byte, 0, len(backupMagic)+2+len(data))
+	res = append(res, backupMagic...)
+	res = append(res, backupFormatVersion, flags)
+	res = append(res, data...)
+
+	return res, nil
+}
+
+// Decode parses the passed backup data. Plain JSON
+// backups without format header are supported too.
+func (c *BackupCodec) Decode(data []byte) (*BackupObject, error) {
+	if bytes.HasPrefix(data, backupMagic) {
+		var err error
+		if data, err = c.unwrap(data[len(backupMagic):]); err != nil {
+			return nil, err
+		}
+	}
+
+	backup := new(BackupObject)
+	if err := json.Unmarshal(data, backup); err != nil {
+		return nil, err
+	}
+
+	return backup, nil
+}
+
+func (c *BackupCodec) unwrap(data []byte) ([]byte, error) {
+	if len(data) < 2 {
+		return nil, ErrBackupMalformed
+	}
+	if data[0] != backupFormatVersion {
+		return nil, fmt.Errorf("unsupported backup format version %d", data[0])
+	}
+
+	flags := data[1]
+	data = data[2:]
+
+	var err error
+
+	if flags&backupFlagEncrypted != 0 {
+		if c.key == nil {
+			return nil, ErrBackupEncrypted
+		}
+		if data, err = c.decrypt(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if flags&backupFlagGzip != 0 {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		if data, err = ioutil.ReadAll(io.LimitReader(zr, backupMaxDecompressedSize+1)); err != nil {
+			return nil, err
+		}
+		if len(data) > backupMaxDecompressedSize {
+			return nil, ErrBackupTooLarge
+		}
+	}
+
+	return data, nil
+}
+
+func (c *BackupCodec) gcm() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (c *BackupCodec) encrypt(data []byte) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+func (c *BackupCodec) decrypt(data []byte) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrBackupMalformed
+	}
+
+	nonce, data := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, data, nil)
+}
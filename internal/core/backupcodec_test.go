@@ -0,0 +1,139 @@
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+)
+
+func testBackupObject() *BackupObject {
+	return &BackupObject{
+		ID:    "backup",
+		Guild: &BackupGuild{ID: "guild", Name: "some guild name"},
+		Roles: []*BackupRole{{ID: "role", Name: "admin", Permissions: 8}},
+		Channels: []*BackupChannel{
+			{ID: "channel", Name: "general", Topic: "some channel topic"},
+		},
+		Members: []*BackupMember{{ID: "member", Nick: "nick", Roles: []string{"role"}}},
+	}
+}
+
+func TestBackupCodecRoundTrip(t *testing.T) {
+	cases := []struct {
+		name  string
+		cfg   *ConfigBackups
+		flags byte
+	}{
+		{"default", &ConfigBackups{}, backupFlagGzip},
+		{"none", &ConfigBackups{Compression: "none"}, 0},
+		{"gzip", &ConfigBackups{Compression: "GZIP"}, backupFlagGzip},
+		{"encrypted", &ConfigBackups{Compression: "none", EncryptionKey: "key"}, backupFlagEncrypted},
+		{"gzip encrypted", &ConfigBackups{Compression: "gzip", EncryptionKey: "key"}, backupFlagGzip | backupFlagEncrypted},
+	}
+
+	backup := testBackupObject()
+	exp, _ := json.Marshal(backup)
+
+	for _, tc := range cases {
+		codec, err := NewBackupCodec(tc.cfg)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err.Error())
+		}
+
+		data, err := codec.Encode(backup)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err.Error())
+		}
+
+		if !bytes.HasPrefix(data, backupMagic) {
+			t.Errorf("%s: encoded data has no magic prefix", tc.name)
+		} else if v, flags := data[len(backupMagic)], data[len(backupMagic)+1]; v != backupFormatVersion || flags != tc.flags {
+			t.Errorf("%s: header was version %d flags %d, expected version %d flags %d",
+				tc.name, v, flags, backupFormatVersion, tc.flags)
+		}
+		if tc.flags != 0 && bytes.Contains(data, []byte(backup.Guild.Name)) {
+			t.Errorf("%s: encoded data contains plain text", tc.name)
+		}
+
+		decoded, err := codec.Decode(data)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err.Error())
+		}
+		if res, _ := json.Marshal(decoded); !bytes.Equal(res, exp) {
+			t.Errorf("%s: decoded backup was\n%s\nexpected\n%s", tc.name, res, exp)
+		}
+	}
+}
+
+func TestBackupCodecDecodeLegacy(t *testing.T) {
+	backup := testBackupObject()
+	data, _ := json.Marshal(backup)
+
+	for _, cfg := range []*ConfigBackups{{}, {EncryptionKey: "key"}} {
+		codec, err := NewBackupCodec(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := codec.Decode(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res, _ := json.Marshal(decoded); !bytes.Equal(res, data) {
+			t.Errorf("decoded legacy backup was\n%s\nexpected\n%s", res, data)
+		}
+	}
+}
+
+func TestBackupCodecDecodeErrors(t *testing.T) {
+	encrypted, _ := NewBackupCodec(&ConfigBackups{EncryptionKey: "key"})
+	data, err := encrypted.Encode(testBackupObject())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, _ := NewBackupCodec(&ConfigBackups{})
+	if _, err = plain.Decode(data); err != ErrBackupEncrypted {
+		t.Errorf("decoding without key returned %v, expected %v", err, ErrBackupEncrypted)
+	}
+
+	wrongKey, _ := NewBackupCodec(&ConfigBackups{EncryptionKey: "other key"})
+	if _, err = wrongKey.Decode(data); err == nil {
+		t.Error("decoding with wrong key succeeded")
+	}
+
+	if _, err = plain.Decode(backupMagic); err != ErrBackupMalformed {
+		t.Errorf("decoding header only returned %v, expected %v", err, ErrBackupMalformed)
+	}
+
+	if _, err = plain.Decode(append(append([]byte{}, backupMagic...), backupFormatVersion+1, 0)); err == nil {
+		t.Error("decoding unsupported format version succeeded")
+	}
+
+	if _, err = NewBackupCodec(&ConfigBackups{Compression: "zip"}); err == nil {
+		t.Error("creating codec with unsupported compression succeeded")
+	}
+}
+
+func TestBackupCodecDecodeTooLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping decompression of oversized backup in short mode")
+	}
+
+	buf := bytes.NewBuffer(append(append([]byte{}, backupMagic...), backupFormatVersion, backupFlagGzip))
+	zw := gzip.NewWriter(buf)
+	chunk := make([]byte, 1024*1024)
+	for written := 0; written <= backupMaxDecompressedSize; written += len(chunk) {
+		if _, err := zw.Write(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	codec, _ := NewBackupCodec(&ConfigBackups{})
+	if _, err := codec.Decode(buf.Bytes()); err != ErrBackupTooLarge {
+		t.Errorf("decoding oversized backup returned %v, expected %v", err, ErrBackupTooLarge)
+	}
+}
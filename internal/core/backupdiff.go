@@ -0,0 +1,295 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	BackupChangeAdded   = "added"
+	BackupChangeRemoved = "removed"
+	BackupChangeChanged = "changed"
+)
+
+// BackupDiffEntry describes a difference of a
+// role, channel or member between two backups.
+type BackupDiffEntry struct {
+	Part    string
+	Change  string
+	Name    string
+	Details string
+}
+
+// DiffBackup compares the backup fileIDA of the guild with
+// the backup fileIDB. If fileIDB is empty, the backup is
+// compared with the current state of the guild.
+func (bck *GuildBackups) DiffBackup(guildID, fileIDA, fileIDB string) ([]*BackupDiffEntry, error) {
+	if bck.session == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	a, err := bck.readBackup(guildID, fileIDA)
+	if err != nil {
+		return nil, err
+	}
+
+	var b *BackupObject
+	if fileIDB != "" {
+		b, err = bck.readBackup(guildID, fileIDB)
+	} else {
+		var g *discordgo.Guild
+		if g, err = bck.session.Guild(guildID); err == nil {
+			b = newBackupObject(g)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffBackups(a, b), nil
+}
+
+// DiffBackups returns all differences of roles, channels
+// and member role assignments between backup a and b.
+// Roles and channels are matched by their ID first and
+// then by their name.
+func DiffBackups(a, b *BackupObject) []*BackupDiffEntry {
+	diff := make([]*BackupDiffEntry, 0)
+	add := func(part, change, name string, details []string) {
+		diff = append(diff, &BackupDiffEntry{
+			Part:    part,
+			Change:  change,
+			Name:    name,
+			Details: strings.Join(details, ", "),
+		})
+	}
+
+	roleNamesA := make(map[string]string)
+	roleNamesB := make(map[string]string)
+	for _, r := range a.Roles {
+		roleNamesA[r.ID] = r.Name
+	}
+	for _, r := range b.Roles {
+		roleNamesB[r.ID] = r.Name
+	}
+
+	// ROLES
+	rolesB := make(map[string]*BackupRole)
+	for _, r := range b.Roles {
+		rolesB[r.ID] = r
+	}
+	roleIDs := matchBackupObjects(len(a.Roles), len(b.Roles),
+		func(i int) (string, string) { return a.Roles[i].ID, a.Roles[i].Name },
+		func(i int) (string, string) { return b.Roles[i].ID, b.Roles[i].Name })
+
+	for _, ra := range a.Roles {
+		rb, ok := rolesB[roleIDs[ra.ID]]
+		if !ok {
+			add("roles", BackupChangeRemoved, ra.Name, nil)
+			continue
+		}
+		changes := make([]string, 0)
+		if ra.Name != rb.Name {
+			changes = append(changes, fmt.Sprintf("name: %s → %s", ra.Name, rb.Name))
+		}
+		if ra.Color != rb.Color {
+			changes = append(changes, fmt.Sprintf("color: #%06x → #%06x", ra.Color, rb.Color))
+		}
+		if ra.Permissions != rb.Permissions {
+			changes = append(changes, permissionChanges("permissions", ra.Permissions, rb.Permissions))
+		}
+		if len(changes) > 0 {
+			add("roles", BackupChangeChanged, rb.Name, changes)
+		}
+	}
+	matchedRoles := valueSet(roleIDs)
+	for _, rb := range b.Roles {
+		if !matchedRoles[rb.ID] {
+			add("roles", BackupChangeAdded, rb.Name, nil)
+		}
+	}
+
+	// CHANNELS
+	channelsB := make(map[string]*BackupChannel)
+	for _, c := range b.Channels {
+		channelsB[c.ID] = c
+	}
+	channelIDs := matchBackupObjects(len(a.Channels), len(b.Channels),
+		func(i int) (string, string) { return a.Channels[i].ID, a.Channels[i].Name },
+		func(i int) (string, string) { return b.Channels[i].ID, b.Channels[i].Name })
+
+	for _, ca := range a.Channels {
+		cb, ok := channelsB[channelIDs[ca.ID]]
+		if !ok {
+			add("channels", BackupChangeRemoved, ca.Name, nil)
+			continue
+		}
+		changes := make([]string, 0)
+		if ca.Name != cb.Name {
+			changes = append(changes, fmt.Sprintf("name: %s → %s", ca.Name, cb.Name))
+		}
+		if ca.Topic != cb.Topic {
+			changes = append(changes, "topic")
+		}
+		if ca.Position != cb.Position {
+			changes = append(changes, fmt.Sprintf("position: %d → %d", ca.Position, cb.Position))
+		}
+		if ca.PermissionOverwrites != nil && cb.PermissionOverwrites != nil {
+			changes = append(changes, overwriteChanges(ca.PermissionOverwrites, cb.PermissionOverwrites,
+				roleIDs, roleNamesB)...)
+		}
+		if len(changes) > 0 {
+			add("channels", BackupChangeChanged, cb.Name, changes)
+		}
+	}
+	matchedChannels := valueSet(channelIDs)
+	for _, cb := range b.Channels {
+		if !matchedChannels[cb.ID] {
+			add("channels", BackupChangeAdded, cb.Name, nil)
+		}
+	}
+
+	// MEMBERS
+	membersB := make(map[string]*BackupMember)
+	for _, m := range b.Members {
+		membersB[m.ID] = m
+	}
+
+	for _, ma := range a.Members {
+		mb, ok := membersB[ma.ID]
+		if !ok {
+			continue
+		}
+
+		mappedRoles := make([]string, 0, len(ma.Roles))
+		for _, r := range ma.Roles {
+			if id, ok := roleIDs[r]; ok {
+				mappedRoles = append(mappedRoles, id)
+			}
+		}
+
+		changes := make([]string, 0)
+		removed, added := diffIDs(mappedRoles, mb.Roles)
+		for _, r := range added {
+			changes = append(changes, "+"+roleNamesB[r])
+		}
+		for _, r := range removed {
+			changes = append(changes, "-"+roleNamesB[r])
+		}
+		for _, r := range ma.Roles {
+			if _, ok := roleIDs[r]; !ok {
+				changes = append(changes, "-"+roleNamesA[r])
+			}
+		}
+		if len(changes) > 0 {
+			add("members", BackupChangeChanged, ma.ID, changes)
+		}
+	}
+
+	return diff
+}
+
+// matchBackupObjects maps the IDs of the objects of a to the
+// IDs of the objects of b. Objects are matched by their ID
+// first and then by their name.
+func matchBackupObjects(lenA, lenB int, getA, getB func(i int) (id, name string)) map[string]string {
+	res := make(map[string]string)
+	used := make(map[string]bool)
+
+	idsB := make(map[string]bool)
+	for i := 0; i < lenB; i++ {
+		id, _ := getB(i)
+		idsB[id] = true
+	}
+
+	for i := 0; i < lenA; i++ {
+		id, _ := getA(i)
+		if idsB[id] {
+			res[id] = id
+			used[id] = true
+		}
+	}
+
+	for i := 0; i < lenA; i++ {
+		idA, nameA := getA(i)
+		if _, ok := res[idA]; ok {
+			continue
+		}
+		for j := 0; j < lenB; j++ {
+			idB, nameB := getB(j)
+			if !used[idB] && nameA == nameB {
+				res[idA] = idB
+				used[idB] = true
+				break
+			}
+		}
+	}
+
+	return res
+}
+
+func overwriteChanges(a, b []*discordgo.PermissionOverwrite, roleIDs, roleNames map[string]string) []string {
+	target := func(po *discordgo.PermissionOverwrite, id string) string {
+		if po.Type == "role" {
+			if name, ok := roleNames[id]; ok {
+				return "@" + name
+			}
+			return "role " + id
+		}
+		return "member " + id
+	}
+
+	overwritesB := make(map[string]*discordgo.PermissionOverwrite)
+	for _, po := range b {
+		overwritesB[po.ID] = po
+	}
+
+	changes := make([]string, 0)
+	matched := make(map[string]bool)
+
+	for _, poA := range a {
+		id := poA.ID
+		if mapped, ok := roleIDs[id]; ok && poA.Type == "role" {
+			id = mapped
+		}
+
+		poB, ok := overwritesB[id]
+		if !ok {
+			changes = append(changes, "overwrite removed: "+target(poA, id))
+			continue
+		}
+		matched[id] = true
+
+		if poA.Allow != poB.Allow {
+			changes = append(changes, permissionChanges("allow "+target(poB, id), poA.Allow, poB.Allow))
+		}
+		if poA.Deny != poB.Deny {
+			changes = append(changes, permissionChanges("deny "+target(poB, id), poA.Deny, poB.Deny))
+		}
+	}
+
+	for _, poB := range b {
+		if !matched[poB.ID] {
+			changes = append(changes, "overwrite added: "+target(poB, poB.ID))
+		}
+	}
+
+	return changes
+}
+
+// permissionChanges formats the permission bits which
+// were added and removed from permission value a to b.
+func permissionChanges(name string, a, b int) string {
+	return fmt.Sprintf("%s: +0x%x -0x%x", name, b&^a, a&^b)
+}
+
+func valueSet(m map[string]string) map[string]bool {
+	res := make(map[string]bool)
+	for _, v := range m {
+		res[v] = true
+	}
+	return res
+}
@@ -0,0 +1,742 @@
+package core
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type BackupEmoji struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Roles    []string `json:"roles"`
+	Animated bool     `json:"animated"`
+	Image    string   `json:"image"`
+}
+
+type BackupBan struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+type BackupWebhook struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+	Name      string `json:"name"`
+	Avatar    string `json:"avatar"`
+}
+
+// BackupBotSettings contains the guild
+// specific settings of shinpuru.
+type BackupBotSettings struct {
+	Prefix              string         `json:"prefix"`
+	AutoRoleID          string         `json:"autorole_id"`
+	Permissions         map[string]int `json:"permissions"`
+	ModLogChannelID     string         `json:"modlog_channel_id"`
+	VoiceLogChannelID   string         `json:"voicelog_channel_id"`
+	MessageLogChannelID string         `json:"messagelog_channel_id"`
+	MemberLogChannelID  string         `json:"memberlog_channel_id"`
+	JoinMsgChannelID    string         `json:"joinmsg_channel_id"`
+	JoinMsg             string         `json:"joinmsg"`
+	LeaveMsgChannelID   string         `json:"leavemsg_channel_id"`
+	LeaveMsg            string         `json:"leavemsg"`
+}
+
+// backupLiveExtras contains live data of a guild
+// which is not available in the discordgo state.
+type backupLiveExtras struct {
+	iconHash   string
+	bannerHash string
+	rateLimits map[string]int
+	bans       map[string]bool
+	webhooks   []*discordgo.Webhook
+	settings   *BackupBotSettings
+	// errors contains the errors of parts whose
+	// live data could not be fetched.
+	errors map[int]error
+}
+
+// availableParts returns the passed parts without
+// the parts whose live data could not be fetched.
+func (l *backupLiveExtras) availableParts(parts int) int {
+	for part := range l.errors {
+		parts &^= part
+	}
+	return parts
+}
+
+// partErrors returns the errors of all parts whose live
+// data could not be fetched, sorted by the part flags.
+func (l *backupLiveExtras) partErrors() []error {
+	parts := make([]int, 0, len(l.errors))
+	for part := range l.errors {
+		parts = append(parts, part)
+	}
+	sort.Ints(parts)
+
+	errs := make([]error, len(parts))
+	for i, part := range parts {
+		errs[i] = fmt.Errorf("%s can not be restored: %s", BackupPartsString(part), l.errors[part].Error())
+	}
+	return errs
+}
+
+type restGuild struct {
+	Icon   string `json:"icon"`
+	Banner string `json:"banner"`
+}
+
+type restChannel struct {
+	ID               string `json:"id"`
+	RateLimitPerUser int    `json:"rate_limit_per_user"`
+}
+
+func emojiImageURL(e *discordgo.Emoji) string {
+	ext := ".png"
+	if e.Animated {
+		ext = ".gif"
+	}
+	return discordgo.EndpointCDN + "emojis/" + e.ID + ext
+}
+
+func guildBannerURL(guildID, hash string) string {
+	return discordgo.EndpointCDN + "banners/" + guildID + "/" + hash + ".png"
+}
+
+func webhookAvatarURL(w *discordgo.Webhook) string {
+	return discordgo.EndpointCDNAvatars + w.ID + "/" + w.Avatar + ".png"
+}
+
+func ignoreNotFound(err error) error {
+	if IsErrDatabaseNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// downloadImage downloads the image from the passed
+// URL and returns it as base64 encoded data URI.
+func (bck *GuildBackups) downloadImage(url string) (string, error) {
+	res, err := bck.session.Client.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed downloading image: %s", res.Status)
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s",
+		http.DetectContentType(data), base64.StdEncoding.EncodeToString(data)), nil
+}
+
+func (bck *GuildBackups) restGuild(guildID string) (*restGuild, error) {
+	endpoint := discordgo.EndpointGuild(guildID)
+	body, err := bck.session.RequestWithBucketID("GET", endpoint, nil, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	g := new(restGuild)
+	err = json.Unmarshal(body, g)
+	return g, err
+}
+
+func (bck *GuildBackups) restRateLimits(guildID string) (map[string]int, error) {
+	endpoint := discordgo.EndpointGuildChannels(guildID)
+	body, err := bck.session.RequestWithBucketID("GET", endpoint, nil, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []*restChannel
+	if err = json.Unmarshal(body, &channels); err != nil {
+		return nil, err
+	}
+
+	rateLimits := make(map[string]int)
+	for _, c := range channels {
+		rateLimits[c.ID] = c.RateLimitPerUser
+	}
+
+	return rateLimits, nil
+}
+
+// setChannelRateLimit sets the slowmode of the channel. This
+// is not done via ChannelEditComplex because a rate limit of
+// 0 would be omitted there.
+func (bck *GuildBackups) setChannelRateLimit(channelID string, rateLimit int) error {
+	endpoint := discordgo.EndpointChannel(channelID)
+	_, err := bck.session.RequestWithBucketID("PATCH", endpoint,
+		map[string]int{"rate_limit_per_user": rateLimit}, endpoint)
+	return err
+}
+
+func (bck *GuildBackups) setGuildBanner(guildID, banner string) error {
+	endpoint := discordgo.EndpointGuild(guildID)
+	_, err := bck.session.RequestWithBucketID("PATCH", endpoint,
+		map[string]string{"banner": banner}, endpoint)
+	return err
+}
+
+func (bck *GuildBackups) getBotSettings(guildID string) (*BackupBotSettings, error) {
+	s := new(BackupBotSettings)
+	var err error
+
+	if s.Prefix, err = bck.db.GetGuildPrefix(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+	if s.AutoRoleID, err = bck.db.GetGuildAutoRole(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+	if s.Permissions, err = bck.db.GetGuildPermissions(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+	if s.ModLogChannelID, err = bck.db.GetGuildModLog(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+	if s.VoiceLogChannelID, err = bck.db.GetGuildVoiceLog(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+	if s.MessageLogChannelID, err = bck.db.GetGuildMessageLog(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+	if s.MemberLogChannelID, err = bck.db.GetGuildMemberLog(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+	if s.JoinMsgChannelID, s.JoinMsg, err = bck.db.GetGuildJoinMsg(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+	if s.LeaveMsgChannelID, s.LeaveMsg, err = bck.db.GetGuildLeaveMsg(guildID); ignoreNotFound(err) != nil {
+		return nil, err
+	}
+
+	if s.Permissions == nil {
+		s.Permissions = make(map[string]int)
+	}
+
+	return s, nil
+}
+
+// addBackupExtras adds emojis, bans, webhooks, images,
+// channel rate limits and bot settings to the backup.
+// Parts which fail to be backed up are logged and left
+// empty so that they are skipped on restore.
+func (bck *GuildBackups) addBackupExtras(g *discordgo.Guild, backup *BackupObject) {
+	warn := func(part string, err error) {
+		util.Log.Warningf("failed backing up %s of guild '%s': %s", part, g.ID, err.Error())
+	}
+
+	if rg, err := bck.restGuild(g.ID); err != nil {
+		warn("guild images", err)
+	} else {
+		backup.Guild.IconHash = rg.Icon
+		backup.Guild.BannerHash = rg.Banner
+		if rg.Icon != "" {
+			if backup.Guild.Icon, err = bck.downloadImage(discordgo.EndpointGuildIcon(g.ID, rg.Icon)); err != nil {
+				warn("guild icon", err)
+			}
+		}
+		if rg.Banner != "" {
+			if backup.Guild.Banner, err = bck.downloadImage(guildBannerURL(g.ID, rg.Banner)); err != nil {
+				warn("guild banner", err)
+			}
+		}
+	}
+
+	if rateLimits, err := bck.restRateLimits(g.ID); err != nil {
+		warn("channel rate limits", err)
+	} else {
+		for _, c := range backup.Channels {
+			c.RateLimitPerUser = rateLimits[c.ID]
+		}
+	}
+
+	emojis := make([]*BackupEmoji, 0, len(g.Emojis))
+	for _, e := range g.Emojis {
+		if e.Managed {
+			continue
+		}
+		image, err := bck.downloadImage(emojiImageURL(e))
+		if err != nil {
+			// Emojis missing in the backup would be deleted
+			// on restore, so the whole part is skipped.
+			warn("emoji "+e.Name, err)
+			emojis = nil
+			break
+		}
+		emojis = append(emojis, &BackupEmoji{
+			ID:       e.ID,
+			Name:     e.Name,
+			Roles:    e.Roles,
+			Animated: e.Animated,
+			Image:    image,
+		})
+	}
+	backup.Emojis = emojis
+
+	if bans, err := bck.session.GuildBans(g.ID); err != nil {
+		warn("bans", err)
+	} else {
+		backup.Bans = make([]*BackupBan, len(bans))
+		for i, b := range bans {
+			backup.Bans[i] = &BackupBan{
+				UserID: b.User.ID,
+				Reason: b.Reason,
+			}
+		}
+	}
+
+	if webhooks, err := bck.session.GuildWebhooks(g.ID); err != nil {
+		warn("webhooks", err)
+	} else {
+		backup.Webhooks = make([]*BackupWebhook, 0, len(webhooks))
+		for _, w := range webhooks {
+			bw := &BackupWebhook{
+				ID:        w.ID,
+				ChannelID: w.ChannelID,
+				Name:      w.Name,
+			}
+			if w.Avatar != "" {
+				if bw.Avatar, err = bck.downloadImage(webhookAvatarURL(w)); err != nil {
+					warn("webhook avatar", err)
+				}
+			}
+			backup.Webhooks = append(backup.Webhooks, bw)
+		}
+	}
+
+	if settings, err := bck.getBotSettings(g.ID); err != nil {
+		warn("bot settings", err)
+	} else {
+		backup.BotSettings = settings
+	}
+}
+
+// fetchLiveExtras collects the live data of the guild
+// which is required to restore the passed parts of the
+// backup. Bans and webhooks are only fetched if they are
+// contained in the backup. If backup is nil, they are
+// not fetched. Errors are recorded per part so that the
+// other parts can still be restored.
+func (bck *GuildBackups) fetchLiveExtras(guildID string, backup *BackupObject, parts int) *backupLiveExtras {
+	live := &backupLiveExtras{
+		rateLimits: make(map[string]int),
+		bans:       make(map[string]bool),
+		errors:     make(map[int]error),
+	}
+
+	if parts&BackupPartGuild != 0 {
+		if rg, err := bck.restGuild(guildID); err != nil {
+			live.errors[BackupPartGuild] = err
+		} else {
+			live.iconHash = rg.Icon
+			live.bannerHash = rg.Banner
+		}
+	}
+
+	if parts&BackupPartChannels != 0 {
+		if rateLimits, err := bck.restRateLimits(guildID); err != nil {
+			live.errors[BackupPartChannels] = err
+		} else {
+			live.rateLimits = rateLimits
+		}
+	}
+
+	if parts&BackupPartBans != 0 && backup != nil && backup.Bans != nil {
+		if bans, err := bck.session.GuildBans(guildID); err != nil {
+			live.errors[BackupPartBans] = err
+		} else {
+			for _, b := range bans {
+				live.bans[b.User.ID] = true
+			}
+		}
+	}
+
+	if parts&BackupPartWebhooks != 0 && backup != nil && backup.Webhooks != nil {
+		if webhooks, err := bck.session.GuildWebhooks(guildID); err != nil {
+			live.errors[BackupPartWebhooks] = err
+		} else {
+			live.webhooks = webhooks
+		}
+	}
+
+	if parts&BackupPartSettings != 0 && backup != nil && backup.BotSettings != nil {
+		if settings, err := bck.getBotSettings(guildID); err != nil {
+			live.errors[BackupPartSettings] = err
+		} else {
+			live.settings = settings
+		}
+	}
+
+	return live
+}
+
+// matchEmojis maps the IDs of the backup emojis to the IDs
+// of the live emojis by their ID first and then by name.
+func matchEmojis(g *discordgo.Guild, emojis []*BackupEmoji) map[string]*discordgo.Emoji {
+	res := make(map[string]*discordgo.Emoji)
+	used := make(map[string]bool)
+
+	for _, e := range emojis {
+		for _, le := range g.Emojis {
+			if le.ID == e.ID {
+				res[e.ID] = le
+				used[le.ID] = true
+			}
+		}
+	}
+	for _, e := range emojis {
+		if _, ok := res[e.ID]; ok {
+			continue
+		}
+		for _, le := range g.Emojis {
+			if !used[le.ID] && !le.Managed && le.Name == e.Name {
+				res[e.ID] = le
+				used[le.ID] = true
+				break
+			}
+		}
+	}
+
+	return res
+}
+
+// matchWebhook returns the live webhook matching the backup
+// webhook by ID or by name and channel.
+func matchWebhook(webhooks []*discordgo.Webhook, w *BackupWebhook, channelID string) *discordgo.Webhook {
+	for _, lw := range webhooks {
+		if lw.ID == w.ID {
+			return lw
+		}
+	}
+	for _, lw := range webhooks {
+		if lw.Name == w.Name && lw.ChannelID == channelID {
+			return lw
+		}
+	}
+	return nil
+}
+
+func (m *backupIDMap) emojiRoles(roles []string) []string {
+	res := make([]string, 0, len(roles))
+	for _, r := range roles {
+		if id, ok := m.roles[r]; ok {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// botSettings returns a copy of the bot settings with
+// role and channel IDs mapped to the live objects.
+func (m *backupIDMap) botSettings(s *BackupBotSettings) *BackupBotSettings {
+	mapped := *s
+	mapped.AutoRoleID = m.roles[s.AutoRoleID]
+	mapped.ModLogChannelID = m.channels[s.ModLogChannelID]
+	mapped.VoiceLogChannelID = m.channels[s.VoiceLogChannelID]
+	mapped.MessageLogChannelID = m.channels[s.MessageLogChannelID]
+	mapped.MemberLogChannelID = m.channels[s.MemberLogChannelID]
+	mapped.JoinMsgChannelID = m.channels[s.JoinMsgChannelID]
+	mapped.LeaveMsgChannelID = m.channels[s.LeaveMsgChannelID]
+
+	mapped.Permissions = make(map[string]int)
+	for roleID, lvl := range s.Permissions {
+		if id, ok := m.roles[roleID]; ok {
+			mapped.Permissions[id] = clampRolePermLvl(lvl)
+		}
+	}
+
+	return &mapped
+}
+
+// clampRolePermLvl clamps the passed permission level to
+// the range which can be assigned to roles by the perms
+// command.
+func clampRolePermLvl(lvl int) int {
+	if lvl < 0 {
+		return 0
+	}
+	if lvl > 9 {
+		return 9
+	}
+	return lvl
+}
+
+// botSettingsChanges returns the changes between the live and
+// the restored bot settings. roleNames maps role IDs to the
+// names used to list changed permission levels.
+func botSettingsChanges(live, s *BackupBotSettings, roleNames map[string]string) []string {
+	changes := make([]string, 0)
+	check := func(name, a, b string) {
+		if a != b {
+			changes = append(changes, name)
+		}
+	}
+
+	check("prefix", live.Prefix, s.Prefix)
+	check("autorole", live.AutoRoleID, s.AutoRoleID)
+	check("modlog", live.ModLogChannelID, s.ModLogChannelID)
+	check("voicelog", live.VoiceLogChannelID, s.VoiceLogChannelID)
+	check("message log", live.MessageLogChannelID, s.MessageLogChannelID)
+	check("member log", live.MemberLogChannelID, s.MemberLogChannelID)
+	check("join message", live.JoinMsgChannelID+live.JoinMsg, s.JoinMsgChannelID+s.JoinMsg)
+	check("leave message", live.LeaveMsgChannelID+live.LeaveMsg, s.LeaveMsgChannelID+s.LeaveMsg)
+
+	roleIDs := make([]string, 0, len(s.Permissions))
+	for roleID := range s.Permissions {
+		roleIDs = append(roleIDs, roleID)
+	}
+	sort.Strings(roleIDs)
+	for _, roleID := range roleIDs {
+		if lvl, liveLvl := s.Permissions[roleID], live.Permissions[roleID]; liveLvl != lvl {
+			changes = append(changes, fmt.Sprintf("permission level of %s: %d → %d",
+				util.EnsureNotEmpty(roleNames[roleID], roleID), liveLvl, lvl))
+		}
+	}
+
+	return changes
+}
+
+func sameRoles(a, b []string) bool {
+	onlyA, onlyB := diffIDs(a, b)
+	return len(onlyA) == 0 && len(onlyB) == 0
+}
+
+func planRestoreExtras(g *discordgo.Guild, backup *BackupObject, opts *restoreOptions, ids *backupIDMap,
+	live *backupLiveExtras, add func(part int, action, name string, details []string)) {
+
+	parts := opts.parts
+
+	if parts&BackupPartEmojis != 0 && backup.Emojis != nil {
+		matched := matchEmojis(g, backup.Emojis)
+		used := make(map[string]bool)
+		for _, e := range backup.Emojis {
+			le, ok := matched[e.ID]
+			if !ok {
+				add(BackupPartEmojis, BackupActionCreate, e.Name, nil)
+				continue
+			}
+			used[le.ID] = true
+			changes := make([]string, 0)
+			if le.Name != e.Name {
+				changes = append(changes, fmt.Sprintf("name: %s → %s", le.Name, e.Name))
+			}
+			if !sameRoles(le.Roles, ids.emojiRoles(e.Roles)) {
+				changes = append(changes, "roles")
+			}
+			if len(changes) > 0 {
+				add(BackupPartEmojis, BackupActionEdit, e.Name, changes)
+			}
+		}
+		for _, le := range g.Emojis {
+			if opts.prune && !le.Managed && !used[le.ID] {
+				add(BackupPartEmojis, BackupActionDelete, le.Name, nil)
+			}
+		}
+	}
+
+	if parts&BackupPartBans != 0 && backup.Bans != nil {
+		for _, b := range backup.Bans {
+			if !live.bans[b.UserID] {
+				add(BackupPartBans, BackupActionCreate, b.UserID, []string{util.EnsureNotEmpty(b.Reason, "no reason")})
+			}
+		}
+	}
+
+	if parts&BackupPartWebhooks != 0 && backup.Webhooks != nil {
+		for _, w := range backup.Webhooks {
+			channelID := ids.channels[w.ChannelID]
+			lw := matchWebhook(live.webhooks, w, channelID)
+			if lw == nil {
+				add(BackupPartWebhooks, BackupActionCreate, w.Name, nil)
+				continue
+			}
+			changes := make([]string, 0)
+			if lw.Name != w.Name {
+				changes = append(changes, fmt.Sprintf("name: %s → %s", lw.Name, w.Name))
+			}
+			if lw.ChannelID != channelID {
+				changes = append(changes, "channel")
+			}
+			if len(changes) > 0 {
+				add(BackupPartWebhooks, BackupActionEdit, w.Name, changes)
+			}
+		}
+	}
+
+	if parts&BackupPartSettings != 0 && backup.BotSettings != nil {
+		roleNames := make(map[string]string)
+		for _, r := range backup.Roles {
+			roleNames[ids.roles[r.ID]] = r.Name
+		}
+		for _, r := range g.Roles {
+			roleNames[r.ID] = r.Name
+		}
+		changes := botSettingsChanges(live.settings, ids.botSettings(backup.BotSettings), roleNames)
+		if len(changes) > 0 {
+			add(BackupPartSettings, BackupActionEdit, "shinpuru settings", changes)
+		}
+	}
+}
+
+func (bck *GuildBackups) restoreEmojis(g *discordgo.Guild, backup *BackupObject, ids *backupIDMap, opts *restoreOptions,
+	statusC chan string, errorsC chan error) {
+
+	asyncWriteStatus(statusC, "updating and creating emojis")
+
+	liveEmojis := make([]*discordgo.Emoji, len(g.Emojis))
+	copy(liveEmojis, g.Emojis)
+
+	matched := matchEmojis(g, backup.Emojis)
+	used := make(map[string]bool)
+
+	for _, e := range backup.Emojis {
+		roles := ids.emojiRoles(e.Roles)
+		le, ok := matched[e.ID]
+		if !ok {
+			if _, err := bck.session.GuildEmojiCreate(g.ID, e.Name, e.Image, roles); err != nil {
+				asyncWriteError(errorsC, err)
+			}
+			continue
+		}
+
+		used[le.ID] = true
+		if le.Name != e.Name || !sameRoles(le.Roles, roles) {
+			if _, err := bck.session.GuildEmojiEdit(g.ID, le.ID, e.Name, roles); err != nil {
+				asyncWriteError(errorsC, err)
+			}
+		}
+	}
+
+	if !opts.prune {
+		return
+	}
+
+	asyncWriteStatus(statusC, "deleting emojis")
+	for _, le := range liveEmojis {
+		if le.Managed || used[le.ID] {
+			continue
+		}
+		if err := bck.session.GuildEmojiDelete(g.ID, le.ID); err != nil {
+			asyncWriteError(errorsC, err)
+		}
+	}
+}
+
+func (bck *GuildBackups) restoreBans(g *discordgo.Guild, backup *BackupObject, live *backupLiveExtras, statusC chan string, errorsC chan error) {
+	asyncWriteStatus(statusC, "restoring bans")
+
+	for _, b := range backup.Bans {
+		if live.bans[b.UserID] {
+			continue
+		}
+		if err := bck.session.GuildBanCreateWithReason(g.ID, b.UserID, b.Reason, 0); err != nil {
+			asyncWriteError(errorsC, err)
+		}
+	}
+}
+
+func (bck *GuildBackups) restoreWebhooks(backup *BackupObject, ids *backupIDMap, live *backupLiveExtras, statusC chan string, errorsC chan error) {
+	asyncWriteStatus(statusC, "updating and creating webhooks")
+
+	for _, w := range backup.Webhooks {
+		channelID := ids.channels[w.ChannelID]
+		if channelID == "" {
+			continue
+		}
+
+		lw := matchWebhook(live.webhooks, w, channelID)
+		if lw == nil {
+			if _, err := bck.session.WebhookCreate(channelID, w.Name, w.Avatar); err != nil {
+				asyncWriteError(errorsC, err)
+			}
+			continue
+		}
+
+		if lw.Name != w.Name || lw.ChannelID != channelID {
+			if _, err := bck.session.WebhookEdit(lw.ID, w.Name, "", channelID); err != nil {
+				asyncWriteError(errorsC, err)
+			}
+		}
+	}
+}
+
+func (bck *GuildBackups) restoreBotSettings(guildID string, backup *BackupObject, ids *backupIDMap, live *backupLiveExtras, statusC chan string, errorsC chan error) {
+	asyncWriteStatus(statusC, "restoring shinpuru settings")
+
+	s := ids.botSettings(backup.BotSettings)
+	l := live.settings
+	report := func(err error) {
+		if err != nil {
+			asyncWriteError(errorsC, err)
+		}
+	}
+
+	if l.Prefix != s.Prefix {
+		report(bck.db.SetGuildPrefix(guildID, s.Prefix))
+	}
+	if l.AutoRoleID != s.AutoRoleID {
+		report(bck.db.SetGuildAutoRole(guildID, s.AutoRoleID))
+	}
+	if l.ModLogChannelID != s.ModLogChannelID {
+		report(bck.db.SetGuildModLog(guildID, s.ModLogChannelID))
+	}
+	if l.VoiceLogChannelID != s.VoiceLogChannelID {
+		report(bck.db.SetGuildVoiceLog(guildID, s.VoiceLogChannelID))
+	}
+	if l.MessageLogChannelID != s.MessageLogChannelID {
+		report(bck.db.SetGuildMessageLog(guildID, s.MessageLogChannelID))
+	}
+	if l.MemberLogChannelID != s.MemberLogChannelID {
+		report(bck.db.SetGuildMemberLog(guildID, s.MemberLogChannelID))
+	}
+	if l.JoinMsgChannelID != s.JoinMsgChannelID || l.JoinMsg != s.JoinMsg {
+		report(bck.db.SetGuildJoinMsg(guildID, s.JoinMsgChannelID, s.JoinMsg))
+	}
+	if l.LeaveMsgChannelID != s.LeaveMsgChannelID || l.LeaveMsg != s.LeaveMsg {
+		report(bck.db.SetGuildLeaveMsg(guildID, s.LeaveMsgChannelID, s.LeaveMsg))
+	}
+
+	roleIDs := make([]string, 0, len(s.Permissions))
+	for roleID := range s.Permissions {
+		roleIDs = append(roleIDs, roleID)
+	}
+	sort.Strings(roleIDs)
+	for _, roleID := range roleIDs {
+		if lvl := s.Permissions[roleID]; l.Permissions[roleID] != lvl {
+			report(bck.db.SetGuildRolePermission(guildID, roleID, lvl))
+		}
+	}
+}
+
+func (bck *GuildBackups) restoreGuildImages(guildID string, bg *BackupGuild, live *backupLiveExtras, errorsC chan error) {
+	if bg.Banner != "" && bg.BannerHash != live.bannerHash {
+		if err := bck.setGuildBanner(guildID, bg.Banner); err != nil {
+			asyncWriteError(errorsC, err)
+		}
+	}
+}
+
+func imageChanges(bg *BackupGuild, live *backupLiveExtras) []string {
+	changes := make([]string, 0)
+	if bg.Icon != "" && bg.IconHash != live.iconHash {
+		changes = append(changes, "icon")
+	}
+	if bg.Banner != "" && bg.BannerHash != live.bannerHash {
+		changes = append(changes, "banner")
+	}
+	return changes
+}
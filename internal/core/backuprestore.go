@@ -0,0 +1,829 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+// Parts of a backup which can be restored selectively.
+const (
+	BackupPartGuild = 1 << iota
+	BackupPartRoles
+	BackupPartChannels
+	BackupPartMembers
+	BackupPartEmojis
+	BackupPartBans
+	BackupPartWebhooks
+	BackupPartSettings
+
+	BackupPartAll = BackupPartGuild | BackupPartRoles | BackupPartChannels | BackupPartMembers |
+		BackupPartEmojis | BackupPartBans | BackupPartWebhooks | BackupPartSettings
+)
+
+const (
+	BackupActionCreate = "create"
+	BackupActionEdit   = "edit"
+	BackupActionDelete = "delete"
+	BackupActionSkip   = "skip"
+)
+
+// BackupPartNames contains the names of the backup
+// parts in the order of their flag values.
+var BackupPartNames = []string{"guild", "roles", "channels", "members", "emojis", "bans", "webhooks", "settings"}
+
+// BackupPlanAction describes a change which would be
+// applied to the guild when restoring a backup.
+type BackupPlanAction struct {
+	Part    string
+	Action  string
+	Name    string
+	Details string
+}
+
+// ParseBackupParts parses a comma separated list of
+// backup part names to a backup part flag value.
+func ParseBackupParts(list string) (int, error) {
+	var parts int
+	for _, name := range strings.Split(strings.ToLower(list), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		i := util.IndexOfStrArray(name, BackupPartNames)
+		if i < 0 {
+			return 0, fmt.Errorf("unknown backup part '%s'", name)
+		}
+		parts |= 1 << uint(i)
+	}
+
+	if parts == 0 {
+		return 0, errors.New("no backup parts specified")
+	}
+
+	return parts, nil
+}
+
+// BackupPartsString returns the names of the
+// passed backup parts as comma separated list.
+func BackupPartsString(parts int) string {
+	names := make([]string, 0, len(BackupPartNames))
+	for i, name := range BackupPartNames {
+		if parts&(1<<uint(i)) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// restoreOptions controls how a backup
+// object is applied to a guild.
+type restoreOptions struct {
+	parts int
+	// prune deletes roles, channels and emojis
+	// which are not contained in the backup.
+	prune bool
+	// positions restores the positions of
+	// roles and channels.
+	positions bool
+	// keepChannelID is the ID of a channel which
+	// is never deleted, even when pruning.
+	keepChannelID string
+}
+
+// backupIDMap maps the IDs of roles and channels of
+// a backup to the IDs of the matching live objects.
+// Objects are matched by their ID first and then by
+// their name.
+type backupIDMap struct {
+	roles     map[string]string
+	channels  map[string]string
+	liveRoles map[string]bool
+}
+
+func newBackupIDMap(g *discordgo.Guild, backup *BackupObject) *backupIDMap {
+	m := &backupIDMap{
+		roles:     make(map[string]string),
+		channels:  make(map[string]string),
+		liveRoles: make(map[string]bool),
+	}
+
+	for _, r := range g.Roles {
+		m.liveRoles[r.ID] = true
+	}
+
+	// The @everyone role has the ID of the guild.
+	if backup.Guild != nil && backup.Guild.ID != "" {
+		m.roles[backup.Guild.ID] = g.ID
+	}
+
+	usedRoles := make(map[string]bool)
+	for _, r := range backup.Roles {
+		for _, lr := range g.Roles {
+			if lr.ID == r.ID {
+				m.roles[r.ID] = lr.ID
+				usedRoles[lr.ID] = true
+			}
+		}
+	}
+	for _, r := range backup.Roles {
+		if _, ok := m.roles[r.ID]; ok {
+			continue
+		}
+		for _, lr := range g.Roles {
+			if !usedRoles[lr.ID] && lr.ID != g.ID && !lr.Managed && lr.Name == r.Name {
+				m.roles[r.ID] = lr.ID
+				usedRoles[lr.ID] = true
+				break
+			}
+		}
+	}
+
+	usedChannels := make(map[string]bool)
+	for _, c := range backup.Channels {
+		for _, lc := range g.Channels {
+			if lc.ID == c.ID {
+				m.channels[c.ID] = lc.ID
+				usedChannels[lc.ID] = true
+			}
+		}
+	}
+	for _, c := range backup.Channels {
+		if _, ok := m.channels[c.ID]; ok {
+			continue
+		}
+		for _, lc := range g.Channels {
+			if !usedChannels[lc.ID] && int(lc.Type) == c.Type && lc.Name == c.Name {
+				m.channels[c.ID] = lc.ID
+				usedChannels[lc.ID] = true
+				break
+			}
+		}
+	}
+
+	return m
+}
+
+// overwrites returns the permission overwrites of a backup
+// channel with role IDs mapped to the live roles. Overwrites
+// of roles which do not exist are skipped. If the overwrites
+// of the channel were not recorded, nil is returned.
+func (m *backupIDMap) overwrites(guildID string, pos []*discordgo.PermissionOverwrite) []*discordgo.PermissionOverwrite {
+	if pos == nil {
+		return nil
+	}
+
+	res := make([]*discordgo.PermissionOverwrite, 0, len(pos))
+	for _, po := range pos {
+		id := po.ID
+		if po.Type == "role" && id != guildID {
+			if mapped, ok := m.roles[po.ID]; ok {
+				id = mapped
+			} else if !m.liveRoles[po.ID] {
+				continue
+			}
+		}
+		res = append(res, &discordgo.PermissionOverwrite{
+			ID:    id,
+			Type:  po.Type,
+			Allow: po.Allow,
+			Deny:  po.Deny,
+		})
+	}
+	return res
+}
+
+// memberRoles returns the mapped roles of the backup member.
+// Managed roles of the live member are kept because they
+// can not be removed from members.
+func (m *backupIDMap) memberRoles(bm *BackupMember, lm *discordgo.Member, liveRoles map[string]*discordgo.Role) []string {
+	roles := make([]string, 0, len(bm.Roles))
+	for _, r := range bm.Roles {
+		if id, ok := m.roles[r]; ok {
+			roles = append(roles, id)
+		}
+	}
+	for _, r := range lm.Roles {
+		if lr, ok := liveRoles[r]; ok && lr.Managed {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+func (m *backupIDMap) mappedRoles() map[string]bool {
+	return valueSet(m.roles)
+}
+
+func (m *backupIDMap) mappedChannels() map[string]bool {
+	return valueSet(m.channels)
+}
+
+func liveRoleMap(g *discordgo.Guild) map[string]*discordgo.Role {
+	res := make(map[string]*discordgo.Role)
+	for _, r := range g.Roles {
+		res[r.ID] = r
+	}
+	return res
+}
+
+func liveChannelMap(g *discordgo.Guild) map[string]*discordgo.Channel {
+	res := make(map[string]*discordgo.Channel)
+	for _, c := range g.Channels {
+		res[c.ID] = c
+	}
+	return res
+}
+
+func liveMemberMap(g *discordgo.Guild) map[string]*discordgo.Member {
+	res := make(map[string]*discordgo.Member)
+	for _, m := range g.Members {
+		if m.User != nil {
+			res[m.User.ID] = m
+		}
+	}
+	return res
+}
+
+func isDeletableRole(g *discordgo.Guild, r *discordgo.Role) bool {
+	return r.ID != g.ID && !r.Managed
+}
+
+func roleChanges(lr *discordgo.Role, r *BackupRole, positions bool) []string {
+	changes := make([]string, 0)
+	if lr.Name != r.Name {
+		changes = append(changes, fmt.Sprintf("name: %s → %s", lr.Name, r.Name))
+	}
+	if lr.Color != r.Color {
+		changes = append(changes, fmt.Sprintf("color: #%06x → #%06x", lr.Color, r.Color))
+	}
+	if lr.Hoist != r.Hoist {
+		changes = append(changes, fmt.Sprintf("hoist: %t → %t", lr.Hoist, r.Hoist))
+	}
+	if lr.Mentionable != r.Mentionable {
+		changes = append(changes, fmt.Sprintf("mentionable: %t → %t", lr.Mentionable, r.Mentionable))
+	}
+	if lr.Permissions != r.Permissions {
+		changes = append(changes, fmt.Sprintf("permissions: %d → %d", lr.Permissions, r.Permissions))
+	}
+	if positions && lr.Position != r.Position {
+		changes = append(changes, fmt.Sprintf("position: %d → %d", lr.Position, r.Position))
+	}
+	return changes
+}
+
+func channelChanges(lc *discordgo.Channel, c *BackupChannel, parentID string, overwrites []*discordgo.PermissionOverwrite,
+	rateLimit int, positions bool) []string {
+
+	changes := make([]string, 0)
+	if lc.Name != c.Name {
+		changes = append(changes, fmt.Sprintf("name: %s → %s", lc.Name, c.Name))
+	}
+	if lc.Topic != c.Topic {
+		changes = append(changes, "topic")
+	}
+	if lc.NSFW != c.NSFW {
+		changes = append(changes, fmt.Sprintf("nsfw: %t → %t", lc.NSFW, c.NSFW))
+	}
+	if lc.Bitrate != c.Bitrate {
+		changes = append(changes, fmt.Sprintf("bitrate: %d → %d", lc.Bitrate, c.Bitrate))
+	}
+	if lc.UserLimit != c.UserLimit {
+		changes = append(changes, fmt.Sprintf("user limit: %d → %d", lc.UserLimit, c.UserLimit))
+	}
+	if lc.ParentID != parentID {
+		changes = append(changes, "category")
+	}
+	if positions && lc.Position != c.Position {
+		changes = append(changes, fmt.Sprintf("position: %d → %d", lc.Position, c.Position))
+	}
+	if overwrites != nil && !sameOverwrites(lc.PermissionOverwrites, overwrites) {
+		changes = append(changes, "permission overwrites")
+	}
+	if rateLimit != c.RateLimitPerUser {
+		changes = append(changes, fmt.Sprintf("slowmode: %ds → %ds", rateLimit, c.RateLimitPerUser))
+	}
+	return changes
+}
+
+func guildChanges(g *discordgo.Guild, bg *BackupGuild, afkChannelID string) []string {
+	changes := make([]string, 0)
+	if g.Name != bg.Name {
+		changes = append(changes, fmt.Sprintf("name: %s → %s", g.Name, bg.Name))
+	}
+	if g.AfkChannelID != afkChannelID {
+		changes = append(changes, "afk channel")
+	}
+	if g.AfkTimeout != bg.AfkTimeout {
+		changes = append(changes, fmt.Sprintf("afk timeout: %d → %d", g.AfkTimeout, bg.AfkTimeout))
+	}
+	if int(g.VerificationLevel) != bg.VerificationLevel {
+		changes = append(changes, fmt.Sprintf("verification level: %d → %d", g.VerificationLevel, bg.VerificationLevel))
+	}
+	if g.DefaultMessageNotifications != bg.DefaultMessageNotifications {
+		changes = append(changes, fmt.Sprintf("default notifications: %d → %d",
+			g.DefaultMessageNotifications, bg.DefaultMessageNotifications))
+	}
+	return changes
+}
+
+func sameOverwrites(a, b []*discordgo.PermissionOverwrite) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[discordgo.PermissionOverwrite]bool)
+	for _, po := range a {
+		set[*po] = true
+	}
+	for _, po := range b {
+		if !set[*po] {
+			return false
+		}
+	}
+	return true
+}
+
+// diffIDs returns the IDs which are only in a
+// and the IDs which are only in b.
+func diffIDs(a, b []string) (onlyA, onlyB []string) {
+	setA := make(map[string]bool)
+	setB := make(map[string]bool)
+	for _, id := range a {
+		setA[id] = true
+	}
+	for _, id := range b {
+		setB[id] = true
+		if !setA[id] {
+			onlyB = append(onlyB, id)
+		}
+	}
+	for _, id := range a {
+		if !setB[id] {
+			onlyA = append(onlyA, id)
+		}
+	}
+	return
+}
+
+// PlanRestore returns all changes which would be applied
+// to the guild when restoring the passed parts of the
+// backup, without executing any of them. Deletions are
+// only planned if prune is true and never affect the
+// channel with the ID keepChannelID.
+func (bck *GuildBackups) PlanRestore(guildID, fileID string, parts int, prune bool, keepChannelID string) ([]*BackupPlanAction, error) {
+	if bck.session == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	backup, err := bck.readBackup(guildID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := bck.session.Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	live := bck.fetchLiveExtras(guildID, backup, parts)
+
+	return planRestore(g, backup, &restoreOptions{parts, prune, true, keepChannelID}, live), nil
+}
+
+// planRestore returns the changes which would be applied by
+// restoring the backup. Parts whose live data could not be
+// fetched are listed as skipped.
+func planRestore(g *discordgo.Guild, backup *BackupObject, opts *restoreOptions, live *backupLiveExtras) []*BackupPlanAction {
+	available := *opts
+	available.parts = live.availableParts(opts.parts)
+	opts = &available
+
+	ids := newBackupIDMap(g, backup)
+	liveRoles := liveRoleMap(g)
+	liveChannels := liveChannelMap(g)
+
+	actions := make([]*BackupPlanAction, 0)
+	add := func(part int, action, name string, details []string) {
+		actions = append(actions, &BackupPlanAction{
+			Part:    BackupPartsString(part),
+			Action:  action,
+			Name:    name,
+			Details: strings.Join(details, ", "),
+		})
+	}
+
+	for i := range BackupPartNames {
+		part := 1 << uint(i)
+		if err, ok := live.errors[part]; ok {
+			add(part, BackupActionSkip, "live data could not be fetched", []string{err.Error()})
+		}
+	}
+
+	// Objects which would be created are mapped to
+	// placeholder IDs so that references to them can
+	// be resolved in the following steps.
+	roleNames := make(map[string]string)
+	for _, r := range g.Roles {
+		roleNames[r.ID] = r.Name
+	}
+
+	if opts.parts&BackupPartRoles != 0 {
+		mapped := ids.mappedRoles()
+		for _, r := range backup.Roles {
+			lr, ok := liveRoles[ids.roles[r.ID]]
+			if !ok {
+				ids.roles[r.ID] = "new:" + r.ID
+				roleNames[ids.roles[r.ID]] = r.Name
+				add(BackupPartRoles, BackupActionCreate, r.Name, nil)
+				continue
+			}
+			if changes := roleChanges(lr, r, opts.positions); len(changes) > 0 {
+				add(BackupPartRoles, BackupActionEdit, r.Name, changes)
+			}
+		}
+		for _, lr := range g.Roles {
+			if opts.prune && isDeletableRole(g, lr) && !mapped[lr.ID] {
+				add(BackupPartRoles, BackupActionDelete, lr.Name, nil)
+			}
+		}
+	}
+
+	if opts.parts&BackupPartChannels != 0 {
+		mapped := ids.mappedChannels()
+		for _, c := range backup.Channels {
+			if _, ok := liveChannels[ids.channels[c.ID]]; !ok {
+				ids.channels[c.ID] = "new:" + c.ID
+			}
+		}
+		for _, c := range backup.Channels {
+			lc, ok := liveChannels[ids.channels[c.ID]]
+			if !ok {
+				add(BackupPartChannels, BackupActionCreate, c.Name, nil)
+				continue
+			}
+			changes := channelChanges(lc, c, ids.channels[c.ParentID], ids.overwrites(g.ID, c.PermissionOverwrites),
+				live.rateLimits[lc.ID], opts.positions)
+			if len(changes) > 0 {
+				add(BackupPartChannels, BackupActionEdit, c.Name, changes)
+			}
+		}
+		for _, lc := range g.Channels {
+			if opts.prune && !mapped[lc.ID] && lc.ID != opts.keepChannelID {
+				add(BackupPartChannels, BackupActionDelete, lc.Name, nil)
+			}
+		}
+	}
+
+	if opts.parts&BackupPartMembers != 0 {
+		liveMembers := liveMemberMap(g)
+		for _, m := range backup.Members {
+			lm, ok := liveMembers[m.ID]
+			if !ok {
+				continue
+			}
+
+			changes := make([]string, 0)
+			added, removed := diffIDs(ids.memberRoles(m, lm, liveRoles), lm.Roles)
+			for _, id := range added {
+				changes = append(changes, "+"+roleNames[id])
+			}
+			for _, id := range removed {
+				changes = append(changes, "-"+roleNames[id])
+			}
+			if lm.Nick != m.Nick {
+				changes = append(changes, fmt.Sprintf("nick: %s → %s",
+					util.EnsureNotEmpty(lm.Nick, "none"), util.EnsureNotEmpty(m.Nick, "none")))
+			}
+
+			if len(changes) > 0 {
+				add(BackupPartMembers, BackupActionEdit, lm.User.String(), changes)
+			}
+		}
+	}
+
+	planRestoreExtras(g, backup, opts, ids, live, add)
+
+	if opts.parts&BackupPartGuild != 0 {
+		changes := guildChanges(g, backup.Guild, ids.channels[backup.Guild.AfkChannelID])
+		changes = append(changes, imageChanges(backup.Guild, live)...)
+		if len(changes) > 0 {
+			add(BackupPartGuild, BackupActionEdit, g.Name, changes)
+		}
+	}
+
+	return actions
+}
+
+// RestoreBackup restores the passed parts of the backup
+// to the guild. Roles, channels and emojis which are not
+// part of the backup are only deleted if prune is true.
+// The channel with the ID keepChannelID is never deleted.
+func (bck *GuildBackups) RestoreBackup(guildID, fileID string, parts int, prune bool, keepChannelID string,
+	statusC chan string, errorsC chan error) error {
+
+	defer func() {
+		close(statusC)
+		close(errorsC)
+	}()
+
+	if bck.session == nil {
+		return errors.New("session is nil")
+	}
+
+	asyncWriteStatus(statusC, "reading backup file")
+	backup, err := bck.readBackup(guildID, fileID)
+	if err != nil {
+		return err
+	}
+
+	return bck.restore(guildID, backup, &restoreOptions{parts, prune, true, keepChannelID}, statusC, errorsC)
+}
+
+func (bck *GuildBackups) restore(guildID string, backup *BackupObject, opts *restoreOptions, statusC chan string, errorsC chan error) error {
+	g, err := bck.session.Guild(guildID)
+	if err != nil {
+		return err
+	}
+
+	// Parts whose live data could not be fetched, for example
+	// because of missing permissions, are skipped so that the
+	// other parts can still be restored.
+	live := bck.fetchLiveExtras(guildID, backup, opts.parts)
+	for _, err := range live.partErrors() {
+		asyncWriteError(errorsC, err)
+	}
+
+	available := *opts
+	available.parts = live.availableParts(opts.parts)
+	opts = &available
+	parts := opts.parts
+
+	ids := newBackupIDMap(g, backup)
+
+	if parts&BackupPartRoles != 0 {
+		bck.restoreRoles(g, backup, ids, opts, statusC, errorsC)
+	}
+
+	if parts&BackupPartChannels != 0 {
+		bck.restoreChannels(g, backup, ids, live, opts, statusC, errorsC)
+	}
+
+	if parts&BackupPartMembers != 0 {
+		bck.restoreMembers(g, backup, ids, statusC, errorsC)
+	}
+
+	if parts&BackupPartEmojis != 0 && backup.Emojis != nil {
+		bck.restoreEmojis(g, backup, ids, opts, statusC, errorsC)
+	}
+
+	if parts&BackupPartBans != 0 && backup.Bans != nil {
+		bck.restoreBans(g, backup, live, statusC, errorsC)
+	}
+
+	if parts&BackupPartWebhooks != 0 && backup.Webhooks != nil {
+		bck.restoreWebhooks(backup, ids, live, statusC, errorsC)
+	}
+
+	if parts&BackupPartSettings != 0 && backup.BotSettings != nil {
+		bck.restoreBotSettings(guildID, backup, ids, live, statusC, errorsC)
+	}
+
+	if parts&BackupPartGuild != 0 {
+		asyncWriteStatus(statusC, "editing guild")
+		verificationLevel := discordgo.VerificationLevel(backup.Guild.VerificationLevel)
+		params := discordgo.GuildParams{
+			Name:                        backup.Guild.Name,
+			AfkChannelID:                ids.channels[backup.Guild.AfkChannelID],
+			AfkTimeout:                  backup.Guild.AfkTimeout,
+			VerificationLevel:           &verificationLevel,
+			DefaultMessageNotifications: backup.Guild.DefaultMessageNotifications,
+		}
+		if backup.Guild.Icon != "" && backup.Guild.IconHash != live.iconHash {
+			params.Icon = backup.Guild.Icon
+		}
+		if _, err = bck.session.GuildEdit(guildID, params); err != nil {
+			return err
+		}
+		bck.restoreGuildImages(guildID, backup.Guild, live, errorsC)
+	}
+
+	return nil
+}
+
+func (bck *GuildBackups) restoreRoles(g *discordgo.Guild, backup *BackupObject, ids *backupIDMap, opts *restoreOptions,
+	statusC chan string, errorsC chan error) {
+
+	liveRoles := liveRoleMap(g)
+	deletable := make([]*discordgo.Role, 0)
+	for _, lr := range liveRoles {
+		deletable = append(deletable, lr)
+	}
+
+	asyncWriteStatus(statusC, "updating and creating roles")
+	positions := make([]*discordgo.Role, 0, len(backup.Roles))
+	for _, r := range backup.Roles {
+		lr, ok := liveRoles[ids.roles[r.ID]]
+		if !ok {
+			nr, err := bck.session.GuildRoleCreate(g.ID)
+			if err != nil {
+				asyncWriteError(errorsC, err)
+				continue
+			}
+			ids.roles[r.ID] = nr.ID
+		}
+
+		roleID := ids.roles[r.ID]
+		if !ok || len(roleChanges(lr, r, opts.positions)) > 0 {
+			_, err := bck.session.GuildRoleEdit(g.ID, roleID, r.Name, r.Color,
+				r.Hoist, r.Permissions, r.Mentionable)
+			if err != nil {
+				asyncWriteError(errorsC, err)
+				continue
+			}
+		}
+
+		positions = append(positions, &discordgo.Role{ID: roleID, Position: r.Position})
+	}
+
+	if opts.positions {
+		asyncWriteStatus(statusC, "re-positioning roles")
+		sort.Slice(positions, func(i, j int) bool {
+			return positions[i].Position < positions[j].Position
+		})
+		if _, err := bck.session.GuildRoleReorder(g.ID, positions); err != nil {
+			asyncWriteError(errorsC, err)
+		}
+	}
+
+	if !opts.prune {
+		return
+	}
+
+	asyncWriteStatus(statusC, "deleting roles")
+	mapped := ids.mappedRoles()
+	for _, lr := range deletable {
+		if !isDeletableRole(g, lr) || mapped[lr.ID] {
+			continue
+		}
+		if err := bck.session.GuildRoleDelete(g.ID, lr.ID); err != nil {
+			asyncWriteError(errorsC, err)
+		}
+	}
+}
+
+func (bck *GuildBackups) restoreChannels(g *discordgo.Guild, backup *BackupObject, ids *backupIDMap, live *backupLiveExtras,
+	opts *restoreOptions, statusC chan string, errorsC chan error) {
+
+	liveChannels := liveChannelMap(g)
+	created := make(map[string]*BackupChannel)
+
+	// Categories are restored first so that the
+	// other channels can be assigned to them.
+	for _, categories := range []bool{true, false} {
+		if categories {
+			asyncWriteStatus(statusC, "updating and creating categories")
+		} else {
+			asyncWriteStatus(statusC, "updating and creating channels")
+		}
+
+		for _, c := range backup.Channels {
+			if (c.Type == int(discordgo.ChannelTypeGuildCategory)) != categories {
+				continue
+			}
+
+			parentID := ids.channels[c.ParentID]
+			overwrites := ids.overwrites(g.ID, c.PermissionOverwrites)
+
+			lc, ok := liveChannels[ids.channels[c.ID]]
+			if !ok {
+				nc, err := bck.session.GuildChannelCreateComplex(g.ID, discordgo.GuildChannelCreateData{
+					Bitrate:              c.Bitrate,
+					NSFW:                 c.NSFW,
+					Name:                 c.Name,
+					ParentID:             parentID,
+					PermissionOverwrites: overwrites,
+					Topic:                c.Topic,
+					Type:                 discordgo.ChannelType(c.Type),
+					UserLimit:            c.UserLimit,
+				})
+				if err != nil {
+					asyncWriteError(errorsC, err)
+					continue
+				}
+				ids.channels[c.ID] = nc.ID
+				created[nc.ID] = c
+				continue
+			}
+
+			if live.rateLimits[lc.ID] != c.RateLimitPerUser {
+				if err := bck.setChannelRateLimit(lc.ID, c.RateLimitPerUser); err != nil {
+					asyncWriteError(errorsC, err)
+				}
+			}
+
+			if len(channelChanges(lc, c, parentID, overwrites, c.RateLimitPerUser, opts.positions)) == 0 {
+				continue
+			}
+
+			position := lc.Position
+			if opts.positions {
+				position = c.Position
+			}
+
+			_, err := bck.session.ChannelEditComplex(lc.ID, &discordgo.ChannelEdit{
+				Bitrate:              c.Bitrate,
+				NSFW:                 c.NSFW,
+				Name:                 c.Name,
+				ParentID:             parentID,
+				PermissionOverwrites: overwrites,
+				Position:             position,
+				Topic:                c.Topic,
+				UserLimit:            c.UserLimit,
+			})
+			if err != nil {
+				asyncWriteError(errorsC, err)
+				continue
+			}
+
+			// Empty overwrites are omitted from the edit
+			// request, so existing ones must be removed
+			// explicitly.
+			if overwrites != nil && len(overwrites) == 0 {
+				for _, po := range lc.PermissionOverwrites {
+					if err = bck.session.ChannelPermissionDelete(lc.ID, po.ID); err != nil {
+						asyncWriteError(errorsC, err)
+					}
+				}
+			}
+		}
+	}
+
+	if opts.positions {
+		asyncWriteStatus(statusC, "re-positioning channels")
+		for cID, c := range created {
+			_, err := bck.session.ChannelEditComplex(cID, &discordgo.ChannelEdit{
+				Position:         c.Position,
+				RateLimitPerUser: c.RateLimitPerUser,
+			})
+			if err != nil {
+				asyncWriteError(errorsC, err)
+			}
+		}
+	} else {
+		for cID, c := range created {
+			if c.RateLimitPerUser == 0 {
+				continue
+			}
+			if err := bck.setChannelRateLimit(cID, c.RateLimitPerUser); err != nil {
+				asyncWriteError(errorsC, err)
+			}
+		}
+	}
+
+	if !opts.prune {
+		return
+	}
+
+	asyncWriteStatus(statusC, "deleting channels")
+	mapped := ids.mappedChannels()
+	for _, lc := range liveChannels {
+		if mapped[lc.ID] || lc.ID == opts.keepChannelID {
+			continue
+		}
+		if _, err := bck.session.ChannelDelete(lc.ID); err != nil {
+			asyncWriteError(errorsC, err)
+		}
+	}
+}
+
+func (bck *GuildBackups) restoreMembers(g *discordgo.Guild, backup *BackupObject, ids *backupIDMap, statusC chan string, errorsC chan error) {
+	asyncWriteStatus(statusC, "updating members")
+
+	liveRoles := liveRoleMap(g)
+	liveMembers := liveMemberMap(g)
+
+	for _, m := range backup.Members {
+		lm, ok := liveMembers[m.ID]
+		if !ok {
+			continue
+		}
+
+		roles := ids.memberRoles(m, lm, liveRoles)
+		if added, removed := diffIDs(roles, lm.Roles); len(added) > 0 || len(removed) > 0 {
+			if err := bck.session.GuildMemberEdit(g.ID, m.ID, roles); err != nil {
+				asyncWriteError(errorsC, err)
+				continue
+			}
+		}
+
+		if lm.Nick != m.Nick {
+			if err := bck.session.GuildMemberNickname(g.ID, m.ID, m.Nick); err != nil {
+				asyncWriteError(errorsC, err)
+			}
+		}
+	}
+}
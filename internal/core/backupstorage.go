@@ -0,0 +1,213 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/zekroTJA/shinpuru/internal/util"
+	"github.com/zekroTJA/shinpuru/pkg/s3"
+)
+
+const (
+	BackupStorageLocal = "local"
+	BackupStorageS3    = "s3"
+)
+
+// BackupStorage describes a storage for backup
+// files which are identified by their name.
+type BackupStorage interface {
+	Put(name string, r io.Reader) error
+	Get(name string) (io.ReadCloser, error)
+	Delete(name string) error
+	List() ([]string, error)
+}
+
+// BackupStorageType returns the normalized name
+// of the passed backup storage type.
+func BackupStorageType(storageType string) string {
+	switch t := strings.ToLower(storageType); t {
+	case "":
+		return BackupStorageLocal
+	case "minio":
+		return BackupStorageS3
+	default:
+		return t
+	}
+}
+
+// NewBackupStorage creates the backup storage
+// of the passed type using the backup config.
+func NewBackupStorage(storageType string, cfg *ConfigBackups) (BackupStorage, error) {
+	switch BackupStorageType(storageType) {
+	case BackupStorageLocal:
+		location := cfg.Location
+		if location == "" {
+			location = defaultBackupLocation
+		}
+		return NewLocalBackupStorage(location), nil
+	case BackupStorageS3:
+		if cfg.S3 == nil {
+			return nil, fmt.Errorf("backup storage '%s' requires s3 config", storageType)
+		}
+		return NewS3BackupStorage(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unsupported backup storage '%s'", storageType)
+	}
+}
+
+// MigrateBackupStorage copies all files of the storage from
+// into the storage to. Files are not deleted from the source
+// storage. The number of migrated files is returned.
+func MigrateBackupStorage(from, to BackupStorage) (int, error) {
+	names, err := from.List()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, name := range names {
+		r, err := from.Get(name)
+		if err != nil {
+			return i, err
+		}
+		err = to.Put(name, r)
+		r.Close()
+		if err != nil {
+			return i, err
+		}
+		util.Log.Infof("migrated backup file %s (%d/%d)", name, i+1, len(names))
+	}
+
+	return len(names), nil
+}
+
+// LocalBackupStorage stores backup files
+// in a directory of the local file system.
+type LocalBackupStorage struct {
+	location string
+}
+
+func NewLocalBackupStorage(location string) *LocalBackupStorage {
+	return &LocalBackupStorage{location}
+}
+
+// Put writes the data into a temporary file which is
+// renamed to the target file afterwards, so that an
+// existing file is never truncated while it is read.
+func (l *LocalBackupStorage) Put(name string, r io.Reader) error {
+	if err := os.MkdirAll(l.location, os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := ioutil.TempFile(l.location, "."+name+".tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err = os.Rename(f.Name(), path.Join(l.location, name)); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return nil
+}
+
+func (l *LocalBackupStorage) Get(name string) (io.ReadCloser, error) {
+	return os.Open(path.Join(l.location, name))
+}
+
+func (l *LocalBackupStorage) Delete(name string) error {
+	err := os.Remove(path.Join(l.location, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *LocalBackupStorage) List() ([]string, error) {
+	files, err := ioutil.ReadDir(l.location)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		// Hidden files are temporary files of
+		// uncompleted Put operations.
+		if !f.IsDir() && !strings.HasPrefix(f.Name(), ".") {
+			names = append(names, f.Name())
+		}
+	}
+
+	return names, nil
+}
+
+// S3BackupStorage stores backup files in a bucket
+// of an S3 compatible object storage like MinIO.
+type S3BackupStorage struct {
+	client *s3.Client
+	prefix string
+}
+
+func NewS3BackupStorage(cfg *ConfigBackupsS3) (*S3BackupStorage, error) {
+	client, err := s3.New(cfg.Endpoint, cfg.Region, cfg.AccessKey, cfg.SecretKey, cfg.Bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = client.EnsureBucket(); err != nil {
+		return nil, err
+	}
+
+	return &S3BackupStorage{client, cfg.Prefix}, nil
+}
+
+func (s *S3BackupStorage) Put(name string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return s.client.PutObject(s.prefix+name, data)
+}
+
+func (s *S3BackupStorage) Get(name string) (io.ReadCloser, error) {
+	return s.client.GetObject(s.prefix + name)
+}
+
+func (s *S3BackupStorage) Delete(name string) error {
+	err := s.client.DeleteObject(s.prefix + name)
+	if s3.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *S3BackupStorage) List() ([]string, error) {
+	keys, err := s.client.ListObjects(s.prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, s.prefix)
+	}
+
+	return keys, nil
+}
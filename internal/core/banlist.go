@@ -0,0 +1,213 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+const (
+	BanListGlobal = "global"
+
+	BanListModeAuto    = "auto"
+	BanListModeConfirm = "confirm"
+
+	BanListAlertAccept  = "✅"
+	BanListAlertDecline = "❌"
+)
+
+type BanListEntry struct {
+	SourceID   string
+	UserID     string
+	ExecutorID string
+	Reason     string
+	Timestamp  time.Time
+}
+
+// PermissionFunc reports whether the user is
+// permitted to execute an action on the guild.
+type PermissionFunc func(guildID, userID string) bool
+
+// ModTargetFunc returns a description why the moderation
+// action must not be performed on the victim on the guild
+// or an empty string, if the action is allowed.
+type ModTargetFunc func(guildID, victimID string) string
+
+type BanListSubscription struct {
+	GuildID  string
+	SourceID string
+	Mode     string
+}
+
+// BanListAlert is a pending ban list entry which was sent
+// to the mod log channel of a guild subscribed in confirm
+// mode and is resolved by reacting to the alert message.
+type BanListAlert struct {
+	MessageID string
+	ChannelID string
+	GuildID   string
+	Entry     *BanListEntry
+}
+
+// PublishBan adds the victim of the passed BAN report to the
+// ban list of the report's guild and propagates the ban to all
+// subscribers, if the guild publishes its ban list.
+// checkTarget is passed to PropagateBan.
+func PublishBan(s *discordgo.Session, db Database, rep *util.Report, checkTarget ModTargetFunc) error {
+	published, err := db.GetGuildBanListPublished(rep.GuildID)
+	if err != nil && !IsErrDatabaseNotFound(err) {
+		return err
+	}
+	if !published {
+		return nil
+	}
+
+	entry := &BanListEntry{
+		SourceID:   rep.GuildID,
+		UserID:     rep.VictimID,
+		ExecutorID: rep.ExecutorID,
+		Reason:     rep.Msg,
+		Timestamp:  time.Now(),
+	}
+
+	if err = db.AddBanListEntry(entry); err != nil {
+		return err
+	}
+
+	return PropagateBan(s, db, entry, checkTarget)
+}
+
+// PropagateBan applies the ban list entry to all guilds
+// subscribed to the entry's source ban list. Depending on
+// the subscription mode, the ban is either executed directly
+// or an alert is sent into the guild's mod log channel which
+// needs to be confirmed by a moderator to apply the ban.
+// Automatic bans are checked against checkTarget first and
+// fall back to an alert if the check fails.
+func PropagateBan(s *discordgo.Session, db Database, entry *BanListEntry, checkTarget ModTargetFunc) error {
+	subs, err := db.GetBanListSubscribers(entry.SourceID)
+	if err != nil {
+		return err
+	}
+
+	sourceName := BanListName(s, entry.SourceID)
+
+	for _, sub := range subs {
+		if sub.GuildID == entry.SourceID {
+			continue
+		}
+
+		if sub.Mode == BanListModeAuto {
+			errTxt := ""
+			if checkTarget != nil {
+				errTxt = checkTarget(sub.GuildID, entry.UserID)
+			}
+			if errTxt == "" {
+				if err = applyBanListEntry(s, db, sub.GuildID, sourceName, s.State.User.ID, entry); err != nil {
+					util.Log.Errorf("failed applying ban list entry of %s to guild %s: %s", entry.SourceID, sub.GuildID, err.Error())
+				}
+				continue
+			}
+			util.Log.Infof("ban list entry of %s can not be applied automatically to guild %s: %s", entry.SourceID, sub.GuildID, errTxt)
+		}
+
+		modlogChan, err := db.GetGuildModLog(sub.GuildID)
+		if err != nil || modlogChan == "" {
+			continue
+		}
+
+		if err = sendBanListAlert(s, db, sub.GuildID, modlogChan, sourceName, entry); err != nil {
+			util.Log.Errorf("failed sending ban list alert to guild %s: %s", sub.GuildID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// BanListName returns a readable name of the ban
+// list with the passed source ID.
+func BanListName(s *discordgo.Session, sourceID string) string {
+	if sourceID == BanListGlobal {
+		return "global ban list"
+	}
+	if guild, err := s.State.Guild(sourceID); err == nil {
+		return guild.Name
+	}
+	return sourceID
+}
+
+// ResolveBanListAlert removes the pending alert and its
+// message and, if accepted, bans the user of the alert's
+// entry on the alert's guild in the name of executorID.
+func ResolveBanListAlert(s *discordgo.Session, db Database, alert *BanListAlert, accepted bool, executorID string) error {
+	if err := db.DeleteBanListAlert(alert.MessageID); err != nil {
+		return err
+	}
+
+	var err error
+	if accepted {
+		err = applyBanListEntry(s, db, alert.GuildID, BanListName(s, alert.Entry.SourceID), executorID, alert.Entry)
+	}
+
+	s.ChannelMessageDelete(alert.ChannelID, alert.MessageID)
+
+	return err
+}
+
+func sendBanListAlert(s *discordgo.Session, db Database, guildID, chanID, sourceName string, entry *BanListEntry) error {
+	msg, err := s.ChannelMessageSendEmbed(chanID, &discordgo.MessageEmbed{
+		Color: util.ColorEmbedOrange,
+		Title: "Ban List Alert",
+		Description: fmt.Sprintf("<@%s> (`%s`) was added to the ban list of **%s**.\n\n"+
+			"React with :white_check_mark: to ban the user on this guild too.",
+			entry.UserID, entry.UserID, sourceName),
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Reason",
+				Value: util.EnsureNotEmpty(entry.Reason, "*no reason specified*"),
+			},
+		},
+		Timestamp: entry.Timestamp.Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	alert := &BanListAlert{
+		MessageID: msg.ID,
+		ChannelID: msg.ChannelID,
+		GuildID:   guildID,
+		Entry:     entry,
+	}
+	if err = db.AddBanListAlert(alert); err != nil {
+		return err
+	}
+
+	for _, emoji := range []string{BanListAlertAccept, BanListAlertDecline} {
+		if err = s.MessageReactionAdd(msg.ChannelID, msg.ID, emoji); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyBanListEntry(s *discordgo.Session, db Database, guildID, sourceName, executorID string, entry *BanListEntry) error {
+	repType := util.IndexOfStrArray("BAN", util.ReportTypes)
+	rep := &util.Report{
+		ID:         util.NodesReport[repType].Generate(),
+		Type:       repType,
+		GuildID:    guildID,
+		ExecutorID: executorID,
+		VictimID:   entry.UserID,
+		Msg:        fmt.Sprintf("[BAN LIST: %s] %s", sourceName, entry.Reason),
+	}
+
+	if err := s.GuildBanCreateWithReason(guildID, entry.UserID, rep.Msg, 0); err != nil {
+		return err
+	}
+
+	return PushReport(s, db, rep)
+}
@@ -36,8 +36,26 @@ type ConfigLogging struct {
 	LogLevel       int
 }
 
+type ConfigBackupsS3 struct {
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	Prefix    string
+}
+
+type ConfigBackups struct {
+	Storage       string
+	Location      string
+	S3            *ConfigBackupsS3
+	Compression   string
+	EncryptionKey string
+}
+
 type ConfigEtc struct {
-	TwitchAppID string
+	TwitchAppID     string
+	TwitchAppSecret string
 }
 
 type Config struct {
@@ -46,6 +64,7 @@ type Config struct {
 	Database    *ConfigDatabaseType
 	Permissions *ConfigPermissions
 	Logging     *ConfigLogging
+	Backups     *ConfigBackups
 	Etc         *ConfigEtc
 }
 
@@ -80,6 +99,16 @@ func NewDefaultConfig() *Config {
 			CommandLogging: true,
 			LogLevel:       4,
 		},
+		Backups: &ConfigBackups{
+			Storage:     "local",
+			Location:    "./guildBackups",
+			Compression: "gzip",
+			S3: &ConfigBackupsS3{
+				Endpoint: "http://localhost:9000",
+				Region:   "us-east-1",
+				Bucket:   "shinpuru-backups",
+			},
+		},
 		Etc: new(ConfigEtc),
 	}
 }
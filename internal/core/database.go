@@ -2,6 +2,7 @@ package core
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/bwmarrin/snowflake"
@@ -46,6 +47,9 @@ type Database interface {
 	GetGuildBackup(guildID string) (bool, error)
 	SetGuildBackup(guildID string, enabled bool) error
 
+	GetGuildBackupSettings(guildID string) (*util.BackupSettings, error)
+	SetGuildBackupSettings(guildID string, settings *util.BackupSettings) error
+
 	GetGuildInviteBlock(guildID string) (string, error)
 	SetGuildInviteBlock(guildID string, data string) error
 
@@ -55,6 +59,51 @@ type Database interface {
 	GetGuildLeaveMsg(guildID string) (string, string, error)
 	SetGuildLeaveMsg(guildID string, msg string, channelID string) error
 
+	GetGuildAntiSpam(guildID string) (*util.AntiSpamSettings, error)
+	SetGuildAntiSpam(guildID string, settings *util.AntiSpamSettings) error
+
+	GetGuildAntiRaid(guildID string) (*util.AntiRaidSettings, error)
+	SetGuildAntiRaid(guildID string, settings *util.AntiRaidSettings) error
+
+	GetGuildRaidMode(guildID string) (bool, int, error)
+	SetGuildRaidMode(guildID string, enabled bool, prevVerificationLevel int) error
+
+	GetGuildLockdown(guildID string) (map[string]*discordgo.PermissionOverwrite, error)
+	SetGuildLockdown(guildID string, prevOverwrites map[string]*discordgo.PermissionOverwrite) error
+
+	GetGuildMessageLog(guildID string) (string, error)
+	SetGuildMessageLog(guildID, chanID string) error
+
+	GetGuildMessageLogIgnores(guildID string) ([]string, []string, error)
+	SetGuildMessageLogIgnores(guildID string, channelIDs, userIDs []string) error
+
+	GetGuildMemberLog(guildID string) (string, error)
+	SetGuildMemberLog(guildID, chanID string) error
+
+	GetGuildMemberLogEvents(guildID string) (int, error)
+	SetGuildMemberLogEvents(guildID string, events int) error
+
+	GetGuildBanListPublished(guildID string) (bool, error)
+	SetGuildBanListPublished(guildID string, published bool) error
+
+	GetGuildStickyRoles(guildID string) (*util.StickyRoleSettings, error)
+	SetGuildStickyRoles(guildID string, settings *util.StickyRoleSettings) error
+
+	GetGuildInviteBlockSettings(guildID string) (*util.InviteBlockSettings, error)
+	SetGuildInviteBlockSettings(guildID string, settings *util.InviteBlockSettings) error
+
+	GetGuildLinkFilter(guildID string) (*util.LinkFilterSettings, error)
+	SetGuildLinkFilter(guildID string, settings *util.LinkFilterSettings) error
+
+	GetGuildFlagQueue(guildID string) (string, error)
+	SetGuildFlagQueue(guildID, chanID string) error
+
+	GetGuildModmail(guildID string) (*util.ModmailSettings, error)
+	SetGuildModmail(guildID string, settings *util.ModmailSettings) error
+
+	GetGuildTickets(guildID string) (*util.TicketSettings, error)
+	SetGuildTickets(guildID string, settings *util.TicketSettings) error
+
 	AddReport(rep *util.Report) error
 	DeleteReport(id snowflake.ID) error
 	GetReport(id snowflake.ID) (*util.Report, error)
@@ -80,7 +129,7 @@ type Database interface {
 	SetTwitchNotify(twitchNotify *TwitchNotifyDBEntry) error
 	DeleteTwitchNotify(twitchUserID, guildID string) error
 
-	AddBackup(guildID, fileID string) error
+	AddBackup(guildID, fileID, checksum string) error
 	DeleteBackup(guildID, fileID string) error
 	GetBackups(guildID string) ([]*BackupEntry, error)
 	GetBackupGuilds() ([]string, error)
@@ -91,8 +140,50 @@ type Database interface {
 	GetTagByIdent(ident string, guildID string) (*util.Tag, error)
 	GetGuildTags(guildID string) ([]*util.Tag, error)
 	DeleteTag(id snowflake.ID) error
+
+	AddBanListEntry(entry *BanListEntry) error
+	GetBanListEntries(sourceID string) ([]*BanListEntry, error)
+
+	SetBanListSubscription(sub *BanListSubscription) error
+	DeleteBanListSubscription(guildID, sourceID string) error
+	GetBanListSubscriptions(guildID string) ([]*BanListSubscription, error)
+	GetBanListSubscribers(sourceID string) ([]*BanListSubscription, error)
+
+	AddBanListAlert(alert *BanListAlert) error
+	GetBanListAlert(messageID string) (*BanListAlert, error)
+	DeleteBanListAlert(messageID string) error
+
+	GetMemberStickyRoles(guildID, userID string) ([]string, error)
+	SetMemberStickyRoles(guildID, userID string, roleIDs []string) error
+
+	GetInviteBlockOffences(guildID, userID string) (int, error)
+	SetInviteBlockOffences(guildID, userID string, count int) error
+
+	AddFlag(flag *Flag) error
+	GetFlagByQueueMessage(queueMsgID string) (*Flag, error)
+	SetFlagResolved(flagID snowflake.ID, status, caseID string) error
+
+	AddModmailThread(thread *ModmailThread) error
+	GetModmailThreadByChannel(channelID string) (*ModmailThread, error)
+	GetModmailOpenThread(userID string) (*ModmailThread, error)
+	SetModmailThreadClosed(thread *ModmailThread) error
+
+	AddModmailMessage(msg *ModmailMessage) error
+	GetModmailMessages(threadID snowflake.ID) ([]*ModmailMessage, error)
+
+	AddTicket(ticket *Ticket) error
+	UpdateTicket(ticket *Ticket) error
+	GetTicketByChannel(channelID string) (*Ticket, error)
+	GetOpenTickets(guildID, ownerID string) ([]*Ticket, error)
 }
 
 func IsErrDatabaseNotFound(err error) bool {
 	return err == ErrDatabaseNotFound
 }
+
+func splitIDList(data string) []string {
+	if data == "" {
+		return []string{}
+	}
+	return strings.Split(data, ",")
+}
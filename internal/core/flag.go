@@ -0,0 +1,211 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/bwmarrin/snowflake"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+const (
+	FlagEmoji = "🚩"
+
+	FlagStatusDismissed = "dismissed"
+	FlagStatusWarned    = "warned"
+	FlagStatusMuted     = "muted"
+	FlagStatusBanned    = "banned"
+)
+
+// FlagActions maps the reaction emojis of a flag in the
+// mod queue channel to the status of the resolved flag.
+var FlagActions = map[string]string{
+	"❌": FlagStatusDismissed,
+	"⚠": FlagStatusWarned,
+	"🔇": FlagStatusMuted,
+	"🔨": FlagStatusBanned,
+}
+
+var flagActionsOrder = []string{"❌", "⚠\ufe0f", "🔇", "🔨"}
+
+var flagReportTypes = map[string]string{
+	FlagStatusWarned: "WARN",
+	FlagStatusMuted:  "MUTE",
+	FlagStatusBanned: "BAN",
+}
+
+var ErrFlagQueueNotSet = errors.New("flag queue channel is not set")
+
+type Flag struct {
+	ID         snowflake.ID
+	GuildID    string
+	ChannelID  string
+	MessageID  string
+	ReporterID string
+	VictimID   string
+	Reason     string
+	Content    string
+	QueueMsgID string
+	Status     string
+	CaseID     string
+	Timestamp  time.Time
+}
+
+func (f *Flag) AsEmbed() *discordgo.MessageEmbed {
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedOrange,
+		Title: "Flag " + f.ID.String(),
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Reporter",
+				Value:  fmt.Sprintf("<@%s>", f.ReporterID),
+			},
+			&discordgo.MessageEmbedField{
+				Inline: true,
+				Name:   "Flagged Member",
+				Value:  fmt.Sprintf("<@%s>", f.VictimID),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "Reason",
+				Value: util.EnsureNotEmpty(f.Reason, "*no reason specified*"),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "❌ dismiss | ⚠ warn | 🔇 mute | 🔨 ban",
+		},
+		Timestamp: f.Timestamp.Format(time.RFC3339),
+	}
+
+	if f.MessageID != "" {
+		content := f.Content
+		if r := []rune(content); len(r) > 900 {
+			content = string(r[:900]) + "..."
+		}
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name: "Message",
+			Value: fmt.Sprintf("%s\n\n[Jump to message](https://discordapp.com/channels/%s/%s/%s) in <#%s>",
+				util.EnsureNotEmpty(content, "*no text content*"), f.GuildID, f.ChannelID, f.MessageID, f.ChannelID),
+		})
+	}
+
+	return emb
+}
+
+// PostFlag sends the passed flag into the guild's flag
+// queue channel, adds the resolve reactions and saves
+// the flag to the database.
+func PostFlag(s *discordgo.Session, db Database, flag *Flag) error {
+	queueChan, err := db.GetGuildFlagQueue(flag.GuildID)
+	if err != nil && !IsErrDatabaseNotFound(err) {
+		return err
+	}
+	if queueChan == "" {
+		return ErrFlagQueueNotSet
+	}
+
+	flag.ID = util.NodeFlags.Generate()
+	flag.Timestamp = time.Now()
+
+	msg, err := s.ChannelMessageSendEmbed(queueChan, flag.AsEmbed())
+	if err != nil {
+		return err
+	}
+	flag.QueueMsgID = msg.ID
+
+	if err = db.AddFlag(flag); err != nil {
+		return err
+	}
+
+	for _, emoji := range flagActionsOrder {
+		if err = s.MessageReactionAdd(msg.ChannelID, msg.ID, emoji); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ResolveFlag executes the action corresponding to the passed
+// status on the flagged member, updates the flag in the database
+// and edits the flag message in the queue channel. The created
+// report is returned, if the flag was not dismissed. checkTarget
+// is passed to PublishBan for banned members.
+func ResolveFlag(s *discordgo.Session, db Database, flag *Flag, status, executorID string, checkTarget ModTargetFunc) (*util.Report, error) {
+	var rep *util.Report
+
+	if repTypeName, ok := flagReportTypes[status]; ok {
+		repType := util.IndexOfStrArray(repTypeName, util.ReportTypes)
+		rep = &util.Report{
+			ID:         util.NodesReport[repType].Generate(),
+			Type:       repType,
+			GuildID:    flag.GuildID,
+			ExecutorID: executorID,
+			VictimID:   flag.VictimID,
+			Msg:        fmt.Sprintf("[FLAG %s] %s", flag.ID, util.EnsureNotEmpty(flag.Reason, "no reason specified")),
+		}
+
+		switch status {
+		case FlagStatusMuted:
+			muteRoleID, err := db.GetMuteRoleGuild(flag.GuildID)
+			if err != nil && !IsErrDatabaseNotFound(err) {
+				return nil, err
+			}
+			if muteRoleID == "" {
+				return nil, errors.New("mute role is not set up")
+			}
+			if err = s.GuildMemberRoleAdd(flag.GuildID, flag.VictimID, muteRoleID); err != nil {
+				return nil, err
+			}
+		case FlagStatusBanned:
+			if err := s.GuildBanCreateWithReason(flag.GuildID, flag.VictimID, rep.Msg, 0); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := PushReport(s, db, rep); err != nil {
+			return nil, err
+		}
+
+		if status == FlagStatusBanned {
+			if err := PublishBan(s, db, rep, checkTarget); err != nil {
+				util.Log.Errorf("failed publishing ban of flag %s: %s", flag.ID, err.Error())
+			}
+		}
+	}
+
+	flag.Status = status
+	if rep != nil {
+		flag.CaseID = rep.ID.String()
+	}
+
+	if err := db.SetFlagResolved(flag.ID, flag.Status, flag.CaseID); err != nil {
+		return rep, err
+	}
+
+	queueChan, err := db.GetGuildFlagQueue(flag.GuildID)
+	if err != nil || queueChan == "" {
+		return rep, err
+	}
+
+	resolution := fmt.Sprintf("**%s** by <@%s>", flag.Status, executorID)
+	if flag.CaseID != "" {
+		resolution += fmt.Sprintf("\nCase: `%s`", flag.CaseID)
+	}
+
+	emb := flag.AsEmbed()
+	emb.Color = util.ColorEmbedGray
+	emb.Footer = nil
+	emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+		Name:  "Resolution",
+		Value: resolution,
+	})
+
+	if _, err = s.ChannelMessageEditEmbed(queueChan, flag.QueueMsgID, emb); err != nil {
+		return rep, err
+	}
+
+	return rep, s.MessageReactionsRemoveAll(queueChan, flag.QueueMsgID)
+}
@@ -1,9 +1,12 @@
 package core
 
 import (
-	"encoding/json"
+	"bytes"
 	"errors"
-	"os"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/zekroTJA/shinpuru/internal/util"
@@ -12,48 +15,68 @@ import (
 )
 
 const (
-	tickRate       = 12 * time.Hour
-	backupLocation = "./guildBackups"
+	tickRate              = 10 * time.Minute
+	defaultBackupLocation = "./guildBackups"
 )
 
 type GuildBackups struct {
 	ticker  *time.Ticker
 	session *discordgo.Session
 	db      Database
+	storage BackupStorage
+	codec   *BackupCodec
 }
 
 type BackupEntry struct {
 	GuildID   string
 	Timestamp time.Time
 	FileID    string
+	Checksum  string
 }
 
+// BackupObject contains all backed up data of a guild.
+// Emojis, bans, webhooks and bot settings are nil for
+// backups created by earlier versions or if they could
+// not be backed up. Nil parts are not restored.
 type BackupObject struct {
-	ID       string           `json:"id"`
-	Guild    *BackupGuild     `json:"guild"`
-	Channels []*BackupChannel `json:"channels"`
-	Roles    []*BackupRole    `json:"roles"`
-	Members  []*BackupMember  `json:"members"`
+	ID          string             `json:"id"`
+	Guild       *BackupGuild       `json:"guild"`
+	Channels    []*BackupChannel   `json:"channels"`
+	Roles       []*BackupRole      `json:"roles"`
+	Members     []*BackupMember    `json:"members"`
+	Emojis      []*BackupEmoji     `json:"emojis"`
+	Bans        []*BackupBan       `json:"bans"`
+	Webhooks    []*BackupWebhook   `json:"webhooks"`
+	BotSettings *BackupBotSettings `json:"bot_settings"`
 }
 
 type BackupGuild struct {
+	ID                          string `json:"id"`
 	Name                        string `json:"name"`
 	AfkChannelID                string `json:"afk_channel_id"`
 	AfkTimeout                  int    `json:"afk_timeout"`
 	VerificationLevel           int    `json:"verification_level"`
 	DefaultMessageNotifications int    `json:"default_message_notifications"`
+	IconHash                    string `json:"icon_hash"`
+	Icon                        string `json:"icon"`
+	BannerHash                  string `json:"banner_hash"`
+	Banner                      string `json:"banner"`
 }
 
 type BackupChannel struct {
-	ID                   string                           `json:"id"`
-	Name                 string                           `json:"name"`
-	Topic                string                           `json:"topic"`
-	Type                 int                              `json:"type"`
-	NSFW                 bool                             `json:"nsfw"`
-	Position             int                              `json:"position"`
-	Bitrate              int                              `json:"bitrate"`
-	UserLimit            int                              `json:"user_limit"`
-	ParentID             string                           `json:"parent_id"`
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	Topic            string `json:"topic"`
+	Type             int    `json:"type"`
+	NSFW             bool   `json:"nsfw"`
+	Position         int    `json:"position"`
+	Bitrate          int    `json:"bitrate"`
+	UserLimit        int    `json:"user_limit"`
+	ParentID         string `json:"parent_id"`
+	RateLimitPerUser int    `json:"rate_limit_per_user"`
+	// PermissionOverwrites is nil for backups created before
+	// overwrites were recorded. Overwrites of such channels
+	// are left unchanged on restore.
 	PermissionOverwrites []*discordgo.PermissionOverwrite `json:"permission_overwrites"`
 }
 
@@ -87,13 +110,20 @@ func asyncWriteError(c chan error, err error) {
 	}()
 }
 
-func NewGuildBackups(s *discordgo.Session, db Database) *GuildBackups {
+func NewGuildBackups(s *discordgo.Session, db Database, storage BackupStorage, config *ConfigBackups) (*GuildBackups, error) {
+	codec, err := NewBackupCodec(config)
+	if err != nil {
+		return nil, err
+	}
+
 	bck := new(GuildBackups)
 	bck.db = db
 	bck.session = s
+	bck.storage = storage
+	bck.codec = codec
 	bck.ticker = time.NewTicker(tickRate)
 	go bck.initTickerLoop()
-	return bck
+	return bck, nil
 }
 
 func (bck *GuildBackups) initTickerLoop() {
@@ -111,26 +141,56 @@ func (bck *GuildBackups) backupAllGuilds() {
 	}
 
 	for _, g := range guilds {
+		due, err := bck.isBackupDue(g)
+		if err != nil {
+			util.Log.Errorf("failed checking backup schedule of guild '%s': %s", g, err.Error())
+			continue
+		}
+		if !due {
+			continue
+		}
+
 		err = bck.BackupGuild(g)
 		if err != nil {
-			util.Log.Error("failed creating backup for guild '%s': %s", g, err.Error())
+			util.Log.Errorf("failed creating backup for guild '%s': %s", g, err.Error())
 		}
 		time.Sleep(1 * time.Second)
 	}
 }
 
-func (bck *GuildBackups) BackupGuild(guildID string) error {
-	if bck.session == nil {
-		return errors.New("session is nil")
+// isBackupDue returns true if the latest backup of the
+// guild is older than the guild's backup interval.
+func (bck *GuildBackups) isBackupDue(guildID string) (bool, error) {
+	settings, err := bck.db.GetGuildBackupSettings(guildID)
+	if err != nil {
+		return false, err
 	}
 
-	g, err := bck.session.Guild(guildID)
-	if err != nil {
-		return err
+	backups, err := bck.db.GetBackups(guildID)
+	if err != nil && !IsErrDatabaseNotFound(err) {
+		return false, err
+	}
+
+	for _, b := range backups {
+		if time.Since(b.Timestamp) < settings.GetInterval() {
+			return false, nil
+		}
 	}
 
+	return true, nil
+}
+
+func (bck *GuildBackups) backupFileName(fileID string) string {
+	return fileID + ".json"
+}
+
+// newBackupObject creates a backup object
+// from the current state of the guild.
+func newBackupObject(g *discordgo.Guild) *BackupObject {
 	backup := new(BackupObject)
 	backup.Guild = &BackupGuild{
+		ID:                          g.ID,
+		IconHash:                    g.Icon,
 		AfkChannelID:                g.AfkChannelID,
 		AfkTimeout:                  g.AfkTimeout,
 		DefaultMessageNotifications: g.DefaultMessageNotifications,
@@ -139,21 +199,26 @@ func (bck *GuildBackups) BackupGuild(guildID string) error {
 	}
 
 	for _, c := range g.Channels {
+		overwrites := c.PermissionOverwrites
+		if overwrites == nil {
+			overwrites = make([]*discordgo.PermissionOverwrite, 0)
+		}
 		backup.Channels = append(backup.Channels, &BackupChannel{
-			Bitrate:   c.Bitrate,
-			ID:        c.ID,
-			NSFW:      c.NSFW,
-			Name:      c.Name,
-			ParentID:  c.ParentID,
-			Position:  c.Position,
-			Topic:     c.Topic,
-			Type:      int(c.Type),
-			UserLimit: c.UserLimit,
+			Bitrate:              c.Bitrate,
+			ID:                   c.ID,
+			NSFW:                 c.NSFW,
+			Name:                 c.Name,
+			ParentID:             c.ParentID,
+			Position:             c.Position,
+			Topic:                c.Topic,
+			Type:                 int(c.Type),
+			UserLimit:            c.UserLimit,
+			PermissionOverwrites: overwrites,
 		})
 	}
 
 	for _, r := range g.Roles {
-		if r.ID == guildID {
+		if r.ID == g.ID {
 			continue
 		}
 		backup.Roles = append(backup.Roles, &BackupRole{
@@ -177,271 +242,267 @@ func (bck *GuildBackups) BackupGuild(guildID string) error {
 		})
 	}
 
-	if _, err := os.Stat(backupLocation); os.IsNotExist(err) {
-		err = os.MkdirAll(backupLocation, os.ModePerm)
-		if err != nil {
-			return err
-		}
-	} else if err != nil {
-		return err
-	}
-
-	backupID := util.NodeBackup.Generate()
-	backupFileName := backupLocation + "/" + backupID.String() + ".json"
+	return backup
+}
 
-	f, err := os.Create(backupFileName)
-	if err != nil {
-		return err
+func (bck *GuildBackups) BackupGuild(guildID string) error {
+	if bck.session == nil {
+		return errors.New("session is nil")
 	}
-	defer f.Close()
 
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	err = enc.Encode(backup)
+	g, err := bck.session.Guild(guildID)
 	if err != nil {
-		f.Close()
-		os.Remove(backupFileName)
 		return err
 	}
 
-	err = bck.db.AddBackup(g.ID, backupID.String())
-	if err != nil {
+	backup := newBackupObject(g)
+	bck.addBackupExtras(g, backup)
+
+	if _, err = bck.storeBackup(g.ID, backup); err != nil {
 		return err
 	}
 
-	cBackups, err := bck.db.GetBackups(g.ID)
+	return bck.applyRetention(g.ID)
+}
+
+// storeBackup encodes the backup object, saves it to the
+// backup storage as new backup of the guild and returns
+// the ID of the created backup.
+func (bck *GuildBackups) storeBackup(guildID string, backup *BackupObject) (string, error) {
+	backupID := util.NodeBackup.Generate().String()
+
+	data, err := bck.codec.Encode(backup)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	if len(cBackups) > 10 {
-		var lastEntry *BackupEntry
-		for _, b := range cBackups {
-			if lastEntry == nil || b.Timestamp.Before(lastEntry.Timestamp) {
-				lastEntry = b
-			}
-		}
-
-		err = os.Remove(backupLocation + "/" + lastEntry.FileID + ".json")
-		if err != nil {
-			return err
-		}
+	if err = bck.storage.Put(bck.backupFileName(backupID), bytes.NewReader(data)); err != nil {
+		return "", err
+	}
 
-		err = bck.db.DeleteBackup(g.ID, lastEntry.FileID)
-		if err != nil {
-			return err
-		}
+	if err = bck.db.AddBackup(guildID, backupID, BackupChecksum(data)); err != nil {
+		return "", err
 	}
 
-	return err
+	return backupID, nil
 }
 
-func (bck *GuildBackups) RestoreBackup(guildID, fileID string, statusC chan string, errorsC chan error) error {
-	defer func() {
-		close(statusC)
-		close(errorsC)
-	}()
+// ImportBackup validates the passed backup file data and
+// stores it as new backup of the guild. The backup is
+// re-encoded using the configured compression and
+// encryption. The ID of the created backup is returned.
+func (bck *GuildBackups) ImportBackup(guildID string, data []byte) (string, error) {
+	backup, err := bck.codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
 
-	if bck.session == nil {
-		return errors.New("session is nil")
+	if err = validateBackup(backup); err != nil {
+		return "", err
 	}
 
-	asyncWriteStatus(statusC, "reading backup file")
-	f, err := os.Open(backupLocation + "/" + fileID + ".json")
+	backupID, err := bck.storeBackup(guildID, backup)
 	if err != nil {
-		return err
+		return "", err
 	}
-	defer f.Close()
 
-	var backup BackupObject
+	return backupID, bck.applyRetention(guildID)
+}
 
-	dec := json.NewDecoder(f)
-	err = dec.Decode(&backup)
+// CloneBackup copies the backup of the guild to the guild
+// targetGuildID so that it can be restored there. The ID
+// of the created backup is returned.
+func (bck *GuildBackups) CloneBackup(guildID, fileID, targetGuildID string) (string, error) {
+	backup, err := bck.readBackup(guildID, fileID)
 	if err != nil {
-		return err
-	}
-
-	if bck.session == nil {
-		return errors.New("session is nil")
+		return "", err
 	}
 
-	// EDIT GUILD
-	asyncWriteStatus(statusC, "editing guild")
-	_verificationLevel := discordgo.VerificationLevel(backup.Guild.VerificationLevel)
-	_, err = bck.session.GuildEdit(guildID, discordgo.GuildParams{
-		Name:                        backup.Guild.Name,
-		AfkChannelID:                backup.Guild.AfkChannelID,
-		AfkTimeout:                  backup.Guild.AfkTimeout,
-		VerificationLevel:           &_verificationLevel,
-		DefaultMessageNotifications: backup.Guild.DefaultMessageNotifications,
-	})
+	backupID, err := bck.storeBackup(targetGuildID, backup)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// backup ID - created ID
-	ids := make(map[string]string)
-	channelsPos := make(map[string]int)
-	orderedRoles := make([]*discordgo.Role, len(backup.Roles))
-
-	// CREATE ROLES
-	asyncWriteStatus(statusC, "updating and creating roles")
-	for _, r := range backup.Roles {
-		roles, err := bck.session.GuildRoles(guildID)
-		if err != nil {
-			return err
-		}
+	return backupID, bck.applyRetention(targetGuildID)
+}
 
-		var rObj *discordgo.Role
-		for _, crObj := range roles {
-			if crObj.ID == r.ID {
-				rObj = crObj
-			}
-		}
+// BackupFile returns the verified, encoded
+// backup file data of the guild's backup.
+func (bck *GuildBackups) BackupFile(guildID, fileID string) ([]byte, error) {
+	backups, err := bck.db.GetBackups(guildID)
+	if err != nil {
+		return nil, err
+	}
 
-		if rObj == nil {
-			rObj, err = bck.session.GuildRoleCreate(guildID)
-		}
-		_, err = bck.session.GuildRoleEdit(guildID, rObj.ID, r.Name, r.Color,
-			r.Hoist, r.Permissions, r.Mentionable)
-		if err != nil {
-			asyncWriteError(errorsC, err)
-			continue
+	var entry *BackupEntry
+	for _, b := range backups {
+		if b.FileID == fileID {
+			entry = b
+			break
 		}
+	}
+	if entry == nil {
+		return nil, ErrDatabaseNotFound
+	}
 
-		ids[r.ID] = rObj.ID
-		orderedRoles[r.Position-1] = rObj
+	f, err := bck.storage.Get(bck.backupFileName(fileID))
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
 
-	// RE-POSITION ROLES
-	asyncWriteStatus(statusC, "re-position roles")
-	_, err = bck.session.GuildRoleReorder(guildID, orderedRoles)
+	data, err := ioutil.ReadAll(f)
 	if err != nil {
-		asyncWriteError(errorsC, err)
+		return nil, err
 	}
 
-	// CREATE CATEGORIES
-	asyncWriteStatus(statusC, "updating and creating categories")
-	for _, c := range backup.Channels {
-		if c.Type != int(discordgo.ChannelTypeGuildCategory) {
-			continue
-		}
-		cObj, _ := bck.session.Channel(c.ID)
-		if cObj != nil && cObj.GuildID != guildID {
-			cObj = nil
-		}
+	if entry.Checksum != "" && BackupChecksum(data) != entry.Checksum {
+		return nil, ErrBackupChecksumMismatch
+	}
 
-		for _, po := range c.PermissionOverwrites {
-			po.ID = ids[po.ID]
-		}
+	return data, nil
+}
 
-		if cObj == nil {
-			cObj, err = bck.session.GuildChannelCreateComplex(guildID,
-				discordgo.GuildChannelCreateData{
-					Name:                 c.Name,
-					PermissionOverwrites: c.PermissionOverwrites,
-					Type:                 discordgo.ChannelTypeGuildCategory,
-				})
-		} else {
-			_, err = bck.session.ChannelEditComplex(cObj.ID,
-				&discordgo.ChannelEdit{
-					Name:                 c.Name,
-					PermissionOverwrites: c.PermissionOverwrites,
-				})
+// validateBackup checks if the backup object contains
+// all data required to restore it.
+func validateBackup(backup *BackupObject) error {
+	if backup.Guild == nil {
+		return fmt.Errorf("%s: missing guild", ErrBackupMalformed)
+	}
+	for _, r := range backup.Roles {
+		if r == nil || r.ID == "" {
+			return fmt.Errorf("%s: invalid role", ErrBackupMalformed)
 		}
-		if err != nil {
-			asyncWriteError(errorsC, err)
-			continue
+	}
+	for _, c := range backup.Channels {
+		if c == nil || c.ID == "" || c.Name == "" {
+			return fmt.Errorf("%s: invalid channel", ErrBackupMalformed)
 		}
-		ids[c.ID] = cObj.ID
-		channelsPos[cObj.ID] = c.Position
 	}
-
-	// CREATE CHANNELS AND ADD TO CATEGORIES
-	asyncWriteStatus(statusC, "updating and creating channels")
 	for _, c := range backup.Channels {
-		if c.Type == int(discordgo.ChannelTypeGuildCategory) {
-			continue
+		for _, po := range c.PermissionOverwrites {
+			if po == nil || po.ID == "" || (po.Type != "role" && po.Type != "member") {
+				return fmt.Errorf("%s: invalid permission overwrite of channel %s", ErrBackupMalformed, c.Name)
+			}
 		}
-
-		cObj, _ := bck.session.Channel(c.ID)
-		if cObj != nil && cObj.GuildID != guildID {
-			cObj = nil
+	}
+	for _, m := range backup.Members {
+		if m == nil || m.ID == "" {
+			return fmt.Errorf("%s: invalid member", ErrBackupMalformed)
 		}
-
-		for _, po := range c.PermissionOverwrites {
-			po.ID = ids[po.ID]
+	}
+	for _, e := range backup.Emojis {
+		if e == nil || e.ID == "" || e.Name == "" {
+			return fmt.Errorf("%s: invalid emoji", ErrBackupMalformed)
 		}
-
-		if cObj == nil {
-			cObj, err = bck.session.GuildChannelCreateComplex(guildID,
-				discordgo.GuildChannelCreateData{
-					Bitrate:              c.Bitrate,
-					NSFW:                 c.NSFW,
-					Name:                 c.Name,
-					ParentID:             ids[c.ParentID],
-					PermissionOverwrites: c.PermissionOverwrites,
-					Topic:                c.Topic,
-					Type:                 discordgo.ChannelType(c.Type),
-					UserLimit:            c.UserLimit,
-				})
-		} else {
-			_, err = bck.session.ChannelEditComplex(cObj.ID,
-				&discordgo.ChannelEdit{
-					Bitrate:              c.Bitrate,
-					NSFW:                 c.NSFW,
-					Name:                 c.Name,
-					ParentID:             ids[c.ParentID],
-					PermissionOverwrites: c.PermissionOverwrites,
-					Topic:                c.Topic,
-					UserLimit:            c.UserLimit,
-				})
+	}
+	for _, b := range backup.Bans {
+		if b == nil || b.UserID == "" {
+			return fmt.Errorf("%s: invalid ban", ErrBackupMalformed)
 		}
-		if err != nil {
-			asyncWriteError(errorsC, err)
-			continue
+	}
+	for _, w := range backup.Webhooks {
+		if w == nil || w.ID == "" || w.ChannelID == "" {
+			return fmt.Errorf("%s: invalid webhook", ErrBackupMalformed)
+		}
+	}
+	if s := backup.BotSettings; s != nil {
+		if strings.ContainsAny(s.Prefix, " \t\r\n") {
+			return fmt.Errorf("%s: invalid prefix", ErrBackupMalformed)
+		}
+		for roleID, lvl := range s.Permissions {
+			if roleID == "" || lvl < 0 || lvl > 9 {
+				return fmt.Errorf("%s: invalid permission level", ErrBackupMalformed)
+			}
 		}
+	}
+	return nil
+}
 
-		channelsPos[cObj.ID] = c.Position
+// applyRetention deletes all backups of the guild which
+// are not kept by the guild's retention policy.
+func (bck *GuildBackups) applyRetention(guildID string) error {
+	settings, err := bck.db.GetGuildBackupSettings(guildID)
+	if err != nil {
+		return err
 	}
 
-	// RE-POSITION CHANNELS
-	asyncWriteStatus(statusC, "re-positioning channels")
-	for cID, pos := range channelsPos {
-		_, err = bck.session.ChannelEditComplex(cID, &discordgo.ChannelEdit{
-			Position: pos,
-		})
-		if err != nil {
+	backups, err := bck.db.GetBackups(guildID)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range backupsToDelete(backups, settings, time.Now()) {
+		if err = bck.storage.Delete(bck.backupFileName(b.FileID)); err != nil {
+			return err
+		}
+
+		if err = bck.db.DeleteBackup(guildID, b.FileID); err != nil {
 			return err
 		}
 	}
 
-	// UPDATE MEMBERS
-	asyncWriteStatus(statusC, "updating members")
-	for _, m := range backup.Members {
-		mObj, _ := bck.session.GuildMember(guildID, m.ID)
+	return nil
+}
 
-		newRoles := make([]string, len(m.Roles))
-		for i, r := range m.Roles {
-			newRoles[i] = ids[r]
-		}
+// backupsToDelete returns all backups which are not kept by
+// the passed retention settings. Kept are the latest KeepLast
+// backups, the latest backup of each of the last KeepDaily
+// days and the latest backup of each of the last KeepWeekly
+// weeks.
+func backupsToDelete(backups []*BackupEntry, settings *util.BackupSettings, now time.Time) []*BackupEntry {
+	sorted := make([]*BackupEntry, len(backups))
+	copy(sorted, backups)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.After(sorted[j].Timestamp)
+	})
+
+	keepDailyUntil := now.AddDate(0, 0, -settings.KeepDaily)
+	keepWeeklyUntil := now.AddDate(0, 0, -7*settings.KeepWeekly)
+
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+
+	toDelete := make([]*BackupEntry, 0)
+
+	for i, b := range sorted {
+		keep := i < settings.KeepLast
 
-		if mObj != nil {
-			err = bck.session.GuildMemberEdit(guildID, m.ID, newRoles)
-			if err != nil {
-				asyncWriteError(errorsC, err)
-				continue
+		if b.Timestamp.After(keepDailyUntil) {
+			day := b.Timestamp.Format("2006-01-02")
+			if !days[day] {
+				days[day] = true
+				keep = true
 			}
+		}
 
-			err = bck.session.GuildMemberNickname(guildID, m.ID, m.Nick)
-			if err != nil {
-				asyncWriteError(errorsC, err)
-				continue
+		if b.Timestamp.After(keepWeeklyUntil) {
+			year, week := b.Timestamp.ISOWeek()
+			weekKey := fmt.Sprintf("%d-%d", year, week)
+			if !weeks[weekKey] {
+				weeks[weekKey] = true
+				keep = true
 			}
 		}
+
+		if !keep {
+			toDelete = append(toDelete, b)
+		}
 	}
 
-	return nil
+	return toDelete
+}
+
+// readBackup reads and decodes the backup file of the
+// passed guild. If a checksum is stored for the backup,
+// the file is verified against it before decoding.
+func (bck *GuildBackups) readBackup(guildID, fileID string) (*BackupObject, error) {
+	data, err := bck.BackupFile(guildID, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return bck.codec.Decode(data)
 }
 
 func (bck *GuildBackups) HardFlush(guildID string) error {
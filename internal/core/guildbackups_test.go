@@ -0,0 +1,171 @@
+package core
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+// backupsAt creates backup entries whose file IDs are the
+// passed labels and whose timestamps are the passed offsets
+// before now.
+func backupsAt(now time.Time, offsets map[string]time.Duration) []*BackupEntry {
+	backups := make([]*BackupEntry, 0, len(offsets))
+	for fileID, d := range offsets {
+		backups = append(backups, &BackupEntry{
+			GuildID:   "guild",
+			FileID:    fileID,
+			Timestamp: now.Add(-d),
+		})
+	}
+	return backups
+}
+
+func backupFileIDs(backups []*BackupEntry) string {
+	ids := make([]string, len(backups))
+	for i, b := range backups {
+		ids[i] = b.FileID
+	}
+	sort.Strings(ids)
+	return strings.Join(ids, ",")
+}
+
+func TestBackupsToDelete(t *testing.T) {
+	// Wednesday of ISO week 24
+	now := time.Date(2020, 6, 10, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	cases := []struct {
+		name     string
+		settings *util.BackupSettings
+		backups  map[string]time.Duration
+		exp      []string
+	}{
+		{
+			name:     "nothing kept",
+			settings: &util.BackupSettings{},
+			backups:  map[string]time.Duration{"a": time.Hour, "b": day},
+			exp:      []string{"a", "b"},
+		},
+		{
+			name:     "keep last",
+			settings: &util.BackupSettings{KeepLast: 3},
+			backups: map[string]time.Duration{
+				"a": 1 * time.Hour, "b": 2 * time.Hour, "c": 3 * time.Hour,
+				"d": 4 * time.Hour, "e": 5 * time.Hour,
+			},
+			exp: []string{"d", "e"},
+		},
+		{
+			name:     "keep last exceeding backups",
+			settings: &util.BackupSettings{KeepLast: 10},
+			backups:  map[string]time.Duration{"a": time.Hour, "b": 30 * day},
+			exp:      []string{},
+		},
+		{
+			name:     "keep daily",
+			settings: &util.BackupSettings{KeepDaily: 2},
+			backups: map[string]time.Duration{
+				// 2020-06-10
+				"a": 1 * time.Hour, "b": 2 * time.Hour,
+				// 2020-06-09
+				"c": 25 * time.Hour, "d": 26 * time.Hour,
+				// 2020-06-08, still within the last 48 hours
+				"e": 47 * time.Hour,
+				// exactly at and beyond the boundary
+				"f": 48 * time.Hour, "g": 72 * time.Hour,
+			},
+			exp: []string{"b", "d", "f", "g"},
+		},
+		{
+			name:     "keep weekly",
+			settings: &util.BackupSettings{KeepWeekly: 2},
+			backups: map[string]time.Duration{
+				// week 24
+				"a": 1 * day, "b": 2 * day,
+				// week 23
+				"c": 4 * day, "d": 5 * day,
+				// week 22, still within the last 14 days
+				"e": 13 * day,
+				// exactly at and beyond the boundary
+				"f": 14 * day, "g": 20 * day,
+			},
+			exp: []string{"b", "d", "f", "g"},
+		},
+		{
+			name:     "combined",
+			settings: &util.BackupSettings{KeepLast: 1, KeepDaily: 1, KeepWeekly: 1},
+			backups: map[string]time.Duration{
+				"a": 1 * time.Hour, "b": 2 * time.Hour,
+				// 2020-06-07, week 23
+				"c": 3 * day,
+				"d": 10 * day,
+			},
+			exp: []string{"b", "d"},
+		},
+	}
+
+	for _, tc := range cases {
+		res := backupsToDelete(backupsAt(now, tc.backups), tc.settings, now)
+		if ids, exp := backupFileIDs(res), strings.Join(tc.exp, ","); ids != exp {
+			t.Errorf("%s: deleted backups were [%s], expected [%s]", tc.name, ids, exp)
+		}
+	}
+}
+
+func TestDiffBackups(t *testing.T) {
+	a := &BackupObject{
+		Roles: []*BackupRole{
+			{ID: "r1", Name: "admin", Color: 0xff0000},
+			{ID: "r2", Name: "member"},
+			{ID: "r3", Name: "muted"},
+		},
+		Channels: []*BackupChannel{
+			{ID: "c1", Name: "general", Position: 0},
+			{ID: "c2", Name: "memes", Position: 1},
+		},
+		Members: []*BackupMember{
+			{ID: "m1", Roles: []string{"r1", "r2"}},
+			{ID: "m2", Roles: []string{"r2"}},
+		},
+	}
+	b := &BackupObject{
+		Roles: []*BackupRole{
+			{ID: "r1", Name: "admins", Color: 0xff0000},
+			// recreated role with a new ID
+			{ID: "r4", Name: "member"},
+			{ID: "r5", Name: "vip"},
+		},
+		Channels: []*BackupChannel{
+			{ID: "c1", Name: "general", Position: 1},
+			{ID: "c3", Name: "news", Position: 0},
+		},
+		Members: []*BackupMember{
+			{ID: "m1", Roles: []string{"r1", "r4"}},
+			{ID: "m2", Roles: []string{"r5"}},
+		},
+	}
+
+	res := DiffBackups(a, b)
+
+	exp := []string{
+		"roles changed admins: name: admin → admins",
+		"roles removed muted: ",
+		"roles added vip: ",
+		"channels changed general: position: 0 → 1",
+		"channels removed memes: ",
+		"channels added news: ",
+		"members changed m2: +vip, -member",
+	}
+	if len(res) != len(exp) {
+		t.Fatalf("diff has %d entries, expected %d: %+v", len(res), len(exp), res)
+	}
+	for i, e := range res {
+		if txt := e.Part + " " + e.Change + " " + e.Name + ": " + e.Details; txt != exp[i] {
+			t.Errorf("diff entry %d was '%s', expected '%s'", i, txt, exp[i])
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	layoutChannelText  = "text"
+	layoutChannelVoice = "voice"
+
+	layoutEveryone = "@everyone"
+
+	layoutParts = BackupPartRoles | BackupPartChannels
+)
+
+// GuildLayout describes roles, categories, channels and
+// their permission overwrites of a guild. Roles and
+// channels are identified by their names. Properties
+// which are not set keep the value of the existing
+// role or channel.
+type GuildLayout struct {
+	Roles      []*LayoutRole     `yaml:"roles"`
+	Categories []*LayoutCategory `yaml:"categories"`
+	Channels   []*LayoutChannel  `yaml:"channels"`
+}
+
+type LayoutRole struct {
+	Name        string  `yaml:"name"`
+	Color       *string `yaml:"color"`
+	Hoist       *bool   `yaml:"hoist"`
+	Mentionable *bool   `yaml:"mentionable"`
+	Permissions *int    `yaml:"permissions"`
+}
+
+type LayoutCategory struct {
+	Name       string             `yaml:"name"`
+	Overwrites []*LayoutOverwrite `yaml:"overwrites"`
+	Channels   []*LayoutChannel   `yaml:"channels"`
+}
+
+type LayoutChannel struct {
+	Name       string             `yaml:"name"`
+	Type       string             `yaml:"type"`
+	Topic      *string            `yaml:"topic"`
+	NSFW       *bool              `yaml:"nsfw"`
+	Slowmode   *int               `yaml:"slowmode"`
+	Bitrate    *int               `yaml:"bitrate"`
+	UserLimit  *int               `yaml:"user_limit"`
+	Overwrites []*LayoutOverwrite `yaml:"overwrites"`
+}
+
+// LayoutOverwrite is a permission overwrite for either
+// a role, specified by its name, or a member, specified
+// by its user ID.
+type LayoutOverwrite struct {
+	Role   string `yaml:"role"`
+	Member string `yaml:"member"`
+	Allow  int    `yaml:"allow"`
+	Deny   int    `yaml:"deny"`
+}
+
+// ParseGuildLayout decodes and validates a YAML
+// guild layout from the passed reader.
+func ParseGuildLayout(r io.Reader) (*GuildLayout, error) {
+	layout := new(GuildLayout)
+	if err := yaml.NewDecoder(r).Decode(layout); err != nil {
+		if err == io.EOF {
+			return nil, errors.New("layout is empty")
+		}
+		return nil, err
+	}
+
+	if err := layout.validate(); err != nil {
+		return nil, err
+	}
+
+	return layout, nil
+}
+
+func (l *GuildLayout) validate() error {
+	roles := make(map[string]bool)
+	for _, r := range l.Roles {
+		if r == nil || r.Name == "" {
+			return errors.New("roles must have a name")
+		}
+		if roles[r.Name] {
+			return fmt.Errorf("role %s is declared multiple times", r.Name)
+		}
+		roles[r.Name] = true
+		if r.Color != nil {
+			if _, err := parseLayoutColor(*r.Color); err != nil {
+				return fmt.Errorf("role %s: %s", r.Name, err.Error())
+			}
+		}
+	}
+
+	categories := make(map[string]bool)
+	for _, c := range l.Categories {
+		if c == nil || c.Name == "" {
+			return errors.New("categories must have a name")
+		}
+		if categories[c.Name] {
+			return fmt.Errorf("category %s is declared multiple times", c.Name)
+		}
+		categories[c.Name] = true
+		if err := validateLayoutOverwrites("category "+c.Name, c.Overwrites); err != nil {
+			return err
+		}
+		if err := validateLayoutChannels(c.Channels); err != nil {
+			return err
+		}
+	}
+
+	return validateLayoutChannels(l.Channels)
+}
+
+func validateLayoutChannels(channels []*LayoutChannel) error {
+	declared := make(map[string]bool)
+	for _, c := range channels {
+		if c == nil || c.Name == "" {
+			return errors.New("channels must have a name")
+		}
+		if c.Type == "" {
+			c.Type = layoutChannelText
+		}
+		c.Type = strings.ToLower(c.Type)
+		if c.Type != layoutChannelText && c.Type != layoutChannelVoice {
+			return fmt.Errorf("channel %s: invalid type %s", c.Name, c.Type)
+		}
+		key := c.Type + "/" + c.Name
+		if declared[key] {
+			return fmt.Errorf("channel %s is declared multiple times", c.Name)
+		}
+		declared[key] = true
+		if err := validateLayoutOverwrites("channel "+c.Name, c.Overwrites); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateLayoutOverwrites(owner string, overwrites []*LayoutOverwrite) error {
+	for _, o := range overwrites {
+		if o == nil || (o.Role == "") == (o.Member == "") {
+			return fmt.Errorf("%s: overwrites must specify either a role or a member", owner)
+		}
+	}
+	return nil
+}
+
+func parseLayoutColor(color string) (int, error) {
+	c, err := strconv.ParseInt(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil || c < 0 || c > 0xffffff {
+		return 0, fmt.Errorf("invalid color %s", color)
+	}
+	return int(c), nil
+}
+
+// PlanLayout returns all changes which would be applied
+// to the guild by applying the layout. Roles and channels
+// which are not declared in the layout are only deleted if
+// prune is true. The channel with the ID keepChannelID is
+// never deleted.
+func (bck *GuildBackups) PlanLayout(guildID string, layout *GuildLayout, prune bool, keepChannelID string) ([]*BackupPlanAction, error) {
+	if bck.session == nil {
+		return nil, errors.New("session is nil")
+	}
+
+	g, err := bck.session.Guild(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	live := bck.fetchLiveExtras(guildID, nil, layoutParts)
+	if errs := live.partErrors(); len(errs) > 0 {
+		return nil, errs[0]
+	}
+
+	backup, err := layout.backupObject(g, live)
+	if err != nil {
+		return nil, err
+	}
+
+	return planRestore(g, backup, &restoreOptions{layoutParts, prune, false, keepChannelID}, live), nil
+}
+
+// ApplyLayout reconciles the roles and channels of the
+// guild with the layout. Positions of existing roles and
+// channels are kept.
+func (bck *GuildBackups) ApplyLayout(guildID string, layout *GuildLayout, prune bool, keepChannelID string,
+	statusC chan string, errorsC chan error) error {
+
+	defer func() {
+		close(statusC)
+		close(errorsC)
+	}()
+
+	if bck.session == nil {
+		return errors.New("session is nil")
+	}
+
+	g, err := bck.session.Guild(guildID)
+	if err != nil {
+		return err
+	}
+
+	live := bck.fetchLiveExtras(guildID, nil, layoutParts)
+	if errs := live.partErrors(); len(errs) > 0 {
+		return errs[0]
+	}
+
+	backup, err := layout.backupObject(g, live)
+	if err != nil {
+		return err
+	}
+
+	return bck.restore(guildID, backup, &restoreOptions{layoutParts, prune, false, keepChannelID}, statusC, errorsC)
+}
+
+// backupObject converts the layout to a backup object
+// which can be applied to the guild by the backup restore
+// machinery. Declared objects which already exist get the
+// ID of the live object and inherit all properties which
+// are not set in the layout. Objects which do not exist
+// yet get placeholder IDs.
+func (l *GuildLayout) backupObject(g *discordgo.Guild, live *backupLiveExtras) (*BackupObject, error) {
+	backup := &BackupObject{
+		Guild:    &BackupGuild{ID: g.ID},
+		Roles:    make([]*BackupRole, 0, len(l.Roles)),
+		Channels: make([]*BackupChannel, 0),
+	}
+
+	// ROLES
+	roleIDs := make(map[string]string)
+	liveRoles := make(map[string]*discordgo.Role)
+	for _, lr := range g.Roles {
+		name := lr.Name
+		if lr.ID == g.ID {
+			name = layoutEveryone
+		}
+		if _, ok := liveRoles[name]; !ok {
+			liveRoles[name] = lr
+			roleIDs[name] = lr.ID
+		}
+	}
+
+	for _, r := range l.Roles {
+		br := &BackupRole{
+			ID:   "layout:role:" + r.Name,
+			Name: r.Name,
+		}
+		if lr, ok := liveRoles[r.Name]; ok {
+			br.ID = lr.ID
+			br.Color = lr.Color
+			br.Hoist = lr.Hoist
+			br.Mentionable = lr.Mentionable
+			br.Permissions = lr.Permissions
+			br.Position = lr.Position
+		}
+		if r.Color != nil {
+			br.Color, _ = parseLayoutColor(*r.Color)
+		}
+		if r.Hoist != nil {
+			br.Hoist = *r.Hoist
+		}
+		if r.Mentionable != nil {
+			br.Mentionable = *r.Mentionable
+		}
+		if r.Permissions != nil {
+			br.Permissions = *r.Permissions
+		}
+		roleIDs[r.Name] = br.ID
+		backup.Roles = append(backup.Roles, br)
+	}
+
+	// CHANNELS
+	used := make(map[string]bool)
+	matchChannel := func(name string, chType discordgo.ChannelType, parentID string) *discordgo.Channel {
+		var match *discordgo.Channel
+		for _, lc := range g.Channels {
+			if used[lc.ID] || lc.Type != chType || lc.Name != name {
+				continue
+			}
+			if lc.ParentID == parentID {
+				match = lc
+				break
+			}
+			if match == nil {
+				match = lc
+			}
+		}
+		if match != nil {
+			used[match.ID] = true
+		}
+		return match
+	}
+
+	addChannel := func(c *LayoutChannel, chType discordgo.ChannelType, parentID string,
+		overwrites []*LayoutOverwrite) error {
+
+		bc := &BackupChannel{
+			ID:       fmt.Sprintf("layout:channel:%s/%d/%s", parentID, chType, c.Name),
+			Name:     c.Name,
+			Type:     int(chType),
+			ParentID: parentID,
+		}
+		if lc := matchChannel(c.Name, chType, parentID); lc != nil {
+			bc.ID = lc.ID
+			bc.Topic = lc.Topic
+			bc.NSFW = lc.NSFW
+			bc.Position = lc.Position
+			bc.Bitrate = lc.Bitrate
+			bc.UserLimit = lc.UserLimit
+			bc.RateLimitPerUser = live.rateLimits[lc.ID]
+			bc.PermissionOverwrites = lc.PermissionOverwrites
+		}
+		if c.Topic != nil {
+			bc.Topic = *c.Topic
+		}
+		if c.NSFW != nil {
+			bc.NSFW = *c.NSFW
+		}
+		if c.Bitrate != nil {
+			bc.Bitrate = *c.Bitrate
+		}
+		if c.UserLimit != nil {
+			bc.UserLimit = *c.UserLimit
+		}
+		if c.Slowmode != nil {
+			bc.RateLimitPerUser = *c.Slowmode
+		}
+		if overwrites != nil {
+			pos, err := layoutOverwrites(overwrites, roleIDs)
+			if err != nil {
+				return fmt.Errorf("channel %s: %s", c.Name, err.Error())
+			}
+			bc.PermissionOverwrites = pos
+		}
+		backup.Channels = append(backup.Channels, bc)
+		return nil
+	}
+
+	for _, c := range l.Categories {
+		category := &LayoutChannel{Name: c.Name}
+		if err := addChannel(category, discordgo.ChannelTypeGuildCategory, "", c.Overwrites); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, c := range l.Categories {
+		parentID := backup.Channels[i].ID
+		for _, ch := range c.Channels {
+			if err := addChannel(ch, layoutChannelType(ch.Type), parentID, ch.Overwrites); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, ch := range l.Channels {
+		if err := addChannel(ch, layoutChannelType(ch.Type), "", ch.Overwrites); err != nil {
+			return nil, err
+		}
+	}
+
+	return backup, nil
+}
+
+func layoutOverwrites(overwrites []*LayoutOverwrite, roleIDs map[string]string) ([]*discordgo.PermissionOverwrite, error) {
+	res := make([]*discordgo.PermissionOverwrite, 0, len(overwrites))
+	for _, o := range overwrites {
+		po := &discordgo.PermissionOverwrite{
+			ID:    o.Member,
+			Type:  "member",
+			Allow: o.Allow,
+			Deny:  o.Deny,
+		}
+		if o.Role != "" {
+			id, ok := roleIDs[o.Role]
+			if !ok {
+				return nil, fmt.Errorf("unknown role %s", o.Role)
+			}
+			po.ID = id
+			po.Type = "role"
+		}
+		res = append(res, po)
+	}
+	return res, nil
+}
+
+func layoutChannelType(t string) discordgo.ChannelType {
+	if t == layoutChannelVoice {
+		return discordgo.ChannelTypeGuildVoice
+	}
+	return discordgo.ChannelTypeGuildText
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGuildLayout(t *testing.T) {
+	layout, err := ParseGuildLayout(strings.NewReader(`
+roles:
+  - name: admin
+    color: "#ff0000"
+    permissions: 8
+categories:
+  - name: info
+    overwrites:
+      - role: "@everyone"
+        deny: 2048
+    channels:
+      - name: rules
+      - name: Voice
+        type: VOICE
+channels:
+  - name: general
+    topic: some topic
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(layout.Roles) != 1 || *layout.Roles[0].Permissions != 8 {
+		t.Errorf("unexpected roles %+v", layout.Roles)
+	}
+	if len(layout.Categories) != 1 || len(layout.Categories[0].Channels) != 2 {
+		t.Fatalf("unexpected categories %+v", layout.Categories)
+	}
+	if typ := layout.Categories[0].Channels[0].Type; typ != layoutChannelText {
+		t.Errorf("default channel type was %s, expected %s", typ, layoutChannelText)
+	}
+	if typ := layout.Categories[0].Channels[1].Type; typ != layoutChannelVoice {
+		t.Errorf("channel type was %s, expected %s", typ, layoutChannelVoice)
+	}
+	if len(layout.Channels) != 1 || *layout.Channels[0].Topic != "some topic" {
+		t.Errorf("unexpected channels %+v", layout.Channels)
+	}
+}
+
+func TestParseGuildLayoutInvalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		layout string
+	}{
+		{"empty", ""},
+		{"role without name", "roles:\n  - color: '#fff'"},
+		{"duplicate role", "roles:\n  - name: a\n  - name: a"},
+		{"invalid color", "roles:\n  - name: a\n    color: red"},
+		{"color out of range", "roles:\n  - name: a\n    color: '#1000000'"},
+		{"duplicate category", "categories:\n  - name: a\n  - name: a"},
+		{"channel without name", "channels:\n  - type: text"},
+		{"invalid channel type", "channels:\n  - name: a\n    type: news"},
+		{"duplicate channel", "channels:\n  - name: a\n  - name: a\n    type: text"},
+		{"duplicate category channel", "categories:\n  - name: c\n    channels:\n      - name: a\n      - name: a"},
+		{"overwrite without target", "channels:\n  - name: a\n    overwrites:\n      - allow: 1"},
+		{"overwrite with role and member", "channels:\n  - name: a\n    overwrites:\n      - role: r\n        member: '1'"},
+	}
+
+	for _, tc := range cases {
+		if _, err := ParseGuildLayout(strings.NewReader(tc.layout)); err == nil {
+			t.Errorf("%s: layout was parsed without error", tc.name)
+		}
+	}
+
+	// Text and voice channels may share a name.
+	if _, err := ParseGuildLayout(strings.NewReader("channels:\n  - name: a\n  - name: a\n    type: voice")); err != nil {
+		t.Errorf("text and voice channel with the same name: %s", err.Error())
+	}
+}
@@ -0,0 +1,387 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/bwmarrin/snowflake"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+const (
+	modmailMaxAttachmentSize   = 8 * 1024 * 1024
+	modmailAttachmentTimeout   = 30 * time.Second
+	modmailChannelNameMaxRunes = 90
+)
+
+var modmailHTTPClient = &http.Client{Timeout: modmailAttachmentTimeout}
+
+type ModmailThread struct {
+	ID          snowflake.ID
+	GuildID     string
+	UserID      string
+	ChannelID   string
+	ClosedBy    string
+	CloseReason string
+	Transcript  string
+	Created     time.Time
+	Closed      time.Time
+}
+
+type ModmailMessage struct {
+	ThreadID    snowflake.ID
+	AuthorID    string
+	AuthorTag   string
+	FromStaff   bool
+	Anonymous   bool
+	Content     string
+	Attachments []string
+	Timestamp   time.Time
+}
+
+// ModmailOpenThread creates a new thread channel for the passed
+// user in the modmail category of the guild and saves the thread
+// to the database.
+func ModmailOpenThread(s *discordgo.Session, db Database, guildID string, user *discordgo.User) (*ModmailThread, error) {
+	settings, err := db.GetGuildModmail(guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := discordgo.GuildChannelCreateData{
+		Name:     modmailChannelName(user),
+		Type:     discordgo.ChannelTypeGuildText,
+		Topic:    fmt.Sprintf("Modmail thread of %s (%s)", user.String(), user.ID),
+		ParentID: settings.CategoryID,
+	}
+	if category, err := s.State.Channel(settings.CategoryID); err == nil {
+		data.PermissionOverwrites = category.PermissionOverwrites
+	}
+
+	ch, err := s.GuildChannelCreateComplex(guildID, data)
+	if err != nil {
+		return nil, err
+	}
+
+	thread := &ModmailThread{
+		ID:        util.NodeModmail.Generate(),
+		GuildID:   guildID,
+		UserID:    user.ID,
+		ChannelID: ch.ID,
+		Created:   time.Now(),
+	}
+
+	if err = db.AddModmailThread(thread); err != nil {
+		return nil, err
+	}
+
+	_, err = s.ChannelMessageSendEmbed(ch.ID, &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Modmail Thread " + thread.ID.String(),
+		Description: fmt.Sprintf("Thread opened by %s (`%s`).\n\n"+
+			"Use `modmail reply <message>` to answer, `modmail anon <message>` to answer anonymously "+
+			"and `modmail close <reason>` to close the thread.", user.Mention(), user.ID),
+		Timestamp: thread.Created.Format(time.RFC3339),
+	})
+
+	return thread, err
+}
+
+// ModmailRelayToThread posts the passed direct message of the
+// thread's user including attachments into the thread channel.
+func ModmailRelayToThread(s *discordgo.Session, db Database, thread *ModmailThread, msg *discordgo.Message) error {
+	files, attachmentURLs := modmailDownloadAttachments(msg.Attachments)
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedGray,
+		Author: &discordgo.MessageEmbedAuthor{
+			Name:    msg.Author.String(),
+			IconURL: msg.Author.AvatarURL(""),
+		},
+		Description: msg.Content,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	modmailAddAttachmentField(emb, attachmentURLs)
+
+	_, err := s.ChannelMessageSendComplex(thread.ChannelID, &discordgo.MessageSend{
+		Embed: emb,
+		Files: files,
+	})
+	if err != nil {
+		return err
+	}
+
+	return db.AddModmailMessage(&ModmailMessage{
+		ThreadID:    thread.ID,
+		AuthorID:    msg.Author.ID,
+		AuthorTag:   msg.Author.String(),
+		Content:     msg.Content,
+		Attachments: modmailAttachmentURLs(msg.Attachments),
+		Timestamp:   time.Now(),
+	})
+}
+
+// ModmailRelayToUser sends the passed staff message including
+// attachments to the user of the thread. If anonymous is true,
+// the name of the author is not shown to the user.
+func ModmailRelayToUser(s *discordgo.Session, db Database, thread *ModmailThread, author *discordgo.User,
+	content string, attachments []*discordgo.MessageAttachment, anonymous bool) error {
+
+	guildName := thread.GuildID
+	if guild, err := s.State.Guild(thread.GuildID); err == nil {
+		guildName = guild.Name
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Author: &discordgo.MessageEmbedAuthor{
+			Name: fmt.Sprintf("Staff of %s", guildName),
+		},
+		Description: content,
+		Timestamp:   time.Now().Format(time.RFC3339),
+	}
+	if !anonymous {
+		emb.Author.Name = fmt.Sprintf("%s (Staff of %s)", author.String(), guildName)
+		emb.Author.IconURL = author.AvatarURL("")
+	}
+
+	dmChan, err := s.UserChannelCreate(thread.UserID)
+	if err != nil {
+		return err
+	}
+
+	files, attachmentURLs := modmailDownloadAttachments(attachments)
+	modmailAddAttachmentField(emb, attachmentURLs)
+
+	if _, err = s.ChannelMessageSendComplex(dmChan.ID, &discordgo.MessageSend{
+		Embed: emb,
+		Files: files,
+	}); err != nil {
+		return err
+	}
+
+	// The message is also posted into the thread channel
+	// including the author so that staff can see who
+	// replied, even if the reply was anonymous.
+	threadEmb := *emb
+	threadEmb.Author = &discordgo.MessageEmbedAuthor{
+		Name:    author.String(),
+		IconURL: author.AvatarURL(""),
+	}
+	if anonymous {
+		threadEmb.Author.Name += " (anonymous)"
+	}
+	threadEmb.Color = util.ColorEmbedGreen
+
+	files, _ = modmailDownloadAttachments(attachments)
+	if _, err = s.ChannelMessageSendComplex(thread.ChannelID, &discordgo.MessageSend{
+		Embed: &threadEmb,
+		Files: files,
+	}); err != nil {
+		return err
+	}
+
+	return db.AddModmailMessage(&ModmailMessage{
+		ThreadID:    thread.ID,
+		AuthorID:    author.ID,
+		AuthorTag:   author.String(),
+		FromStaff:   true,
+		Anonymous:   anonymous,
+		Content:     content,
+		Attachments: modmailAttachmentURLs(attachments),
+		Timestamp:   time.Now(),
+	})
+}
+
+// ModmailCloseThread closes the passed thread, notifies the
+// user, archives the transcript in the database and the
+// guild's modmail log channel and deletes the thread channel.
+func ModmailCloseThread(s *discordgo.Session, db Database, thread *ModmailThread, executor *discordgo.User, reason string) error {
+	if err := modmailArchiveThread(s, db, thread, executor, reason); err != nil {
+		return err
+	}
+
+	_, err := s.ChannelDelete(thread.ChannelID)
+	return err
+}
+
+// ModmailCloseDeletedThread closes the passed thread whose
+// channel was deleted, notifies the user and archives the
+// transcript.
+func ModmailCloseDeletedThread(s *discordgo.Session, db Database, thread *ModmailThread) error {
+	return modmailArchiveThread(s, db, thread, s.State.User, "The thread channel was deleted.")
+}
+
+// IsErrUnknownChannel returns true if the passed error is
+// a Discord API error caused by a channel which does not
+// exist.
+func IsErrUnknownChannel(err error) bool {
+	restErr, ok := err.(*discordgo.RESTError)
+	return ok && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownChannel
+}
+
+func modmailArchiveThread(s *discordgo.Session, db Database, thread *ModmailThread, executor *discordgo.User, reason string) error {
+	msgs, err := db.GetModmailMessages(thread.ID)
+	if err != nil {
+		return err
+	}
+
+	thread.ClosedBy = executor.ID
+	thread.CloseReason = reason
+	thread.Closed = time.Now()
+	thread.Transcript = ModmailTranscript(thread, msgs)
+
+	if err = db.SetModmailThreadClosed(thread); err != nil {
+		return err
+	}
+
+	guildName := thread.GuildID
+	if guild, err := s.State.Guild(thread.GuildID); err == nil {
+		guildName = guild.Name
+	}
+
+	if dmChan, err := s.UserChannelCreate(thread.UserID); err == nil {
+		s.ChannelMessageSendEmbed(dmChan.ID, &discordgo.MessageEmbed{
+			Color: util.ColorEmbedOrange,
+			Title: "Modmail Thread Closed",
+			Description: fmt.Sprintf("Your modmail thread with the staff of **%s** was closed.\n\n**Reason:**\n%s",
+				guildName, util.EnsureNotEmpty(reason, "*no reason specified*")),
+		})
+	}
+
+	if settings, err := db.GetGuildModmail(thread.GuildID); err == nil && settings.LogChannelID != "" {
+		_, err = s.ChannelMessageSendComplex(settings.LogChannelID, &discordgo.MessageSend{
+			Embed: &discordgo.MessageEmbed{
+				Color: util.ColorEmbedGray,
+				Title: "Modmail Thread " + thread.ID.String(),
+				Fields: []*discordgo.MessageEmbedField{
+					&discordgo.MessageEmbedField{
+						Inline: true,
+						Name:   "User",
+						Value:  fmt.Sprintf("<@%s>", thread.UserID),
+					},
+					&discordgo.MessageEmbedField{
+						Inline: true,
+						Name:   "Closed By",
+						Value:  executor.Mention(),
+					},
+					&discordgo.MessageEmbedField{
+						Inline: true,
+						Name:   "Messages",
+						Value:  fmt.Sprintf("%d", len(msgs)),
+					},
+					&discordgo.MessageEmbedField{
+						Name:  "Reason",
+						Value: util.EnsureNotEmpty(reason, "*no reason specified*"),
+					},
+				},
+				Timestamp: thread.Closed.Format(time.RFC3339),
+			},
+			Files: []*discordgo.File{
+				&discordgo.File{
+					Name:        fmt.Sprintf("modmail-%s.txt", thread.ID),
+					ContentType: "text/plain",
+					Reader:      strings.NewReader(thread.Transcript),
+				},
+			},
+		})
+		if err != nil {
+			util.Log.Errorf("failed sending modmail transcript of thread %s: %s", thread.ID, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// ModmailTranscript creates a text transcript of the
+// passed thread and its messages.
+func ModmailTranscript(thread *ModmailThread, msgs []*ModmailMessage) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Modmail thread %s\nGuild:  %s\nUser:   %s\nOpened: %s\nClosed: %s by %s\nReason: %s\n\n",
+		thread.ID, thread.GuildID, thread.UserID, thread.Created.Format(time.RFC3339),
+		thread.Closed.Format(time.RFC3339), thread.ClosedBy, thread.CloseReason))
+
+	for _, msg := range msgs {
+		role := "user"
+		if msg.FromStaff {
+			role = "staff"
+			if msg.Anonymous {
+				role = "staff, anonymous"
+			}
+		}
+		sb.WriteString(fmt.Sprintf("[%s] %s (%s) [%s]: %s\n",
+			msg.Timestamp.Format("2006-01-02 15:04:05"), msg.AuthorTag, msg.AuthorID, role, msg.Content))
+		for _, url := range msg.Attachments {
+			sb.WriteString("    attachment: " + url + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+func modmailChannelName(user *discordgo.User) string {
+	name := fmt.Sprintf("modmail-%s-%s", strings.ToLower(user.Username), user.Discriminator)
+	if r := []rune(name); len(r) > modmailChannelNameMaxRunes {
+		name = string(r[:modmailChannelNameMaxRunes])
+	}
+	return name
+}
+
+func modmailAttachmentURLs(attachments []*discordgo.MessageAttachment) []string {
+	urls := make([]string, len(attachments))
+	for i, a := range attachments {
+		urls[i] = a.URL
+	}
+	return urls
+}
+
+// modmailDownloadAttachments downloads the passed attachments
+// so that they can be re-uploaded. URLs of attachments which
+// are too large or could not be downloaded are returned.
+func modmailDownloadAttachments(attachments []*discordgo.MessageAttachment) ([]*discordgo.File, []string) {
+	files := make([]*discordgo.File, 0)
+	failed := make([]string, 0)
+
+	for _, a := range attachments {
+		if a.Size > modmailMaxAttachmentSize {
+			failed = append(failed, a.URL)
+			continue
+		}
+
+		resp, err := modmailHTTPClient.Get(a.URL)
+		if err != nil {
+			failed = append(failed, a.URL)
+			continue
+		}
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK {
+			failed = append(failed, a.URL)
+			continue
+		}
+
+		files = append(files, &discordgo.File{
+			Name:        a.Filename,
+			ContentType: resp.Header.Get("Content-Type"),
+			Reader:      bytes.NewReader(data),
+		})
+	}
+
+	return files, failed
+}
+
+func modmailAddAttachmentField(emb *discordgo.MessageEmbed, failedURLs []string) {
+	if len(failedURLs) == 0 {
+		return
+	}
+	emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+		Name:  "Attachments",
+		Value: strings.Join(failedURLs, "\n"),
+	})
+}
@@ -2,8 +2,10 @@ package core
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,6 +38,22 @@ func (m *MySQL) setup() {
 		"`inviteBlock` text NOT NULL," +
 		"`joinMsg` text NOT NULL," +
 		"`leaveMsg` text NOT NULL," +
+		"`antiSpam` text NOT NULL," +
+		"`antiRaid` text NOT NULL," +
+		"`raidMode` text NOT NULL," +
+		"`lockdown` text NOT NULL," +
+		"`msglogchanID` text NOT NULL," +
+		"`msgLogIgnores` text NOT NULL," +
+		"`memberlogchanID` text NOT NULL," +
+		"`memberLogEvents` text NOT NULL," +
+		"`banlistPublished` text NOT NULL," +
+		"`stickyRoles` text NOT NULL," +
+		"`inviteBlockSettings` text NOT NULL," +
+		"`linkFilter` text NOT NULL," +
+		"`flagQueueChanID` text NOT NULL," +
+		"`modmail` text NOT NULL," +
+		"`tickets` text NOT NULL," +
+		"`backupSettings` text NOT NULL," +
 		"PRIMARY KEY (`iid`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	mErr.Append(err)
@@ -102,6 +120,7 @@ func (m *MySQL) setup() {
 		"`guildID` text NOT NULL," +
 		"`timestamp` bigint(20) NOT NULL," +
 		"`fileID` text NOT NULL," +
+		"`checksum` text NOT NULL," +
 		"PRIMARY KEY (`iid`)" +
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	mErr.Append(err)
@@ -119,6 +138,121 @@ func (m *MySQL) setup() {
 		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
 	mErr.Append(err)
 
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `banlistentries` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`sourceID` text NOT NULL," +
+		"`userID` text NOT NULL," +
+		"`executorID` text NOT NULL," +
+		"`reason` text NOT NULL," +
+		"`timestamp` bigint(20) NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `banlistsubs` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`guildID` text NOT NULL," +
+		"`sourceID` text NOT NULL," +
+		"`mode` text NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `banlistalerts` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`messageID` text NOT NULL," +
+		"`channelID` text NOT NULL," +
+		"`guildID` text NOT NULL," +
+		"`sourceID` text NOT NULL," +
+		"`userID` text NOT NULL," +
+		"`executorID` text NOT NULL," +
+		"`reason` text NOT NULL," +
+		"`timestamp` bigint(20) NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `stickyroles` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`guildID` text NOT NULL," +
+		"`userID` text NOT NULL," +
+		"`roleIDs` text NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `inviteblockoffences` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`guildID` text NOT NULL," +
+		"`userID` text NOT NULL," +
+		"`offences` int(11) NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `flags` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`id` text NOT NULL," +
+		"`guildID` text NOT NULL," +
+		"`channelID` text NOT NULL," +
+		"`messageID` text NOT NULL," +
+		"`reporterID` text NOT NULL," +
+		"`victimID` text NOT NULL," +
+		"`reason` text NOT NULL," +
+		"`content` mediumtext NOT NULL," +
+		"`queueMsgID` text NOT NULL," +
+		"`status` text NOT NULL," +
+		"`caseID` text NOT NULL," +
+		"`timestamp` bigint(20) NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `modmailthreads` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`id` text NOT NULL," +
+		"`guildID` text NOT NULL," +
+		"`userID` text NOT NULL," +
+		"`channelID` text NOT NULL," +
+		"`closedBy` text NOT NULL," +
+		"`closeReason` text NOT NULL," +
+		"`transcript` mediumtext NOT NULL," +
+		"`created` bigint(20) NOT NULL," +
+		"`closed` bigint(20) NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `modmailmessages` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`threadID` text NOT NULL," +
+		"`authorID` text NOT NULL," +
+		"`authorTag` text NOT NULL," +
+		"`fromStaff` int(11) NOT NULL," +
+		"`anonymous` int(11) NOT NULL," +
+		"`content` mediumtext NOT NULL," +
+		"`attachments` text NOT NULL," +
+		"`timestamp` bigint(20) NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
+	_, err = m.DB.Exec("CREATE TABLE IF NOT EXISTS `tickets` (" +
+		"`iid` int(11) NOT NULL AUTO_INCREMENT," +
+		"`id` text NOT NULL," +
+		"`guildID` text NOT NULL," +
+		"`channelID` text NOT NULL," +
+		"`ownerID` text NOT NULL," +
+		"`claimedBy` text NOT NULL," +
+		"`members` text NOT NULL," +
+		"`closedBy` text NOT NULL," +
+		"`closeReason` text NOT NULL," +
+		"`created` bigint(20) NOT NULL," +
+		"`closed` bigint(20) NOT NULL," +
+		"PRIMARY KEY (`iid`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	mErr.Append(err)
+
 	if mErr.Len() > 0 {
 		util.Log.Fatalf("Failed database setup: %s", mErr.Concat().Error())
 	}
@@ -524,9 +658,9 @@ func (m *MySQL) GetAllTwitchNotifies(twitchUserID string) ([]*TwitchNotifyDBEntr
 	return results, nil
 }
 
-func (m *MySQL) AddBackup(guildID, fileID string) error {
+func (m *MySQL) AddBackup(guildID, fileID, checksum string) error {
 	timestamp := time.Now().Unix()
-	_, err := m.DB.Exec("INSERT INTO backups (guildID, timestamp, fileID) VALUES (?, ?, ?)", guildID, timestamp, fileID)
+	_, err := m.DB.Exec("INSERT INTO backups (guildID, timestamp, fileID, checksum) VALUES (?, ?, ?, ?)", guildID, timestamp, fileID, checksum)
 	return err
 }
 
@@ -578,7 +712,7 @@ func (m *MySQL) SetGuildLeaveMsg(guildID string, channelID string, msg string) e
 }
 
 func (m *MySQL) GetBackups(guildID string) ([]*BackupEntry, error) {
-	rows, err := m.DB.Query("SELECT guildID, timestamp, fileID FROM backups WHERE guildID = ?", guildID)
+	rows, err := m.DB.Query("SELECT guildID, timestamp, fileID, checksum FROM backups WHERE guildID = ?", guildID)
 	if err == sql.ErrNoRows {
 		return nil, ErrDatabaseNotFound
 	}
@@ -590,7 +724,7 @@ func (m *MySQL) GetBackups(guildID string) ([]*BackupEntry, error) {
 	for rows.Next() {
 		be := new(BackupEntry)
 		var timeStampUnix int64
-		err = rows.Scan(&be.GuildID, &timeStampUnix, &be.FileID)
+		err = rows.Scan(&be.GuildID, &timeStampUnix, &be.FileID, &be.Checksum)
 		if err != nil {
 			return nil, err
 		}
@@ -720,3 +854,568 @@ func (m *MySQL) DeleteTag(id snowflake.ID) error {
 	}
 	return err
 }
+
+func (m *MySQL) GetGuildAntiSpam(guildID string) (*util.AntiSpamSettings, error) {
+	data, err := m.getGuildSetting(guildID, "antiSpam")
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return nil, nil
+	}
+	return util.AntiSpamSettingsUnmarshal(data)
+}
+
+func (m *MySQL) SetGuildAntiSpam(guildID string, settings *util.AntiSpamSettings) error {
+	if settings == nil {
+		return m.setGuildSetting(guildID, "antiSpam", "")
+	}
+	data, err := settings.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "antiSpam", data)
+}
+
+func (m *MySQL) GetGuildAntiRaid(guildID string) (*util.AntiRaidSettings, error) {
+	data, err := m.getGuildSetting(guildID, "antiRaid")
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return nil, nil
+	}
+	return util.AntiRaidSettingsUnmarshal(data)
+}
+
+func (m *MySQL) SetGuildAntiRaid(guildID string, settings *util.AntiRaidSettings) error {
+	if settings == nil {
+		return m.setGuildSetting(guildID, "antiRaid", "")
+	}
+	data, err := settings.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "antiRaid", data)
+}
+
+func (m *MySQL) GetGuildRaidMode(guildID string) (bool, int, error) {
+	data, err := m.getGuildSetting(guildID, "raidMode")
+	if err != nil || data == "" {
+		return false, 0, err
+	}
+	prevLevel, err := strconv.Atoi(data)
+	return true, prevLevel, err
+}
+
+func (m *MySQL) SetGuildRaidMode(guildID string, enabled bool, prevVerificationLevel int) error {
+	var val string
+	if enabled {
+		val = strconv.Itoa(prevVerificationLevel)
+	}
+	return m.setGuildSetting(guildID, "raidMode", val)
+}
+
+func (m *MySQL) GetGuildLockdown(guildID string) (map[string]*discordgo.PermissionOverwrite, error) {
+	data, err := m.getGuildSetting(guildID, "lockdown")
+	if err != nil || data == "" {
+		return nil, err
+	}
+	overwrites := make(map[string]*discordgo.PermissionOverwrite)
+	err = json.Unmarshal([]byte(data), &overwrites)
+	return overwrites, err
+}
+
+func (m *MySQL) SetGuildLockdown(guildID string, prevOverwrites map[string]*discordgo.PermissionOverwrite) error {
+	if prevOverwrites == nil {
+		return m.setGuildSetting(guildID, "lockdown", "")
+	}
+	data, err := json.Marshal(prevOverwrites)
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "lockdown", string(data))
+}
+
+func (m *MySQL) GetGuildMessageLog(guildID string) (string, error) {
+	return m.getGuildSetting(guildID, "msglogchanID")
+}
+
+func (m *MySQL) SetGuildMessageLog(guildID, chanID string) error {
+	return m.setGuildSetting(guildID, "msglogchanID", chanID)
+}
+
+func (m *MySQL) GetGuildMessageLogIgnores(guildID string) ([]string, []string, error) {
+	data, err := m.getGuildSetting(guildID, "msgLogIgnores")
+	if err != nil || data == "" {
+		return nil, nil, err
+	}
+
+	i := strings.Index(data, "|")
+	return splitIDList(data[:i]), splitIDList(data[i+1:]), nil
+}
+
+func (m *MySQL) SetGuildMessageLogIgnores(guildID string, channelIDs, userIDs []string) error {
+	return m.setGuildSetting(guildID, "msgLogIgnores",
+		fmt.Sprintf("%s|%s", strings.Join(channelIDs, ","), strings.Join(userIDs, ",")))
+}
+
+func (m *MySQL) GetGuildMemberLog(guildID string) (string, error) {
+	return m.getGuildSetting(guildID, "memberlogchanID")
+}
+
+func (m *MySQL) SetGuildMemberLog(guildID, chanID string) error {
+	return m.setGuildSetting(guildID, "memberlogchanID", chanID)
+}
+
+func (m *MySQL) GetGuildMemberLogEvents(guildID string) (int, error) {
+	data, err := m.getGuildSetting(guildID, "memberLogEvents")
+	if err != nil || data == "" {
+		return util.MemberLogEventsAll, err
+	}
+	return strconv.Atoi(data)
+}
+
+func (m *MySQL) SetGuildMemberLogEvents(guildID string, events int) error {
+	return m.setGuildSetting(guildID, "memberLogEvents", strconv.Itoa(events))
+}
+
+func (m *MySQL) GetGuildBanListPublished(guildID string) (bool, error) {
+	val, err := m.getGuildSetting(guildID, "banlistPublished")
+	return val != "", err
+}
+
+func (m *MySQL) SetGuildBanListPublished(guildID string, published bool) error {
+	var val string
+	if published {
+		val = "1"
+	}
+	return m.setGuildSetting(guildID, "banlistPublished", val)
+}
+
+func (m *MySQL) AddBanListEntry(entry *BanListEntry) error {
+	_, err := m.DB.Exec("INSERT INTO banlistentries (sourceID, userID, executorID, reason, timestamp) VALUES (?, ?, ?, ?, ?)",
+		entry.SourceID, entry.UserID, entry.ExecutorID, entry.Reason, entry.Timestamp.Unix())
+	return err
+}
+
+func (m *MySQL) GetBanListEntries(sourceID string) ([]*BanListEntry, error) {
+	rows, err := m.DB.Query("SELECT sourceID, userID, executorID, reason, timestamp FROM banlistentries WHERE sourceID = ? ORDER BY timestamp DESC",
+		sourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*BanListEntry, 0)
+	for rows.Next() {
+		e := new(BanListEntry)
+		var timestampUnix int64
+		if err = rows.Scan(&e.SourceID, &e.UserID, &e.ExecutorID, &e.Reason, &timestampUnix); err != nil {
+			return nil, err
+		}
+		e.Timestamp = time.Unix(timestampUnix, 0)
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func (m *MySQL) SetBanListSubscription(sub *BanListSubscription) error {
+	res, err := m.DB.Exec("UPDATE banlistsubs SET mode = ? WHERE guildID = ? AND sourceID = ?",
+		sub.Mode, sub.GuildID, sub.SourceID)
+	if err != nil {
+		return err
+	}
+	if ar, err := res.RowsAffected(); err != nil {
+		return err
+	} else if ar == 0 {
+		_, err = m.DB.Exec("INSERT INTO banlistsubs (guildID, sourceID, mode) VALUES (?, ?, ?)",
+			sub.GuildID, sub.SourceID, sub.Mode)
+		return err
+	}
+	return nil
+}
+
+func (m *MySQL) DeleteBanListSubscription(guildID, sourceID string) error {
+	_, err := m.DB.Exec("DELETE FROM banlistsubs WHERE guildID = ? AND sourceID = ?", guildID, sourceID)
+	return err
+}
+
+func (m *MySQL) GetBanListSubscriptions(guildID string) ([]*BanListSubscription, error) {
+	return m.getBanListSubscriptions("guildID", guildID)
+}
+
+func (m *MySQL) GetBanListSubscribers(sourceID string) ([]*BanListSubscription, error) {
+	return m.getBanListSubscriptions("sourceID", sourceID)
+}
+
+func (m *MySQL) getBanListSubscriptions(key, value string) ([]*BanListSubscription, error) {
+	rows, err := m.DB.Query("SELECT guildID, sourceID, mode FROM banlistsubs WHERE "+key+" = ?", value)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]*BanListSubscription, 0)
+	for rows.Next() {
+		sub := new(BanListSubscription)
+		if err = rows.Scan(&sub.GuildID, &sub.SourceID, &sub.Mode); err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, nil
+}
+
+func (m *MySQL) AddBanListAlert(alert *BanListAlert) error {
+	_, err := m.DB.Exec("INSERT INTO banlistalerts (messageID, channelID, guildID, sourceID, userID, executorID, reason, timestamp) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		alert.MessageID, alert.ChannelID, alert.GuildID, alert.Entry.SourceID, alert.Entry.UserID, alert.Entry.ExecutorID,
+		alert.Entry.Reason, alert.Entry.Timestamp.Unix())
+	return err
+}
+
+func (m *MySQL) GetBanListAlert(messageID string) (*BanListAlert, error) {
+	alert := &BanListAlert{Entry: new(BanListEntry)}
+	var timestampUnix int64
+	err := m.DB.QueryRow("SELECT messageID, channelID, guildID, sourceID, userID, executorID, reason, timestamp FROM banlistalerts WHERE messageID = ?",
+		messageID).Scan(&alert.MessageID, &alert.ChannelID, &alert.GuildID, &alert.Entry.SourceID, &alert.Entry.UserID,
+		&alert.Entry.ExecutorID, &alert.Entry.Reason, &timestampUnix)
+	if err == sql.ErrNoRows {
+		return nil, ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	alert.Entry.Timestamp = time.Unix(timestampUnix, 0)
+	return alert, nil
+}
+
+func (m *MySQL) DeleteBanListAlert(messageID string) error {
+	_, err := m.DB.Exec("DELETE FROM banlistalerts WHERE messageID = ?", messageID)
+	return err
+}
+
+func (m *MySQL) GetGuildStickyRoles(guildID string) (*util.StickyRoleSettings, error) {
+	data, err := m.getGuildSetting(guildID, "stickyRoles")
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return util.NewDefaultStickyRoleSettings(), nil
+	}
+	return util.StickyRoleSettingsUnmarshal(data)
+}
+
+func (m *MySQL) SetGuildStickyRoles(guildID string, settings *util.StickyRoleSettings) error {
+	data, err := settings.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "stickyRoles", data)
+}
+
+func (m *MySQL) GetMemberStickyRoles(guildID, userID string) ([]string, error) {
+	var roleIDs string
+	err := m.DB.QueryRow("SELECT roleIDs FROM stickyroles WHERE guildID = ? AND userID = ?",
+		guildID, userID).Scan(&roleIDs)
+	if err == sql.ErrNoRows {
+		return nil, ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return splitIDList(roleIDs), nil
+}
+
+func (m *MySQL) SetMemberStickyRoles(guildID, userID string, roleIDs []string) error {
+	_, err := m.DB.Exec("DELETE FROM stickyroles WHERE guildID = ? AND userID = ?", guildID, userID)
+	if err != nil || len(roleIDs) == 0 {
+		return err
+	}
+	_, err = m.DB.Exec("INSERT INTO stickyroles (guildID, userID, roleIDs) VALUES (?, ?, ?)",
+		guildID, userID, strings.Join(roleIDs, ","))
+	return err
+}
+
+func (m *MySQL) GetGuildInviteBlockSettings(guildID string) (*util.InviteBlockSettings, error) {
+	data, err := m.getGuildSetting(guildID, "inviteBlockSettings")
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return util.NewDefaultInviteBlockSettings(), nil
+	}
+	return util.InviteBlockSettingsUnmarshal(data)
+}
+
+func (m *MySQL) SetGuildInviteBlockSettings(guildID string, settings *util.InviteBlockSettings) error {
+	data, err := settings.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "inviteBlockSettings", data)
+}
+
+func (m *MySQL) GetInviteBlockOffences(guildID, userID string) (int, error) {
+	var count int
+	err := m.DB.QueryRow("SELECT offences FROM inviteblockoffences WHERE guildID = ? AND userID = ?",
+		guildID, userID).Scan(&count)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return count, err
+}
+
+func (m *MySQL) SetInviteBlockOffences(guildID, userID string, count int) error {
+	_, err := m.DB.Exec("DELETE FROM inviteblockoffences WHERE guildID = ? AND userID = ?", guildID, userID)
+	if err != nil || count <= 0 {
+		return err
+	}
+	_, err = m.DB.Exec("INSERT INTO inviteblockoffences (guildID, userID, offences) VALUES (?, ?, ?)",
+		guildID, userID, count)
+	return err
+}
+
+func (m *MySQL) GetGuildLinkFilter(guildID string) (*util.LinkFilterSettings, error) {
+	data, err := m.getGuildSetting(guildID, "linkFilter")
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return util.NewDefaultLinkFilterSettings(), nil
+	}
+	return util.LinkFilterSettingsUnmarshal(data)
+}
+
+func (m *MySQL) SetGuildLinkFilter(guildID string, settings *util.LinkFilterSettings) error {
+	data, err := settings.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "linkFilter", data)
+}
+
+func (m *MySQL) GetGuildFlagQueue(guildID string) (string, error) {
+	return m.getGuildSetting(guildID, "flagQueueChanID")
+}
+
+func (m *MySQL) SetGuildFlagQueue(guildID, chanID string) error {
+	return m.setGuildSetting(guildID, "flagQueueChanID", chanID)
+}
+
+func (m *MySQL) AddFlag(flag *Flag) error {
+	_, err := m.DB.Exec("INSERT INTO flags (id, guildID, channelID, messageID, reporterID, victimID, reason, content, queueMsgID, status, caseID, timestamp) VALUES "+
+		"(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", flag.ID, flag.GuildID, flag.ChannelID, flag.MessageID, flag.ReporterID, flag.VictimID,
+		flag.Reason, flag.Content, flag.QueueMsgID, flag.Status, flag.CaseID, flag.Timestamp.Unix())
+	return err
+}
+
+func (m *MySQL) GetFlagByQueueMessage(queueMsgID string) (*Flag, error) {
+	flag := new(Flag)
+	var timestampUnix int64
+	err := m.DB.QueryRow("SELECT id, guildID, channelID, messageID, reporterID, victimID, reason, content, queueMsgID, status, caseID, timestamp "+
+		"FROM flags WHERE queueMsgID = ?", queueMsgID).Scan(&flag.ID, &flag.GuildID, &flag.ChannelID, &flag.MessageID, &flag.ReporterID,
+		&flag.VictimID, &flag.Reason, &flag.Content, &flag.QueueMsgID, &flag.Status, &flag.CaseID, &timestampUnix)
+	if err == sql.ErrNoRows {
+		return nil, ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	flag.Timestamp = time.Unix(timestampUnix, 0)
+	return flag, nil
+}
+
+func (m *MySQL) SetFlagResolved(flagID snowflake.ID, status, caseID string) error {
+	_, err := m.DB.Exec("UPDATE flags SET status = ?, caseID = ? WHERE id = ?", status, caseID, flagID)
+	return err
+}
+
+func (m *MySQL) GetGuildModmail(guildID string) (*util.ModmailSettings, error) {
+	data, err := m.getGuildSetting(guildID, "modmail")
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return util.NewDefaultModmailSettings(), nil
+	}
+	return util.ModmailSettingsUnmarshal(data)
+}
+
+func (m *MySQL) SetGuildModmail(guildID string, settings *util.ModmailSettings) error {
+	data, err := settings.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "modmail", data)
+}
+
+func (m *MySQL) AddModmailThread(thread *ModmailThread) error {
+	_, err := m.DB.Exec("INSERT INTO modmailthreads (id, guildID, userID, channelID, closedBy, closeReason, transcript, created, closed) VALUES "+
+		"(?, ?, ?, ?, ?, ?, ?, ?, ?)", thread.ID, thread.GuildID, thread.UserID, thread.ChannelID, thread.ClosedBy, thread.CloseReason,
+		thread.Transcript, thread.Created.Unix(), 0)
+	return err
+}
+
+func (m *MySQL) getModmailThread(query string, args ...interface{}) (*ModmailThread, error) {
+	thread := new(ModmailThread)
+	var createdUnix, closedUnix int64
+	err := m.DB.QueryRow("SELECT id, guildID, userID, channelID, closedBy, closeReason, transcript, created, closed "+
+		"FROM modmailthreads WHERE "+query, args...).Scan(&thread.ID, &thread.GuildID, &thread.UserID, &thread.ChannelID,
+		&thread.ClosedBy, &thread.CloseReason, &thread.Transcript, &createdUnix, &closedUnix)
+	if err == sql.ErrNoRows {
+		return nil, ErrDatabaseNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	thread.Created = time.Unix(createdUnix, 0)
+	if closedUnix > 0 {
+		thread.Closed = time.Unix(closedUnix, 0)
+	}
+	return thread, nil
+}
+
+func (m *MySQL) GetModmailThreadByChannel(channelID string) (*ModmailThread, error) {
+	return m.getModmailThread("channelID = ? AND closed = 0", channelID)
+}
+
+func (m *MySQL) GetModmailOpenThread(userID string) (*ModmailThread, error) {
+	return m.getModmailThread("userID = ? AND closed = 0", userID)
+}
+
+func (m *MySQL) SetModmailThreadClosed(thread *ModmailThread) error {
+	_, err := m.DB.Exec("UPDATE modmailthreads SET closedBy = ?, closeReason = ?, transcript = ?, closed = ? WHERE id = ?",
+		thread.ClosedBy, thread.CloseReason, thread.Transcript, thread.Closed.Unix(), thread.ID)
+	return err
+}
+
+func (m *MySQL) AddModmailMessage(msg *ModmailMessage) error {
+	_, err := m.DB.Exec("INSERT INTO modmailmessages (threadID, authorID, authorTag, fromStaff, anonymous, content, attachments, timestamp) VALUES "+
+		"(?, ?, ?, ?, ?, ?, ?, ?)", msg.ThreadID, msg.AuthorID, msg.AuthorTag, msg.FromStaff, msg.Anonymous, msg.Content,
+		strings.Join(msg.Attachments, "\n"), msg.Timestamp.Unix())
+	return err
+}
+
+func (m *MySQL) GetModmailMessages(threadID snowflake.ID) ([]*ModmailMessage, error) {
+	rows, err := m.DB.Query("SELECT threadID, authorID, authorTag, fromStaff, anonymous, content, attachments, timestamp "+
+		"FROM modmailmessages WHERE threadID = ? ORDER BY timestamp ASC", threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	msgs := make([]*ModmailMessage, 0)
+	for rows.Next() {
+		msg := new(ModmailMessage)
+		var attachments string
+		var timestampUnix int64
+		if err = rows.Scan(&msg.ThreadID, &msg.AuthorID, &msg.AuthorTag, &msg.FromStaff, &msg.Anonymous, &msg.Content,
+			&attachments, &timestampUnix); err != nil {
+			return nil, err
+		}
+		if attachments != "" {
+			msg.Attachments = strings.Split(attachments, "\n")
+		}
+		msg.Timestamp = time.Unix(timestampUnix, 0)
+		msgs = append(msgs, msg)
+	}
+
+	return msgs, nil
+}
+
+func (m *MySQL) GetGuildTickets(guildID string) (*util.TicketSettings, error) {
+	data, err := m.getGuildSetting(guildID, "tickets")
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return util.NewDefaultTicketSettings(), nil
+	}
+	return util.TicketSettingsUnmarshal(data)
+}
+
+func (m *MySQL) SetGuildTickets(guildID string, settings *util.TicketSettings) error {
+	data, err := settings.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "tickets", data)
+}
+
+func (m *MySQL) AddTicket(ticket *Ticket) error {
+	_, err := m.DB.Exec("INSERT INTO tickets (id, guildID, channelID, ownerID, claimedBy, members, closedBy, closeReason, created, closed) VALUES "+
+		"(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", ticket.ID, ticket.GuildID, ticket.ChannelID, ticket.OwnerID, ticket.ClaimedBy,
+		strings.Join(ticket.Members, ","), ticket.ClosedBy, ticket.CloseReason, ticket.Created.Unix(), 0)
+	return err
+}
+
+func (m *MySQL) UpdateTicket(ticket *Ticket) error {
+	var closedUnix int64
+	if !ticket.Closed.IsZero() {
+		closedUnix = ticket.Closed.Unix()
+	}
+	_, err := m.DB.Exec("UPDATE tickets SET claimedBy = ?, members = ?, closedBy = ?, closeReason = ?, closed = ? WHERE id = ?",
+		ticket.ClaimedBy, strings.Join(ticket.Members, ","), ticket.ClosedBy, ticket.CloseReason, closedUnix, ticket.ID)
+	return err
+}
+
+func (m *MySQL) getTickets(query string, args ...interface{}) ([]*Ticket, error) {
+	rows, err := m.DB.Query("SELECT id, guildID, channelID, ownerID, claimedBy, members, closedBy, closeReason, created, closed "+
+		"FROM tickets WHERE "+query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	tickets := make([]*Ticket, 0)
+	for rows.Next() {
+		ticket := new(Ticket)
+		var members string
+		var createdUnix, closedUnix int64
+		if err = rows.Scan(&ticket.ID, &ticket.GuildID, &ticket.ChannelID, &ticket.OwnerID, &ticket.ClaimedBy, &members,
+			&ticket.ClosedBy, &ticket.CloseReason, &createdUnix, &closedUnix); err != nil {
+			return nil, err
+		}
+		ticket.Members = splitIDList(members)
+		ticket.Created = time.Unix(createdUnix, 0)
+		if closedUnix > 0 {
+			ticket.Closed = time.Unix(closedUnix, 0)
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, nil
+}
+
+func (m *MySQL) GetTicketByChannel(channelID string) (*Ticket, error) {
+	tickets, err := m.getTickets("channelID = ? AND closed = 0", channelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(tickets) == 0 {
+		return nil, ErrDatabaseNotFound
+	}
+	return tickets[0], nil
+}
+
+func (m *MySQL) GetOpenTickets(guildID, ownerID string) ([]*Ticket, error) {
+	return m.getTickets("guildID = ? AND ownerID = ? AND closed = 0", guildID, ownerID)
+}
+
+func (m *MySQL) GetGuildBackupSettings(guildID string) (*util.BackupSettings, error) {
+	data, err := m.getGuildSetting(guildID, "backupSettings")
+	if err != nil {
+		return nil, err
+	}
+	if data == "" {
+		return util.NewDefaultBackupSettings(), nil
+	}
+	return util.BackupSettingsUnmarshal(data)
+}
+
+func (m *MySQL) SetGuildBackupSettings(guildID string, settings *util.BackupSettings) error {
+	data, err := settings.Marshal()
+	if err != nil {
+		return err
+	}
+	return m.setGuildSetting(guildID, "backupSettings", data)
+}
@@ -0,0 +1,26 @@
+package core
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+// PushReport saves the passed report to the database and
+// sends its embed into the guild's mod log channel (if set)
+// and to the victim via DM.
+func PushReport(s *discordgo.Session, db Database, rep *util.Report) error {
+	err := db.AddReport(rep)
+	if err != nil {
+		return err
+	}
+
+	emb := rep.AsEmbed()
+	if modlogChan, err := db.GetGuildModLog(rep.GuildID); err == nil && modlogChan != "" {
+		s.ChannelMessageSendEmbed(modlogChan, emb)
+	}
+	if dmChan, err := s.UserChannelCreate(rep.VictimID); err == nil {
+		s.ChannelMessageSendEmbed(dmChan.ID, emb)
+	}
+
+	return nil
+}
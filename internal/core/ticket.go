@@ -0,0 +1,265 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/bwmarrin/snowflake"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+const (
+	TicketEmoji = "🎫"
+
+	// ticketTranscriptMaxMessages is the maximum number of
+	// messages of a ticket channel included in the transcript.
+	ticketTranscriptMaxMessages = 2000
+
+	ticketMemberPermissions = discordgo.PermissionReadMessages |
+		discordgo.PermissionSendMessages |
+		discordgo.PermissionReadMessageHistory |
+		discordgo.PermissionAttachFiles |
+		discordgo.PermissionEmbedLinks
+)
+
+var (
+	ErrTicketsNotEnabled     = errors.New("tickets are not enabled")
+	ErrTicketLimitReached    = errors.New("limit of open tickets reached")
+	ErrTicketMemberNotAdded  = errors.New("member is not added to the ticket")
+	ErrTicketMemberDuplicate = errors.New("member is already added to the ticket")
+)
+
+// ticketOpenLocks serializes opening tickets per guild
+// and user so that simultaneous requests can not exceed
+// the limit of open tickets.
+var ticketOpenLocks = util.NewKeyedLocks()
+
+type Ticket struct {
+	ID          snowflake.ID
+	GuildID     string
+	ChannelID   string
+	OwnerID     string
+	ClaimedBy   string
+	Members     []string
+	ClosedBy    string
+	CloseReason string
+	Created     time.Time
+	Closed      time.Time
+}
+
+// TicketOpen creates a private ticket channel for the passed
+// user in the guild's ticket category, which is only visible
+// for the user, the staff roles and the bot.
+func TicketOpen(s *discordgo.Session, db Database, guildID string, user *discordgo.User) (*Ticket, error) {
+	settings, err := db.GetGuildTickets(guildID)
+	if err != nil && !IsErrDatabaseNotFound(err) {
+		return nil, err
+	}
+	if settings == nil || !settings.IsEnabled() {
+		return nil, ErrTicketsNotEnabled
+	}
+
+	unlock := ticketOpenLocks.Lock(guildID + user.ID)
+	defer unlock()
+
+	open, err := db.GetOpenTickets(guildID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.MaxOpen > 0 && len(open) >= settings.MaxOpen {
+		return nil, ErrTicketLimitReached
+	}
+
+	overwrites := []*discordgo.PermissionOverwrite{
+		&discordgo.PermissionOverwrite{
+			ID:   guildID,
+			Type: "role",
+			Deny: discordgo.PermissionReadMessages,
+		},
+		&discordgo.PermissionOverwrite{
+			ID:    s.State.User.ID,
+			Type:  "member",
+			Allow: ticketMemberPermissions | discordgo.PermissionManageChannels,
+		},
+		&discordgo.PermissionOverwrite{
+			ID:    user.ID,
+			Type:  "member",
+			Allow: ticketMemberPermissions,
+		},
+	}
+	for _, roleID := range settings.StaffRoleIDs {
+		overwrites = append(overwrites, &discordgo.PermissionOverwrite{
+			ID:    roleID,
+			Type:  "role",
+			Allow: ticketMemberPermissions,
+		})
+	}
+
+	t := &Ticket{
+		ID:      util.NodeTickets.Generate(),
+		GuildID: guildID,
+		OwnerID: user.ID,
+		Members: []string{},
+		Created: time.Now(),
+	}
+
+	ch, err := s.GuildChannelCreateComplex(guildID, discordgo.GuildChannelCreateData{
+		Name:                 fmt.Sprintf("ticket-%s", strings.ToLower(user.Username)),
+		Type:                 discordgo.ChannelTypeGuildText,
+		Topic:                fmt.Sprintf("Ticket %s of %s (%s)", t.ID, user.String(), user.ID),
+		ParentID:             settings.CategoryID,
+		PermissionOverwrites: overwrites,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.ChannelID = ch.ID
+
+	if err = db.AddTicket(t); err != nil {
+		return nil, err
+	}
+
+	_, err = s.ChannelMessageSendEmbed(ch.ID, &discordgo.MessageEmbed{
+		Color: util.ColorEmbedDefault,
+		Title: "Ticket " + t.ID.String(),
+		Description: fmt.Sprintf("Welcome %s! Please describe your request, a staff member will be with you soon.\n\n"+
+			"Staff can use `ticket claim`, `ticket add <user>`, `ticket remove <user>` and `ticket close (<reason>)`.",
+			user.Mention()),
+		Timestamp: t.Created.Format(time.RFC3339),
+	})
+
+	return t, err
+}
+
+// TicketAddMember grants the passed member access to the ticket channel.
+func TicketAddMember(s *discordgo.Session, db Database, t *Ticket, userID string) error {
+	if userID == t.OwnerID || util.IndexOfStrArray(userID, t.Members) > -1 {
+		return ErrTicketMemberDuplicate
+	}
+
+	if err := s.ChannelPermissionSet(t.ChannelID, userID, "member", ticketMemberPermissions, 0); err != nil {
+		return err
+	}
+
+	t.Members = append(t.Members, userID)
+	return db.UpdateTicket(t)
+}
+
+// TicketRemoveMember revokes the access of the passed member
+// to the ticket channel, if the member was added before.
+func TicketRemoveMember(s *discordgo.Session, db Database, t *Ticket, userID string) error {
+	i := util.IndexOfStrArray(userID, t.Members)
+	if i < 0 {
+		return ErrTicketMemberNotAdded
+	}
+
+	if err := s.ChannelPermissionDelete(t.ChannelID, userID); err != nil {
+		return err
+	}
+
+	t.Members = append(t.Members[:i], t.Members[i+1:]...)
+	return db.UpdateTicket(t)
+}
+
+// TicketClose creates a transcript of the ticket channel, posts it
+// into the guild's ticket log channel, marks the ticket as closed
+// and deletes the ticket channel.
+func TicketClose(s *discordgo.Session, db Database, t *Ticket, executor *discordgo.User, reason string, asHTML bool) error {
+	msgs, err := ticketFetchMessages(s, t.ChannelID)
+	if err != nil {
+		return err
+	}
+
+	t.ClosedBy = executor.ID
+	t.CloseReason = reason
+	t.Closed = time.Now()
+
+	if err = db.UpdateTicket(t); err != nil {
+		return err
+	}
+
+	settings, err := db.GetGuildTickets(t.GuildID)
+	if err == nil && settings.LogChannelID != "" {
+		file := &discordgo.File{
+			Name:        fmt.Sprintf("ticket-%s.txt", t.ID),
+			ContentType: "text/plain",
+			Reader:      strings.NewReader(util.MessagesTranscript(msgs)),
+		}
+		if asHTML {
+			file = &discordgo.File{
+				Name:        fmt.Sprintf("ticket-%s.html", t.ID),
+				ContentType: "text/html",
+				Reader:      strings.NewReader(util.MessagesTranscriptHTML("Ticket "+t.ID.String(), msgs)),
+			}
+		}
+
+		claimedBy := "*not claimed*"
+		if t.ClaimedBy != "" {
+			claimedBy = fmt.Sprintf("<@%s>", t.ClaimedBy)
+		}
+
+		_, err = s.ChannelMessageSendComplex(settings.LogChannelID, &discordgo.MessageSend{
+			Embed: &discordgo.MessageEmbed{
+				Color: util.ColorEmbedGray,
+				Title: "Ticket " + t.ID.String(),
+				Fields: []*discordgo.MessageEmbedField{
+					&discordgo.MessageEmbedField{
+						Inline: true,
+						Name:   "Owner",
+						Value:  fmt.Sprintf("<@%s>", t.OwnerID),
+					},
+					&discordgo.MessageEmbedField{
+						Inline: true,
+						Name:   "Claimed By",
+						Value:  claimedBy,
+					},
+					&discordgo.MessageEmbedField{
+						Inline: true,
+						Name:   "Closed By",
+						Value:  executor.Mention(),
+					},
+					&discordgo.MessageEmbedField{
+						Name:  "Reason",
+						Value: util.EnsureNotEmpty(reason, "*no reason specified*"),
+					},
+				},
+				Timestamp: t.Closed.Format(time.RFC3339),
+			},
+			Files: []*discordgo.File{file},
+		})
+		if err != nil {
+			util.Log.Errorf("failed sending transcript of ticket %s: %s", t.ID, err.Error())
+		}
+	}
+
+	_, err = s.ChannelDelete(t.ChannelID)
+	return err
+}
+
+// ticketFetchMessages returns the messages of the passed
+// channel in chronological order.
+func ticketFetchMessages(s *discordgo.Session, channelID string) ([]*discordgo.Message, error) {
+	msgs := make([]*discordgo.Message, 0)
+	var before string
+
+	for len(msgs) < ticketTranscriptMaxMessages {
+		chunk, err := s.ChannelMessages(channelID, 100, before, "", "")
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, chunk...)
+		if len(chunk) < 100 {
+			break
+		}
+		before = chunk[len(chunk)-1].ID
+	}
+
+	for i, j := 0, len(msgs)-1; i < j; i, j = i+1, j-1 {
+		msgs[i], msgs[j] = msgs[j], msgs[i]
+	}
+
+	return msgs, nil
+}
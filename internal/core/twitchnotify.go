@@ -1,10 +1,16 @@
 package core
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"image"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/generaltso/vibrant"
@@ -15,11 +21,21 @@ import (
 
 const clockDuration = 60 * time.Second
 
+const (
+	twitchMaxQueryIDs      = 100
+	twitchMaxRetries       = 3
+	twitchRequestTimeout   = 10 * time.Second
+	twitchMaxRateLimitWait = 60 * time.Second
+	twitchTokenLeeway      = time.Minute
+)
+
 const (
 	TwitchNotifyIdentLogin = "login"
 	TwitchNotifyIdentID    = "id"
 )
 
+var ErrTwitchUserNotFound = errors.New("not found")
+
 type TwitchNotifyData struct {
 	ID           string   `json:"id"`
 	UserID       string   `json:"user_id"`
@@ -51,10 +67,31 @@ type TwitchNotifyGame struct {
 
 type TwitchNotifyHandler func(*TwitchNotifyData, *TwitchNotifyUser)
 
+// TwitchAPIEndpoints contains the base URL of the Helix
+// API and the URL of the OAuth2 token endpoint.
+type TwitchAPIEndpoints struct {
+	HelixURL string
+	TokenURL string
+}
+
+var DefaultTwitchAPIEndpoints = &TwitchAPIEndpoints{
+	HelixURL: "https://api.twitch.tv/helix",
+	TokenURL: "https://id.twitch.tv/oauth2/token",
+}
+
 type TwitchNotifyWorker struct {
 	timer              *time.Ticker
+	client             *http.Client
+	endpoints          *TwitchAPIEndpoints
 	users              map[string]*TwitchNotifyUser
+	usersMx            sync.RWMutex
 	clientID           string
+	clientSecret       string
+	token              string
+	tokenExpires       time.Time
+	tokenMx            sync.Mutex
+	rateLimitReset     time.Time
+	rateLimitMx        sync.Mutex
 	pastResponses      []*TwitchNotifyData
 	wentOnlineHandler  TwitchNotifyHandler
 	wentOfflineHandler TwitchNotifyHandler
@@ -71,55 +108,76 @@ func (g *TwitchNotifyGame) formatIconURL(res string) {
 	g.IconURL = strings.Replace(g.IconURL, "{width}x{height}", res, 1)
 }
 
-func NewTwitchNotifyWorker(clientID string, wentOnlineHandler TwitchNotifyHandler, wentOfflineHandler TwitchNotifyHandler) *TwitchNotifyWorker {
+// NewTwitchNotifyWorker creates a worker which checks the
+// stream status of all added users periodically after Start
+// was called. If endpoints is nil, DefaultTwitchAPIEndpoints
+// is used.
+func NewTwitchNotifyWorker(clientID, clientSecret string, endpoints *TwitchAPIEndpoints,
+	wentOnlineHandler TwitchNotifyHandler, wentOfflineHandler TwitchNotifyHandler) *TwitchNotifyWorker {
+
+	if endpoints == nil {
+		endpoints = DefaultTwitchAPIEndpoints
+	}
+
 	worker := &TwitchNotifyWorker{
+		client:             &http.Client{Timeout: twitchRequestTimeout},
+		endpoints:          endpoints,
 		users:              make(map[string]*TwitchNotifyUser),
 		clientID:           clientID,
+		clientSecret:       clientSecret,
 		wentOnlineHandler:  wentOnlineHandler,
 		wentOfflineHandler: wentOfflineHandler,
 		gameIDCache:        make(map[string]*TwitchNotifyGame),
 	}
 
-	timer := time.NewTicker(clockDuration)
+	return worker
+}
+
+// Start starts checking the stream status
+// of all added users periodically.
+func (w *TwitchNotifyWorker) Start() {
+	if w.timer != nil {
+		return
+	}
+
+	w.timer = time.NewTicker(clockDuration)
 
 	go func() {
 		for {
-			<-timer.C
-			worker.handler()
+			<-w.timer.C
+			if err := w.handler(); err != nil {
+				util.Log.Error("failed checking Twitch streams: ", err)
+			}
 		}
 	}()
-
-	worker.timer = timer
-
-	return worker
 }
 
 func (w *TwitchNotifyWorker) handler() error {
-	if len(w.users) < 1 {
+	userIDs := w.userIDs()
+	if len(userIDs) < 1 {
 		return nil
 	}
-	urlParam := ""
-	for uID := range w.users {
-		urlParam += "&user_id=" + uID
-	}
-
-	res, err := HTTPRequest("GET", "https://api.twitch.tv/helix/streams?"+urlParam[1:], map[string]string{
-		"Client-ID": w.clientID,
-	}, nil)
 
-	if err != nil {
-		return err
-	}
-
-	var data struct {
-		Data []*TwitchNotifyData `json:"data"`
-	}
-	err = res.ParseJSONBody(&data)
-	if err != nil {
-		return err
+	// As the streams are filtered by at most 100 user IDs
+	// and 100 streams are requested per page, every chunk
+	// fits into a single page.
+	streams := make([]*TwitchNotifyData, 0)
+	for _, chunk := range chunkTwitchIDs(userIDs) {
+		var data struct {
+			Data []*TwitchNotifyData `json:"data"`
+		}
+		query := url.Values{
+			"user_id": chunk,
+			"first":   {strconv.Itoa(twitchMaxQueryIDs)},
+		}
+		if err := w.helixGet("/streams", query, &data); err != nil {
+			return err
+		}
+		streams = append(streams, data.Data...)
 	}
 
-	for _, cData := range data.Data {
+	wentOnline := make([]*TwitchNotifyData, 0)
+	for _, cData := range streams {
 		var isStillOffline bool
 		for _, pData := range w.pastResponses {
 			if cData.ID == pData.ID {
@@ -128,42 +186,32 @@ func (w *TwitchNotifyWorker) handler() error {
 		}
 
 		if !isStillOffline {
-			user, _ := w.users[cData.UserID]
-			if game, ok := w.gameIDCache[cData.GameID]; !ok {
-				res, err := HTTPRequest("GET", "https://api.twitch.tv/helix/games?id="+cData.GameID, map[string]string{
-					"Client-ID": w.clientID,
-				}, nil)
-				if err == nil {
-					var body struct {
-						Data []*TwitchNotifyGame `json:"data"`
-					}
-					if res.ParseJSONBody(&body) == nil && &body != nil && len(body.Data) > 0 {
-						game = body.Data[0]
-						game.formatIconURL("50x70")
-						w.gameIDCache[cData.GameID] = game
-						cData.Game = game
-					}
-				} else {
-					util.Log.Error("failed requesting game name: ", err)
-				}
-			} else {
-				cData.Game = game
-			}
+			wentOnline = append(wentOnline, cData)
+		}
+	}
 
-			if cData.GameID == "" {
-				cData.Game = &TwitchNotifyGame{
-					Name: "game not found",
-				}
-			}
+	w.fetchGames(wentOnline)
 
-			cData.ThumbnailURL = strings.Replace(cData.ThumbnailURL, "{width}x{height}", "1280x720", 1)
-			w.wentOnlineHandler(cData, user)
+	for _, cData := range wentOnline {
+		if game, ok := w.gameIDCache[cData.GameID]; ok {
+			cData.Game = game
+		} else {
+			cData.Game = &TwitchNotifyGame{
+				Name: "game not found",
+			}
 		}
+
+		w.usersMx.RLock()
+		user := w.users[cData.UserID]
+		w.usersMx.RUnlock()
+
+		cData.ThumbnailURL = strings.Replace(cData.ThumbnailURL, "{width}x{height}", "1280x720", 1)
+		w.wentOnlineHandler(cData, user)
 	}
 
 	for _, pData := range w.pastResponses {
 		var isStillOnline bool
-		for _, cData := range data.Data {
+		for _, cData := range streams {
 			if pData.ID == cData.ID {
 				isStillOnline = true
 			}
@@ -176,38 +224,74 @@ func (w *TwitchNotifyWorker) handler() error {
 		}
 	}
 
-	w.pastResponses = make([]*TwitchNotifyData, len(data.Data))
-	copy(w.pastResponses, data.Data)
+	w.pastResponses = streams
 
 	return nil
 }
 
-func (w *TwitchNotifyWorker) GetUser(identifyer, identType string) (*TwitchNotifyUser, error) {
-	res, err := HTTPRequest("GET", "https://api.twitch.tv/helix/users?"+identType+"="+identifyer, map[string]string{
-		"Client-ID": w.clientID,
-	}, nil)
-
-	if err != nil {
-		return nil, err
+// fetchGames requests all games of the passed streams
+// which are not cached yet and adds them to the cache.
+func (w *TwitchNotifyWorker) fetchGames(streams []*TwitchNotifyData) {
+	gameIDs := make([]string, 0)
+	requested := make(map[string]bool)
+	for _, d := range streams {
+		if _, ok := w.gameIDCache[d.GameID]; !ok && d.GameID != "" && !requested[d.GameID] {
+			gameIDs = append(gameIDs, d.GameID)
+			requested[d.GameID] = true
+		}
 	}
 
-	var data struct {
-		Data []*TwitchNotifyUser `json:"data"`
+	for _, chunk := range chunkTwitchIDs(gameIDs) {
+		var data struct {
+			Data []*TwitchNotifyGame `json:"data"`
+		}
+		if err := w.helixGet("/games", url.Values{"id": chunk}, &data); err != nil {
+			util.Log.Error("failed requesting game name: ", err)
+			continue
+		}
+		for _, game := range data.Data {
+			game.formatIconURL("50x70")
+			w.gameIDCache[game.ID] = game
+		}
 	}
+}
 
-	err = res.ParseJSONBody(&data)
+func (w *TwitchNotifyWorker) GetUser(identifyer, identType string) (*TwitchNotifyUser, error) {
+	users, err := w.GetUsers([]string{identifyer}, identType)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(data.Data) < 1 || data.Data[0].ID == "" {
-		return nil, errors.New("not found")
+	if len(users) < 1 || users[0].ID == "" {
+		return nil, ErrTwitchUserNotFound
 	}
 
-	return data.Data[0], nil
+	return users[0], nil
+}
+
+// GetUsers requests the users by the passed identifyers
+// in chunks of 100. Users which could not be found are
+// not contained in the result.
+func (w *TwitchNotifyWorker) GetUsers(identifyers []string, identType string) ([]*TwitchNotifyUser, error) {
+	users := make([]*TwitchNotifyUser, 0, len(identifyers))
+
+	for _, chunk := range chunkTwitchIDs(identifyers) {
+		var data struct {
+			Data []*TwitchNotifyUser `json:"data"`
+		}
+		if err := w.helixGet("/users", url.Values{identType: chunk}, &data); err != nil {
+			return nil, err
+		}
+		users = append(users, data.Data...)
+	}
+
+	return users, nil
 }
 
 func (w *TwitchNotifyWorker) AddUser(u *TwitchNotifyUser) error {
+	w.usersMx.Lock()
+	defer w.usersMx.Unlock()
+
 	if len(w.users) >= 1000 {
 		return errors.New("max reached")
 	}
@@ -215,6 +299,179 @@ func (w *TwitchNotifyWorker) AddUser(u *TwitchNotifyUser) error {
 	return nil
 }
 
+func (w *TwitchNotifyWorker) userIDs() []string {
+	w.usersMx.RLock()
+	defer w.usersMx.RUnlock()
+
+	ids := make([]string, 0, len(w.users))
+	for id := range w.users {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// helixGet requests the Helix API endpoint and decodes the
+// JSON response body into v. Requests are delayed while the
+// rate limit is exceeded. Requests failing with status 429 or
+// a server error are retried with exponential backoff and
+// requests failing with status 401 are retried once with a
+// new app access token.
+func (w *TwitchNotifyWorker) helixGet(path string, query url.Values, v interface{}) error {
+	for attempt := 0; ; attempt++ {
+		w.waitRateLimit()
+
+		token, err := w.getToken()
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequest("GET", w.endpoints.HelixURL+path+"?"+query.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Client-ID", w.clientID)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := w.client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		w.updateRateLimit(resp.Header)
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && attempt == 0:
+			resp.Body.Close()
+			w.invalidateToken(token)
+			continue
+		case (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500) && attempt < twitchMaxRetries:
+			resp.Body.Close()
+			time.Sleep(time.Second << uint(attempt))
+			continue
+		}
+
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return twitchResponseError(resp)
+		}
+
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+}
+
+// getToken returns the current app access token or
+// requests a new one using the client credentials flow
+// if there is none or it is about to expire.
+func (w *TwitchNotifyWorker) getToken() (string, error) {
+	w.tokenMx.Lock()
+	defer w.tokenMx.Unlock()
+
+	if w.token != "" && time.Now().Before(w.tokenExpires) {
+		return w.token, nil
+	}
+
+	resp, err := w.client.PostForm(w.endpoints.TokenURL, url.Values{
+		"client_id":     {w.clientID},
+		"client_secret": {w.clientSecret},
+		"grant_type":    {"client_credentials"},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", twitchResponseError(resp)
+	}
+
+	var data struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", err
+	}
+	if data.AccessToken == "" {
+		return "", errors.New("twitch token response contains no access token")
+	}
+
+	lifetime := time.Duration(data.ExpiresIn) * time.Second
+	if lifetime > 2*twitchTokenLeeway {
+		lifetime -= twitchTokenLeeway
+	}
+
+	w.token = data.AccessToken
+	w.tokenExpires = time.Now().Add(lifetime)
+
+	return w.token, nil
+}
+
+func (w *TwitchNotifyWorker) invalidateToken(token string) {
+	w.tokenMx.Lock()
+	defer w.tokenMx.Unlock()
+
+	if w.token == token {
+		w.token = ""
+	}
+}
+
+// updateRateLimit remembers the time when the rate limit
+// bucket is refilled if no requests are remaining.
+func (w *TwitchNotifyWorker) updateRateLimit(header http.Header) {
+	if header.Get("Ratelimit-Remaining") != "0" {
+		return
+	}
+
+	reset, err := strconv.ParseInt(header.Get("Ratelimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	w.rateLimitMx.Lock()
+	w.rateLimitReset = time.Unix(reset, 0)
+	w.rateLimitMx.Unlock()
+}
+
+func (w *TwitchNotifyWorker) waitRateLimit() {
+	w.rateLimitMx.Lock()
+	wait := time.Until(w.rateLimitReset)
+	w.rateLimitMx.Unlock()
+
+	if wait > twitchMaxRateLimitWait {
+		wait = twitchMaxRateLimitWait
+	}
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+func twitchResponseError(resp *http.Response) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&body)
+
+	if body.Message == "" {
+		return fmt.Errorf("twitch API request failed: %s", resp.Status)
+	}
+	return fmt.Errorf("twitch API request failed: %s: %s", resp.Status, body.Message)
+}
+
+// chunkTwitchIDs splits the IDs into chunks of the maximum
+// number of IDs which can be passed in one API request.
+func chunkTwitchIDs(ids []string) [][]string {
+	chunks := make([][]string, 0, len(ids)/twitchMaxQueryIDs+1)
+	for len(ids) > twitchMaxQueryIDs {
+		chunks = append(chunks, ids[:twitchMaxQueryIDs])
+		ids = ids[twitchMaxQueryIDs:]
+	}
+	if len(ids) > 0 {
+		chunks = append(chunks, ids)
+	}
+	return chunks
+}
+
 func TwitchNotifyGetEmbed(d *TwitchNotifyData, u *TwitchNotifyUser) *discordgo.MessageEmbed {
 	emb := &discordgo.MessageEmbed{
 		Title:       u.DisplayName + " just started streaming!",
@@ -0,0 +1,186 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTwitchAPI serves the OAuth2 token endpoint and a
+// Helix API whose responses are defined by helix.
+type fakeTwitchAPI struct {
+	mx     sync.Mutex
+	tokens int
+	helix  func(w http.ResponseWriter, r *http.Request, token string)
+}
+
+func (f *fakeTwitchAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	if r.URL.Path == "/oauth2/token" {
+		if r.Method != "POST" || r.FormValue("grant_type") != "client_credentials" ||
+			r.FormValue("client_id") != "client-id" || r.FormValue("client_secret") != "client-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.tokens++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "token-" + strconv.Itoa(f.tokens),
+			"expires_in":   3600,
+		})
+		return
+	}
+
+	if r.Header.Get("Client-ID") != "client-id" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	f.helix(w, r, r.Header.Get("Authorization"))
+}
+
+func newTestTwitchWorker(api *fakeTwitchAPI, online TwitchNotifyHandler) (*TwitchNotifyWorker, func()) {
+	srv := httptest.NewServer(api)
+	worker := NewTwitchNotifyWorker("client-id", "client-secret", &TwitchAPIEndpoints{
+		HelixURL: srv.URL + "/helix",
+		TokenURL: srv.URL + "/oauth2/token",
+	}, online, nil)
+	return worker, srv.Close
+}
+
+func writeTwitchData(w http.ResponseWriter, data interface{}) {
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func TestTwitchRefreshesTokenOnUnauthorized(t *testing.T) {
+	api := &fakeTwitchAPI{}
+	api.helix = func(w http.ResponseWriter, r *http.Request, token string) {
+		if token != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeTwitchData(w, []*TwitchNotifyUser{{ID: "1", LoginName: r.URL.Query().Get("login")}})
+	}
+
+	worker, closer := newTestTwitchWorker(api, nil)
+	defer closer()
+
+	user, err := worker.GetUser("zekro", TwitchNotifyIdentLogin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "1" || user.LoginName != "zekro" {
+		t.Errorf("unexpected user %+v", user)
+	}
+	if api.tokens != 2 {
+		t.Errorf("requested %d tokens, expected 2", api.tokens)
+	}
+
+	if _, err = worker.GetUser("zekro", TwitchNotifyIdentLogin); err != nil {
+		t.Fatal(err)
+	}
+	if api.tokens != 2 {
+		t.Errorf("requested %d tokens after reusing the token, expected 2", api.tokens)
+	}
+}
+
+func TestTwitchChunksUserIDs(t *testing.T) {
+	requested := make(map[string]int)
+	chunkSizes := make([]int, 0)
+
+	api := &fakeTwitchAPI{}
+	api.helix = func(w http.ResponseWriter, r *http.Request, token string) {
+		switch r.URL.Path {
+		case "/helix/streams":
+			ids := r.URL.Query()["user_id"]
+			chunkSizes = append(chunkSizes, len(ids))
+			streams := make([]*TwitchNotifyData, 0)
+			for _, id := range ids {
+				requested[id]++
+				if id == "7" || id == "207" {
+					streams = append(streams, &TwitchNotifyData{ID: "stream-" + id, UserID: id})
+				}
+			}
+			writeTwitchData(w, streams)
+		case "/helix/games":
+			writeTwitchData(w, []*TwitchNotifyGame{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	var onlineMx sync.Mutex
+	online := make(map[string]bool)
+	worker, closer := newTestTwitchWorker(api, func(d *TwitchNotifyData, u *TwitchNotifyUser) {
+		onlineMx.Lock()
+		online[d.UserID] = true
+		onlineMx.Unlock()
+	})
+	defer closer()
+
+	for i := 0; i < 250; i++ {
+		if err := worker.AddUser(&TwitchNotifyUser{ID: strconv.Itoa(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := worker.handler(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(chunkSizes) != 3 {
+		t.Fatalf("streams were requested in %d requests, expected 3", len(chunkSizes))
+	}
+	for _, n := range chunkSizes {
+		if n > twitchMaxQueryIDs {
+			t.Errorf("requested %d user IDs at once, expected at most %d", n, twitchMaxQueryIDs)
+		}
+	}
+	for i := 0; i < 250; i++ {
+		if n := requested[strconv.Itoa(i)]; n != 1 {
+			t.Errorf("user %d was requested %d times, expected once", i, n)
+		}
+	}
+
+	if len(online) != 2 || !online["7"] || !online["207"] {
+		t.Errorf("went online handler was called for %v, expected users 7 and 207", online)
+	}
+}
+
+func TestTwitchWaitsForRateLimitReset(t *testing.T) {
+	var reset time.Time
+	requests := make([]time.Time, 0)
+
+	api := &fakeTwitchAPI{}
+	api.helix = func(w http.ResponseWriter, r *http.Request, token string) {
+		requests = append(requests, time.Now())
+		if len(requests) == 1 {
+			reset = time.Unix(time.Now().Unix()+2, 0)
+			w.Header().Set("Ratelimit-Remaining", "0")
+			w.Header().Set("Ratelimit-Reset", fmt.Sprint(reset.Unix()))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writeTwitchData(w, []*TwitchNotifyUser{{ID: "1"}})
+	}
+
+	worker, closer := newTestTwitchWorker(api, nil)
+	defer closer()
+
+	if _, err := worker.GetUser("1", TwitchNotifyIdentID); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, expected 2", len(requests))
+	}
+	if requests[1].Before(reset) {
+		t.Errorf("request was retried %s before the rate limit reset", reset.Sub(requests[1]))
+	}
+}
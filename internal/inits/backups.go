@@ -0,0 +1,42 @@
+package inits
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+func InitGuildBackups(session *discordgo.Session, config *core.Config, db core.Database, migrateFrom string) *core.GuildBackups {
+	if config.Backups == nil {
+		config.Backups = new(core.ConfigBackups)
+	}
+
+	storage, err := core.NewBackupStorage(config.Backups.Storage, config.Backups)
+	if err != nil {
+		util.Log.Fatal("failed initializing backup storage: ", err)
+	}
+
+	if migrateFrom != "" {
+		if core.BackupStorageType(migrateFrom) == core.BackupStorageType(config.Backups.Storage) {
+			util.Log.Fatalf("can not migrate backups from '%s' storage into the same storage", migrateFrom)
+		}
+
+		from, err := core.NewBackupStorage(migrateFrom, config.Backups)
+		if err != nil {
+			util.Log.Fatal("failed initializing backup migration source storage: ", err)
+		}
+
+		n, err := core.MigrateBackupStorage(from, storage)
+		if err != nil {
+			util.Log.Fatalf("failed migrating backup files after %d files: %s", n, err.Error())
+		}
+		util.Log.Infof("migrated %d backup files from '%s' storage", n, migrateFrom)
+	}
+
+	bck, err := core.NewGuildBackups(session, db, storage, config.Backups)
+	if err != nil {
+		util.Log.Fatal("failed initializing guild backups: ", err)
+	}
+
+	return bck
+}
@@ -1,6 +1,8 @@
 package inits
 
 import (
+	"time"
+
 	"github.com/bwmarrin/discordgo"
 	"github.com/bwmarrin/snowflake"
 	"github.com/zekroTJA/shinpuru/internal/commands"
@@ -9,6 +11,13 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/util"
 )
 
+const (
+	msgCacheSize     = 10000
+	msgCacheLifetime = 6 * time.Hour
+
+	memberCacheGuildSize = 25000
+)
+
 func InitDiscordBotSession(session *discordgo.Session, config *core.Config, database core.Database, cmdHandler *commands.CmdHandler, lct *core.LCTimer) {
 	snowflake.Epoch = util.DefEpoche
 	err := util.SetupSnowflakeNodes()
@@ -18,14 +27,26 @@ func InitDiscordBotSession(session *discordgo.Session, config *core.Config, data
 
 	session.Token = "Bot " + config.Discord.Token
 
+	msgCache := util.NewMessageCache(msgCacheSize, msgCacheLifetime)
+	memberCache := util.NewMemberCache(memberCacheGuildSize)
+
 	listenerInviteBlock := listeners.NewListenerInviteBlock(database, cmdHandler)
-	listenerGhostPing := listeners.NewListenerGhostPing(database, cmdHandler)
+	listenerLinkFilter := listeners.NewListenerLinkFilter(database, cmdHandler)
+	listenerFlag := listeners.NewListenerFlag(database, cmdHandler)
+	listenerBanList := listeners.NewListenerBanList(database, cmdHandler)
+	listenerModmail := listeners.NewListenerModmail(database)
+	listenerTickets := listeners.NewListenerTickets(database)
+	listenerGhostPing := listeners.NewListenerGhostPing(database, cmdHandler, msgCache)
+	listenerMessageLog := listeners.NewListenerMessageLog(database, msgCache)
+	listenerMemberLog := listeners.NewListenerMemberLog(database, memberCache)
+	listenerAuditLogImport := listeners.NewListenerAuditLogImport(database, cmdHandler)
+	listenerAntiSpam := listeners.NewListenerAntiSpam(database)
 
 	session.AddHandler(listeners.NewListenerReady(config, database, lct).Handler)
 	session.AddHandler(listeners.NewListenerCmd(config, database, cmdHandler).Handler)
 	session.AddHandler(listeners.NewListenerGuildJoin(config).Handler)
 	session.AddHandler(listeners.NewListenerMemberAdd(database).Handler)
-	session.AddHandler(listeners.NewListenerMemberRemove(database).Handler)
+	session.AddHandler(listeners.NewListenerMemberRemove(database, memberCache).Handler)
 	session.AddHandler(listeners.NewListenerVote(database).Handler)
 	session.AddHandler(listeners.NewListenerChannelCreate(database).Handler)
 	session.AddHandler(listeners.NewListenerVoiceUpdate(database).Handler)
@@ -35,6 +56,27 @@ func InitDiscordBotSession(session *discordgo.Session, config *core.Config, data
 	session.AddHandler(listenerGhostPing.HandlerMessageDelete)
 	session.AddHandler(listenerInviteBlock.HandlerMessageSend)
 	session.AddHandler(listenerInviteBlock.HandlerMessageEdit)
+	session.AddHandler(listenerLinkFilter.HandlerMessageCreate)
+	session.AddHandler(listenerLinkFilter.HandlerMessageEdit)
+	session.AddHandler(listenerAntiSpam.HandlerMessageCreate)
+	session.AddHandler(listenerMessageLog.HandlerMessageCreate)
+	session.AddHandler(listenerMessageLog.HandlerMessageEdit)
+	session.AddHandler(listenerMessageLog.HandlerMessageDelete)
+	session.AddHandler(listenerMessageLog.HandlerMessageDeleteBulk)
+	session.AddHandler(listenerMemberLog.HandlerGuildCreate)
+	session.AddHandler(listenerMemberLog.HandlerGuildMembersChunk)
+	session.AddHandler(listenerMemberLog.HandlerGuildDelete)
+	session.AddHandler(listenerMemberLog.HandlerMemberAdd)
+	session.AddHandler(listenerMemberLog.HandlerMemberRemove)
+	session.AddHandler(listenerMemberLog.HandlerMemberUpdate)
+	session.AddHandler(listenerMemberLog.HandlerBanAdd)
+	session.AddHandler(listenerAuditLogImport.HandlerBanAdd)
+	session.AddHandler(listenerAuditLogImport.HandlerMemberRemove)
+	session.AddHandler(listenerFlag.HandlerMessageReactionAdd)
+	session.AddHandler(listenerBanList.HandlerMessageReactionAdd)
+	session.AddHandler(listenerModmail.HandlerMessageCreate)
+	session.AddHandler(listenerModmail.HandlerChannelDelete)
+	session.AddHandler(listenerTickets.HandlerMessageReactionAdd)
 
 	err = session.Open()
 	if err != nil {
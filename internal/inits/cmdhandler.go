@@ -7,8 +7,8 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/util"
 )
 
-func InitCommandHandler(s *discordgo.Session, cfg *core.Config, db core.Database, tnw *core.TwitchNotifyWorker, lct *core.LCTimer) *commands.CmdHandler {
-	cmdHandler := commands.NewCmdHandler(s, db, cfg, tnw, lct)
+func InitCommandHandler(s *discordgo.Session, cfg *core.Config, db core.Database, tnw *core.TwitchNotifyWorker, bck *core.GuildBackups, lct *core.LCTimer) *commands.CmdHandler {
+	cmdHandler := commands.NewCmdHandler(s, db, cfg, tnw, bck, lct)
 
 	cmdHandler.RegisterCommand(&commands.CmdHelp{PermLvl: 0})
 	cmdHandler.RegisterCommand(&commands.CmdPrefix{PermLvl: 10})
@@ -41,6 +41,24 @@ func InitCommandHandler(s *discordgo.Session, cfg *core.Config, db core.Database
 	cmdHandler.RegisterCommand(&commands.CmdTag{PermLvl: 0})
 	cmdHandler.RegisterCommand(&commands.CmdJoinMsg{PermLvl: 4})
 	cmdHandler.RegisterCommand(&commands.CmdLeaveMsg{PermLvl: 4})
+	cmdHandler.RegisterCommand(&commands.CmdAntiSpam{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdAntiRaid{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdLockdown{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdMsgLog{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdMemberLog{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdBanList{PermLvl: 8})
+	cmdHandler.RegisterCommand(&commands.CmdHackban{PermLvl: 8})
+	cmdHandler.RegisterCommand(&commands.CmdSoftban{PermLvl: 8})
+	cmdHandler.RegisterCommand(&commands.CmdMassban{PermLvl: 8})
+	cmdHandler.RegisterCommand(&commands.CmdStickyRoles{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdLinkFilter{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdFlag{PermLvl: 0})
+	cmdHandler.RegisterCommand(&commands.CmdFlagQueue{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdModmail{PermLvl: 5})
+	cmdHandler.RegisterCommand(&commands.CmdModmailConfig{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdTicket{PermLvl: 0})
+	cmdHandler.RegisterCommand(&commands.CmdTicketConfig{PermLvl: 6})
+	cmdHandler.RegisterCommand(&commands.CmdLayout{PermLvl: 9})
 
 	if util.Release != "TRUE" {
 		cmdHandler.RegisterCommand(&commands.CmdTest{})
@@ -12,19 +12,41 @@ func InitTwitchNotifyer(session *discordgo.Session, config *core.Config, db core
 		return nil
 	}
 
+	if config.Etc.TwitchAppSecret == "" {
+		util.Log.Warning("Twitch app secret is not set in config. Twitch notifications will be disabled.")
+		return nil
+	}
+
 	listener := listeners.NewListenerTwitchNotify(session, config, db)
-	tnw := core.NewTwitchNotifyWorker(config.Etc.TwitchAppID,
+	tnw := core.NewTwitchNotifyWorker(config.Etc.TwitchAppID, config.Etc.TwitchAppSecret, nil,
 		listener.HandlerWentOnline, listener.HandlerWentOffline)
+	defer tnw.Start()
 
 	notifies, err := db.GetAllTwitchNotifies("")
-	if err == nil {
-		for _, notify := range notifies {
-			if u, err := tnw.GetUser(notify.TwitchUserID, core.TwitchNotifyIdentID); err == nil {
-				tnw.AddUser(u)
-			}
-		}
-	} else {
+	if err != nil {
 		util.Log.Error("failed getting Twitch notify entreis: ", err)
+		return tnw
+	}
+
+	userIDs := make([]string, 0, len(notifies))
+	added := make(map[string]bool)
+	for _, notify := range notifies {
+		if !added[notify.TwitchUserID] {
+			userIDs = append(userIDs, notify.TwitchUserID)
+			added[notify.TwitchUserID] = true
+		}
+	}
+
+	users, err := tnw.GetUsers(userIDs, core.TwitchNotifyIdentID)
+	if err != nil {
+		util.Log.Error("failed getting Twitch users: ", err)
+		return tnw
+	}
+
+	for _, u := range users {
+		if err = tnw.AddUser(u); err != nil {
+			util.Log.Error("failed adding Twitch user: ", err)
+		}
 	}
 
 	return tnw
@@ -0,0 +1,174 @@
+package listeners
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/timedmap"
+
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+const (
+	asCleanupInterval = 1 * time.Minute
+)
+
+type ListenerAntiSpam struct {
+	db       core.Database
+	trackers *timedmap.TimedMap
+}
+
+type spamTracker struct {
+	mtx  sync.Mutex
+	msgs []*spamEntry
+}
+
+type spamEntry struct {
+	id        string
+	channelID string
+	content   string
+	time      time.Time
+}
+
+func NewListenerAntiSpam(db core.Database) *ListenerAntiSpam {
+	return &ListenerAntiSpam{
+		db:       db,
+		trackers: timedmap.New(asCleanupInterval),
+	}
+}
+
+func (l *ListenerAntiSpam) HandlerMessageCreate(s *discordgo.Session, e *discordgo.MessageCreate) {
+	if e.GuildID == "" || e.Author == nil || e.Author.Bot {
+		return
+	}
+
+	settings, err := l.db.GetGuildAntiSpam(e.GuildID)
+	if err != nil {
+		if !core.IsErrDatabaseNotFound(err) {
+			util.Log.Errorf("failed getting anti spam settings for guild %s: %s", e.GuildID, err.Error())
+		}
+		return
+	}
+	if settings == nil {
+		return
+	}
+
+	key := e.GuildID + e.Author.ID
+	tracker, ok := l.trackers.GetValue(key).(*spamTracker)
+	if !ok || tracker == nil {
+		tracker = new(spamTracker)
+	}
+	l.trackers.Set(key, tracker, settings.GetMaxInterval())
+
+	now := time.Now()
+	content := strings.ToLower(strings.TrimSpace(e.Content))
+
+	tracker.mtx.Lock()
+
+	msgs := make([]*spamEntry, 0, len(tracker.msgs)+1)
+	for _, m := range tracker.msgs {
+		if now.Sub(m.time) <= settings.GetMaxInterval() {
+			msgs = append(msgs, m)
+		}
+	}
+	msgs = append(msgs, &spamEntry{
+		id:        e.ID,
+		channelID: e.ChannelID,
+		content:   content,
+		time:      now,
+	})
+	tracker.msgs = msgs
+
+	var inRate, duplicates []*spamEntry
+	for _, m := range msgs {
+		if now.Sub(m.time) <= settings.GetRateInterval() {
+			inRate = append(inRate, m)
+		}
+		if content != "" && m.content == content && now.Sub(m.time) <= settings.GetDuplicateInterval() {
+			duplicates = append(duplicates, m)
+		}
+	}
+
+	var offending []*spamEntry
+	var reason string
+	if settings.RateLimit > 0 && len(inRate) > settings.RateLimit {
+		offending = inRate
+		reason = fmt.Sprintf("Sent %d messages in %d seconds.", len(inRate), settings.RateInterval)
+	} else if settings.DuplicateLimit > 0 && len(duplicates) > settings.DuplicateLimit {
+		offending = duplicates
+		reason = fmt.Sprintf("Sent the same message %d times in %d seconds.", len(duplicates), settings.DuplicateInterval)
+	}
+
+	if offending != nil {
+		tracker.msgs = nil
+	}
+
+	tracker.mtx.Unlock()
+
+	if offending == nil {
+		return
+	}
+
+	l.purge(s, offending)
+
+	if err = l.penalize(s, e.GuildID, e.Author.ID, settings.PenaltyType, reason); err != nil {
+		util.Log.Errorf("failed applying anti spam penalty to %s on guild %s: %s", e.Author.ID, e.GuildID, err.Error())
+	}
+}
+
+func (l *ListenerAntiSpam) purge(s *discordgo.Session, msgs []*spamEntry) {
+	byChannel := make(map[string][]string)
+	for _, m := range msgs {
+		byChannel[m.channelID] = append(byChannel[m.channelID], m.id)
+	}
+
+	for chanID, msgIDs := range byChannel {
+		if err := s.ChannelMessagesBulkDelete(chanID, msgIDs); err != nil {
+			util.Log.Errorf("failed purging spam messages in channel %s: %s", chanID, err.Error())
+		}
+	}
+}
+
+func (l *ListenerAntiSpam) penalize(s *discordgo.Session, guildID, userID string, repType int, reason string) error {
+	rep := &util.Report{
+		ID:         util.NodesReport[repType].Generate(),
+		Type:       repType,
+		GuildID:    guildID,
+		ExecutorID: s.State.User.ID,
+		VictimID:   userID,
+		Msg:        "[ANTI SPAM] " + reason,
+	}
+
+	if util.ReportTypes[repType] == "MUTE" {
+		muteRoleID, err := l.db.GetMuteRoleGuild(guildID)
+		if err != nil {
+			return err
+		}
+		if muteRoleID == "" {
+			return errors.New("mute role is not set up")
+		}
+		if err = s.GuildMemberRoleAdd(guildID, userID, muteRoleID); err != nil {
+			return err
+		}
+	}
+
+	// The report is pushed before kicking or banning so
+	// that the victim still receives the DM.
+	if err := core.PushReport(s, l.db, rep); err != nil {
+		return err
+	}
+
+	switch util.ReportTypes[repType] {
+	case "KICK":
+		return s.GuildMemberDeleteWithReason(guildID, userID, rep.Msg)
+	case "BAN":
+		return s.GuildBanCreateWithReason(guildID, userID, rep.Msg, 1)
+	}
+
+	return nil
+}
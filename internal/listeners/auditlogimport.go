@@ -0,0 +1,110 @@
+package listeners
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/zekroTJA/shinpuru/internal/commands"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+const (
+	auditLogMaxAge = 15 * time.Second
+	auditLogDelay  = 2 * time.Second
+)
+
+// ListenerAuditLogImport creates reports for kicks and bans
+// which were not performed by the bot itself by looking up
+// the responsible moderator in the guild's audit log.
+type ListenerAuditLogImport struct {
+	db         core.Database
+	cmdHandler *commands.CmdHandler
+}
+
+func NewListenerAuditLogImport(db core.Database, cmdHandler *commands.CmdHandler) *ListenerAuditLogImport {
+	return &ListenerAuditLogImport{
+		db:         db,
+		cmdHandler: cmdHandler,
+	}
+}
+
+func (l *ListenerAuditLogImport) HandlerBanAdd(s *discordgo.Session, e *discordgo.GuildBanAdd) {
+	time.Sleep(auditLogDelay)
+
+	executorID, reason, ok := findAuditLogEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberBanAdd)
+	if !ok {
+		return
+	}
+
+	l.importReport(s, e.GuildID, e.User.ID, executorID, reason, "BAN")
+}
+
+func (l *ListenerAuditLogImport) HandlerMemberRemove(s *discordgo.Session, e *discordgo.GuildMemberRemove) {
+	if e.User.ID == s.State.User.ID {
+		return
+	}
+
+	time.Sleep(auditLogDelay)
+
+	executorID, reason, ok := findAuditLogEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberKick)
+	if !ok {
+		return
+	}
+
+	l.importReport(s, e.GuildID, e.User.ID, executorID, reason, "KICK")
+}
+
+func (l *ListenerAuditLogImport) importReport(s *discordgo.Session, guildID, victimID, executorID, reason, repTypeName string) {
+	// Actions performed by the bot already
+	// created a report on execution.
+	if executorID == s.State.User.ID {
+		return
+	}
+
+	repType := util.IndexOfStrArray(repTypeName, util.ReportTypes)
+	rep := &util.Report{
+		ID:         util.NodesReport[repType].Generate(),
+		Type:       repType,
+		GuildID:    guildID,
+		ExecutorID: executorID,
+		VictimID:   victimID,
+		Msg:        util.EnsureNotEmpty(reason, "no reason specified"),
+	}
+
+	if err := core.PushReport(s, l.db, rep); err != nil {
+		util.Log.Errorf("failed importing %s of %s on guild %s from audit log: %s",
+			repTypeName, victimID, guildID, err.Error())
+		return
+	}
+
+	if repTypeName == "BAN" {
+		if err := core.PublishBan(s, l.db, rep, l.cmdHandler.BotModTargetFunc(s, "ban")); err != nil {
+			util.Log.Errorf("failed publishing ban of %s on guild %s: %s", victimID, guildID, err.Error())
+		}
+	}
+}
+
+// findAuditLogEntry searches the guild's audit log for a
+// recent entry of the given action type targeting the
+// passed user and returns its executor and reason.
+func findAuditLogEntry(s *discordgo.Session, guildID, targetID string, actionType int) (string, string, bool) {
+	auditLog, err := s.GuildAuditLog(guildID, "", "", actionType, 10)
+	if err != nil {
+		return "", "", false
+	}
+
+	for _, entry := range auditLog.AuditLogEntries {
+		if entry.TargetID != targetID {
+			continue
+		}
+		created, err := util.GetDiscordSnowflakeCreationTime(entry.ID)
+		if err != nil || time.Since(created) > auditLogMaxAge {
+			continue
+		}
+		return entry.UserID, entry.Reason, true
+	}
+
+	return "", "", false
+}
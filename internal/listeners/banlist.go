@@ -0,0 +1,79 @@
+package listeners
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/commands"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type ListenerBanList struct {
+	db         core.Database
+	cmdHandler *commands.CmdHandler
+
+	mtx sync.Mutex
+}
+
+func NewListenerBanList(db core.Database, cmdHandler *commands.CmdHandler) *ListenerBanList {
+	return &ListenerBanList{
+		db:         db,
+		cmdHandler: cmdHandler,
+	}
+}
+
+func (l *ListenerBanList) HandlerMessageReactionAdd(s *discordgo.Session, e *discordgo.MessageReactionAdd) {
+	if e.GuildID == "" || e.UserID == s.State.User.ID {
+		return
+	}
+
+	accepted := e.Emoji.Name == core.BanListAlertAccept
+	if !accepted && e.Emoji.Name != core.BanListAlertDecline {
+		return
+	}
+
+	alert, err := l.db.GetBanListAlert(e.MessageID)
+	if err != nil || alert.GuildID != e.GuildID {
+		return
+	}
+
+	if !l.cmdHandler.CommandPermissionFunc(s, "ban")(e.GuildID, e.UserID) {
+		s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.APIName(), e.UserID)
+		return
+	}
+
+	if accepted {
+		guild, err := s.Guild(e.GuildID)
+		if err != nil {
+			return
+		}
+		user, err := s.User(e.UserID)
+		if err != nil {
+			return
+		}
+		if errTxt := l.cmdHandler.ModTargetError(s, guild, user, alert.Entry.UserID, "ban", true); errTxt != "" {
+			s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.APIName(), e.UserID)
+			msg, _ := util.SendEmbedError(s, e.ChannelID, errTxt)
+			util.DeleteMessageLater(s, msg, 8*time.Second)
+			return
+		}
+	}
+
+	// Resolving is locked and the alert is fetched again so
+	// that simultaneous reactions of multiple moderators do
+	// not resolve an alert twice.
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if alert, err = l.db.GetBanListAlert(e.MessageID); err != nil {
+		return
+	}
+
+	if err = core.ResolveBanListAlert(s, l.db, alert, accepted, e.UserID); err != nil {
+		msg, _ := util.SendEmbedError(s, e.ChannelID,
+			"Failed applying ban: ```\n"+err.Error()+"\n```")
+		util.DeleteMessageLater(s, msg, 15*time.Second)
+	}
+}
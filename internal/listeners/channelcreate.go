@@ -4,6 +4,7 @@ import (
 	"github.com/bwmarrin/discordgo"
 
 	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
 )
 
 type ListenerChannelCreate struct {
@@ -18,7 +19,16 @@ func NewListenerChannelCreate(db core.Database) *ListenerChannelCreate {
 
 func (l *ListenerChannelCreate) Handler(s *discordgo.Session, e *discordgo.ChannelCreate) {
 	roleID, err := l.db.GetMuteRoleGuild(e.GuildID)
-	if err == nil {
-		s.ChannelPermissionSet(e.ID, roleID, "role", 0, 0x00000800)
+	if err != nil || roleID == "" {
+		return
+	}
+
+	guild, err := s.State.Guild(e.GuildID)
+	if err != nil {
+		return
+	}
+
+	if deny := util.MuteChannelDeny(guild, e.Channel); deny != 0 {
+		util.MuteSetupChannel(s, e.Channel, roleID, deny)
 	}
 }
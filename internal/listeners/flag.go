@@ -0,0 +1,184 @@
+package listeners
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/commands"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+	"github.com/zekroTJA/timedmap"
+)
+
+// flagResolvePermLvlFallback is used as permission level
+// required to resolve flags if the permission level of
+// the corresponding command can not be determined.
+const flagResolvePermLvlFallback = 5
+
+const (
+	// flagReactionCooldown is the time a member must wait
+	// between flagging messages via reaction.
+	flagReactionCooldown = 30 * time.Second
+	// flagReactionDedupLifetime is the time in which the
+	// same message is only flagged once per member via
+	// reaction.
+	flagReactionDedupLifetime = 24 * time.Hour
+)
+
+// flagActionCommands maps the flag statuses to the invokes
+// of the commands whose permission level is required to
+// resolve a flag with the status.
+var flagActionCommands = map[string]string{
+	core.FlagStatusDismissed: "report",
+	core.FlagStatusWarned:    "report",
+	core.FlagStatusMuted:     "mute",
+	core.FlagStatusBanned:    "ban",
+}
+
+// flagActionNames maps the flag statuses which are checked
+// against the moderation target guard to the action name.
+var flagActionNames = map[string]string{
+	core.FlagStatusWarned: "warn",
+	core.FlagStatusMuted:  "mute",
+	core.FlagStatusBanned: "ban",
+}
+
+type ListenerFlag struct {
+	db         core.Database
+	cmdHandler *commands.CmdHandler
+	reactions  *timedmap.TimedMap
+
+	mtx         sync.Mutex
+	reactionMtx sync.Mutex
+}
+
+func NewListenerFlag(db core.Database, cmdHandler *commands.CmdHandler) *ListenerFlag {
+	return &ListenerFlag{
+		db:         db,
+		cmdHandler: cmdHandler,
+		reactions:  timedmap.New(time.Minute),
+	}
+}
+
+func (l *ListenerFlag) HandlerMessageReactionAdd(s *discordgo.Session, e *discordgo.MessageReactionAdd) {
+	if e.GuildID == "" || e.UserID == s.State.User.ID {
+		return
+	}
+
+	queueChan, err := l.db.GetGuildFlagQueue(e.GuildID)
+	if err != nil || queueChan == "" {
+		return
+	}
+
+	emoji := strings.TrimSuffix(e.Emoji.Name, "\ufe0f")
+
+	if e.ChannelID == queueChan {
+		if status, ok := core.FlagActions[emoji]; ok {
+			l.resolve(s, e.MessageReaction, status)
+		}
+		return
+	}
+
+	if emoji == core.FlagEmoji {
+		l.flagByReaction(s, e.MessageReaction)
+	}
+}
+
+func (l *ListenerFlag) flagByReaction(s *discordgo.Session, e *discordgo.MessageReaction) {
+	// The reaction is removed so that the flagged
+	// member does not see who flagged the message.
+	s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.APIName(), e.UserID)
+
+	if !l.allowReactionFlag(e.UserID, e.MessageID) {
+		return
+	}
+
+	msg, err := s.ChannelMessage(e.ChannelID, e.MessageID)
+	if err != nil || msg.Author == nil || msg.Author.Bot || msg.Author.ID == e.UserID {
+		return
+	}
+
+	flag := &core.Flag{
+		GuildID:    e.GuildID,
+		ChannelID:  e.ChannelID,
+		MessageID:  e.MessageID,
+		ReporterID: e.UserID,
+		VictimID:   msg.Author.ID,
+		Reason:     "*flagged via reaction*",
+		Content:    msg.Content,
+	}
+
+	if err = core.PostFlag(s, l.db, flag); err != nil {
+		util.Log.Errorf("Failed posting flag on guild '%s': %s", e.GuildID, err.Error())
+	}
+}
+
+// allowReactionFlag returns true if the member may flag the
+// message via reaction. Members can flag a message only once
+// and must wait flagReactionCooldown between flags.
+func (l *ListenerFlag) allowReactionFlag(userID, messageID string) bool {
+	l.reactionMtx.Lock()
+	defer l.reactionMtx.Unlock()
+
+	msgKey := userID + ":" + messageID
+	if l.reactions.Contains(userID) || l.reactions.Contains(msgKey) {
+		return false
+	}
+
+	l.reactions.Set(userID, true, flagReactionCooldown)
+	l.reactions.Set(msgKey, true, flagReactionDedupLifetime)
+	return true
+}
+
+func (l *ListenerFlag) resolve(s *discordgo.Session, e *discordgo.MessageReaction, status string) {
+	permLvl, err := l.cmdHandler.GetPermissionLevel(s, e.GuildID, e.UserID)
+	if err != nil {
+		return
+	}
+
+	requiredPermLvl := flagResolvePermLvlFallback
+	if cmd, ok := l.cmdHandler.GetCommand(flagActionCommands[status]); ok {
+		requiredPermLvl = cmd.GetPermission()
+	}
+	if permLvl < requiredPermLvl {
+		s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.APIName(), e.UserID)
+		return
+	}
+
+	// Resolving is locked so that simultaneous reactions
+	// of multiple moderators do not resolve a flag twice.
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	flag, err := l.db.GetFlagByQueueMessage(e.MessageID)
+	if err != nil || flag.Status != "" {
+		return
+	}
+
+	if action, ok := flagActionNames[status]; ok {
+		guild, err := s.Guild(e.GuildID)
+		if err != nil {
+			return
+		}
+		user, err := s.User(e.UserID)
+		if err != nil {
+			return
+		}
+		botAction := status != core.FlagStatusWarned
+		if errTxt := l.cmdHandler.ModTargetError(s, guild, user, flag.VictimID, action, botAction); errTxt != "" {
+			s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.APIName(), e.UserID)
+			msg, _ := util.SendEmbedError(s, e.ChannelID, errTxt)
+			util.DeleteMessageLater(s, msg, 8*time.Second)
+			return
+		}
+	}
+
+	if _, err = core.ResolveFlag(s, l.db, flag, status, e.UserID,
+		l.cmdHandler.BotModTargetFunc(s, "ban")); err != nil {
+		msg, _ := util.SendEmbedError(s, e.ChannelID,
+			"Failed resolving flag: ```\n"+err.Error()+"\n```")
+		util.DeleteMessageLater(s, msg, 15*time.Second)
+	}
+}
@@ -1,10 +1,15 @@
 package listeners
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/zekroTJA/shinpuru/internal/util"
 
@@ -13,8 +18,12 @@ import (
 	"github.com/zekroTJA/shinpuru/internal/core"
 )
 
+const linkResolveTimeout = 5 * time.Second
+
+var linkResolveClient = &http.Client{Timeout: linkResolveTimeout}
+
 var (
-	rxInvLink = regexp.MustCompile(`(?i)(https?:\/\/)?(www\.)?(discord\.gg|discordapp.com\/invite)\/.*`)
+	rxInvLink = regexp.MustCompile(`(?i)(?:https?:\/\/)?(?:www\.)?(?:discord\.gg|discord(?:app)?\.com\/invite)\/([\w-]+)`)
 	rxGenLink = regexp.MustCompile(`(?i)(https?:\/\/)?(www\.)?([\w-\S]+\.)+\w{1,10}\/?[\S]+`)
 )
 
@@ -40,44 +49,98 @@ func (l *ListenerInviteBlock) HandlerMessageEdit(s *discordgo.Session, e *discor
 }
 
 func (l *ListenerInviteBlock) invokeCheck(s *discordgo.Session, msg *discordgo.Message) {
-	cont := msg.Content
-
-	if l.checkForInviteLink(cont) {
-		l.detected(s, msg)
+	if msg.Author == nil || msg.Author.Bot || msg.GuildID == "" {
 		return
 	}
 
-	link := rxGenLink.FindString(cont)
-	if link != "" {
-		match, err := l.followLink(link)
+	cont := msg.Content
+
+	codes := l.getInviteCodes(cont)
+
+	if len(codes) == 0 {
+		link := rxGenLink.FindString(cont)
+		if link == "" {
+			return
+		}
+		var err error
+		codes, err = l.followLink(link)
 		if err != nil {
 			util.Log.Error("Failed following link: ", err)
 			return
 		}
-		if match {
-			l.detected(s, msg)
-		}
+	}
+
+	if len(codes) == 0 {
+		return
+	}
+
+	if err := l.detected(s, msg, codes); err != nil {
+		util.Log.Errorf("Failed handling invite link of member '%s' on guild '%s': %s",
+			msg.Author.ID, msg.GuildID, err.Error())
 	}
 }
 
-func (l *ListenerInviteBlock) checkForInviteLink(cont string) bool {
-	return rxInvLink.MatchString(cont)
+func (l *ListenerInviteBlock) getInviteCodes(cont string) []string {
+	matches := rxInvLink.FindAllStringSubmatch(cont, -1)
+	codes := make([]string, len(matches))
+	for i, m := range matches {
+		codes[i] = m[1]
+	}
+	return codes
 }
 
-func (l *ListenerInviteBlock) followLink(link string) (bool, error) {
-	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
-		link = "http://" + link
+func (l *ListenerInviteBlock) followLink(link string) ([]string, error) {
+	u, err := resolveLink(context.Background(), link)
+	if err != nil {
+		return nil, nil
+	}
+
+	return l.getInviteCodes(u.String()), nil
+}
+
+// resolveLink follows all redirects of the passed link
+// and returns the final URL. Requests are canceled after
+// linkResolveTimeout or when ctx is done.
+func resolveLink(ctx context.Context, link string) (*url.URL, error) {
+	req, err := http.NewRequest("GET", withScheme(link), nil)
+	if err != nil {
+		return nil, err
 	}
 
-	resp, err := http.DefaultClient.Get(link)
+	resp, err := linkResolveClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return false, nil
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return resp.Request.URL, nil
+}
+
+func withScheme(link string) string {
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return "http://" + link
 	}
+	return link
+}
 
-	return l.checkForInviteLink(resp.Request.URL.String()), nil
+// isBlocked returns true if any of the passed invite codes
+// points to a guild which is neither the current guild nor
+// whitelisted. Invites which can not be resolved are
+// considered as blocked.
+func (l *ListenerInviteBlock) isBlocked(s *discordgo.Session, guildID string, codes []string, settings *util.InviteBlockSettings) bool {
+	for _, code := range codes {
+		inv, err := s.Invite(code)
+		if err != nil || inv.Guild == nil {
+			return true
+		}
+		if inv.Guild.ID != guildID && !settings.IsWhitelisted(inv.Guild.ID) {
+			return true
+		}
+	}
+	return false
 }
 
-func (l *ListenerInviteBlock) detected(s *discordgo.Session, e *discordgo.Message) error {
+func (l *ListenerInviteBlock) detected(s *discordgo.Session, e *discordgo.Message, codes []string) error {
 	_lvl, err := l.db.GetGuildInviteBlock(e.GuildID)
 	if core.IsErrDatabaseNotFound(err) {
 		return nil
@@ -103,5 +166,76 @@ func (l *ListenerInviteBlock) detected(s *discordgo.Session, e *discordgo.Messag
 		return nil
 	}
 
-	return s.ChannelMessageDelete(e.ChannelID, e.ID)
+	settings, err := l.db.GetGuildInviteBlockSettings(e.GuildID)
+	if err != nil {
+		return err
+	}
+
+	if !l.isBlocked(s, e.GuildID, codes, settings) {
+		return nil
+	}
+
+	if err = s.ChannelMessageDelete(e.ChannelID, e.ID); err != nil {
+		return err
+	}
+
+	offences, err := l.db.GetInviteBlockOffences(e.GuildID, e.Author.ID)
+	if err != nil {
+		return err
+	}
+	offences++
+	if err = l.db.SetInviteBlockOffences(e.GuildID, e.Author.ID, offences); err != nil {
+		return err
+	}
+
+	return l.penalize(s, e.GuildID, e.Author.ID, settings.Action, offences)
+}
+
+func (l *ListenerInviteBlock) penalize(s *discordgo.Session, guildID, userID, action string, offences int) error {
+	var repTypeName string
+	switch action {
+	case util.InviteBlockActionDeleteWarn:
+		repTypeName = "WARN"
+	case util.InviteBlockActionMute:
+		repTypeName = "MUTE"
+	case util.InviteBlockActionKick:
+		repTypeName = "KICK"
+	default:
+		return nil
+	}
+
+	repType := util.IndexOfStrArray(repTypeName, util.ReportTypes)
+	rep := &util.Report{
+		ID:         util.NodesReport[repType].Generate(),
+		Type:       repType,
+		GuildID:    guildID,
+		ExecutorID: s.State.User.ID,
+		VictimID:   userID,
+		Msg:        fmt.Sprintf("[INVITE BLOCK] Posted a blocked invite link (offence #%d).", offences),
+	}
+
+	if repTypeName == "MUTE" {
+		muteRoleID, err := l.db.GetMuteRoleGuild(guildID)
+		if err != nil && !core.IsErrDatabaseNotFound(err) {
+			return err
+		}
+		if muteRoleID == "" {
+			return errors.New("mute role is not set up")
+		}
+		if err = s.GuildMemberRoleAdd(guildID, userID, muteRoleID); err != nil {
+			return err
+		}
+	}
+
+	// The report is pushed before kicking so that
+	// the victim still receives the DM.
+	if err := core.PushReport(s, l.db, rep); err != nil {
+		return err
+	}
+
+	if repTypeName == "KICK" {
+		return s.GuildMemberDeleteWithReason(guildID, userID, rep.Msg)
+	}
+
+	return nil
 }
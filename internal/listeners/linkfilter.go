@@ -0,0 +1,146 @@
+package listeners
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/commands"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+// linkFilterMaxLinks is the maximum number of links per
+// message which are checked against the link filter.
+const linkFilterMaxLinks = 5
+
+// linkFilterResolveTimeout is the maximum time spent
+// on resolving all links of a message.
+const linkFilterResolveTimeout = 5 * time.Second
+
+// rxExplicitLink only matches links with a scheme or
+// a www. prefix so that plain words containing dots
+// like "node.js" are not treated as links in allow mode.
+var rxExplicitLink = regexp.MustCompile(`(?i)(https?:\/\/|www\.)[^\s<>]+`)
+
+type ListenerLinkFilter struct {
+	db         core.Database
+	cmdHandler *commands.CmdHandler
+}
+
+func NewListenerLinkFilter(db core.Database, cmdHandler *commands.CmdHandler) *ListenerLinkFilter {
+	return &ListenerLinkFilter{
+		db:         db,
+		cmdHandler: cmdHandler,
+	}
+}
+
+func (l *ListenerLinkFilter) HandlerMessageCreate(s *discordgo.Session, e *discordgo.MessageCreate) {
+	l.check(s, e.Message)
+}
+
+func (l *ListenerLinkFilter) HandlerMessageEdit(s *discordgo.Session, e *discordgo.MessageUpdate) {
+	l.check(s, e.Message)
+}
+
+func (l *ListenerLinkFilter) check(s *discordgo.Session, msg *discordgo.Message) {
+	if msg.Author == nil || msg.Author.Bot || msg.GuildID == "" {
+		return
+	}
+
+	links := rxGenLink.FindAllString(msg.Content, linkFilterMaxLinks)
+	if len(links) == 0 {
+		return
+	}
+
+	settings, err := l.db.GetGuildLinkFilter(msg.GuildID)
+	if err != nil || !settings.Enabled {
+		return
+	}
+
+	if util.IndexOfStrArray(msg.ChannelID, settings.ChannelExceptions) > -1 {
+		return
+	}
+
+	if settings.Mode == util.LinkFilterModeAllow {
+		if links = rxExplicitLink.FindAllString(msg.Content, linkFilterMaxLinks); len(links) == 0 {
+			return
+		}
+	}
+
+	permLvl, err := l.cmdHandler.GetPermissionLevel(s, msg.GuildID, msg.Author.ID)
+	if err != nil {
+		util.Log.Errorf("Failed getting permission level of member '%s' on guild '%s': %s",
+			msg.Author.ID, msg.GuildID, err.Error())
+		return
+	}
+	if permLvl >= settings.BypassPermLvl {
+		return
+	}
+
+	if l.isBlocked(links, settings) {
+		l.detected(s, msg)
+	}
+}
+
+// isBlocked returns true if either the host of any of the
+// passed links or the host of a link's redirect target is
+// not allowed by the link filter settings. Links are resolved
+// concurrently and links which could not be resolved within
+// linkFilterResolveTimeout are not blocked.
+func (l *ListenerLinkFilter) isBlocked(links []string, settings *util.LinkFilterSettings) bool {
+	hosts := make(map[string]string)
+	for _, link := range links {
+		u, err := url.Parse(withScheme(link))
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		if !settings.IsAllowed(u.Hostname()) {
+			return true
+		}
+		hosts[link] = u.Hostname()
+	}
+
+	if len(hosts) == 0 {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), linkFilterResolveTimeout)
+	defer cancel()
+
+	blocked := make(chan bool, len(hosts))
+	for link, host := range hosts {
+		go func(link, host string) {
+			resolved, err := resolveLink(ctx, link)
+			blocked <- err == nil && resolved.Hostname() != host && !settings.IsAllowed(resolved.Hostname())
+		}(link, host)
+	}
+
+	for range hosts {
+		select {
+		case b := <-blocked:
+			if b {
+				return true
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return false
+}
+
+func (l *ListenerLinkFilter) detected(s *discordgo.Session, msg *discordgo.Message) {
+	if err := s.ChannelMessageDelete(msg.ChannelID, msg.ID); err != nil {
+		util.Log.Errorf("Failed deleting filtered message '%s': %s", msg.ID, err.Error())
+		return
+	}
+
+	notice, err := util.SendEmbedError(s, msg.ChannelID,
+		msg.Author.Mention()+", your message was removed because it contained a link which is not allowed on this guild.")
+	if err == nil {
+		util.DeleteMessageLater(s, notice, 8*time.Second)
+	}
+}
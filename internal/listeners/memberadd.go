@@ -1,7 +1,10 @@
 package listeners
 
 import (
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/zekroTJA/shinpuru/internal/core"
@@ -10,15 +13,30 @@ import (
 
 type ListenerMemberAdd struct {
 	db core.Database
+
+	mtx   sync.Mutex
+	joins map[string][]*raidJoin
+}
+
+type raidJoin struct {
+	userID string
+	time   time.Time
 }
 
 func NewListenerMemberAdd(db core.Database) *ListenerMemberAdd {
 	return &ListenerMemberAdd{
-		db: db,
+		db:    db,
+		joins: make(map[string][]*raidJoin),
 	}
 }
 
 func (l *ListenerMemberAdd) Handler(s *discordgo.Session, e *discordgo.GuildMemberAdd) {
+	if l.checkRaid(s, e) {
+		return
+	}
+
+	l.restoreStickyRoles(s, e)
+
 	autoRoleID, err := l.db.GetGuildAutoRole(e.GuildID)
 	if err != nil && !core.IsErrDatabaseNotFound(err) {
 		util.Log.Errorf("Failed getting autorole for guild '%s' from database: %s", e.GuildID, err.Error())
@@ -39,3 +57,168 @@ func (l *ListenerMemberAdd) Handler(s *discordgo.Session, e *discordgo.GuildMemb
 		util.SendEmbed(s, chanID, msg, "", 0)
 	}
 }
+
+// restoreStickyRoles reapplies the roles which were saved
+// when the member left the guild and sends a note into the
+// guild's mod log channel.
+func (l *ListenerMemberAdd) restoreStickyRoles(s *discordgo.Session, e *discordgo.GuildMemberAdd) {
+	roleIDs, err := l.db.GetMemberStickyRoles(e.GuildID, e.User.ID)
+	if err != nil {
+		if !core.IsErrDatabaseNotFound(err) {
+			util.Log.Errorf("Failed getting sticky roles of member '%s': %s", e.User.ID, err.Error())
+		}
+		return
+	}
+
+	guild, err := s.State.Guild(e.GuildID)
+	if err != nil {
+		return
+	}
+
+	restored := make([]string, 0, len(roleIDs))
+	for _, r := range guild.Roles {
+		if r.Managed || r.ID == guild.ID || util.IndexOfStrArray(r.ID, roleIDs) < 0 {
+			continue
+		}
+		if err = s.GuildMemberRoleAdd(e.GuildID, e.User.ID, r.ID); err != nil {
+			util.Log.Errorf("Failed restoring role '%s' of member '%s': %s", r.ID, e.User.ID, err.Error())
+			continue
+		}
+		restored = append(restored, r.ID)
+	}
+
+	if err = l.db.SetMemberStickyRoles(e.GuildID, e.User.ID, nil); err != nil {
+		util.Log.Errorf("Failed deleting sticky roles of member '%s': %s", e.User.ID, err.Error())
+	}
+
+	if len(restored) == 0 {
+		return
+	}
+
+	modlogChan, err := l.db.GetGuildModLog(e.GuildID)
+	if err != nil || modlogChan == "" {
+		return
+	}
+
+	s.ChannelMessageSendEmbed(modlogChan, &discordgo.MessageEmbed{
+		Color: util.ColorEmbedGray,
+		Title: "Sticky Roles Restored",
+		Description: fmt.Sprintf("%s rejoined the guild and got the following roles restored:\n%s",
+			e.User.Mention(), roleMentions(restored)),
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// checkRaid tracks the join rate of fresh accounts and
+// enables the raid mode if the set limit is exceeded.
+// While the raid mode is active, the anti raid action is
+// applied to all fresh accounts joining the guild.
+// Returns true if the joined member was handled.
+func (l *ListenerMemberAdd) checkRaid(s *discordgo.Session, e *discordgo.GuildMemberAdd) bool {
+	settings, err := l.db.GetGuildAntiRaid(e.GuildID)
+	if err != nil {
+		if !core.IsErrDatabaseNotFound(err) {
+			util.Log.Errorf("Failed getting anti raid settings for guild '%s': %s", e.GuildID, err.Error())
+		}
+		return false
+	}
+	if settings == nil || !settings.IsFreshAccount(e.User.ID) {
+		return false
+	}
+
+	raidMode, _, err := l.db.GetGuildRaidMode(e.GuildID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		util.Log.Errorf("Failed getting raid mode for guild '%s': %s", e.GuildID, err.Error())
+	}
+	if raidMode {
+		l.applyRaidAction(s, e.GuildID, e.User.ID, settings)
+		return true
+	}
+
+	if settings.JoinLimit <= 0 {
+		return false
+	}
+
+	now := time.Now()
+
+	l.mtx.Lock()
+	joins := make([]*raidJoin, 0, len(l.joins[e.GuildID])+1)
+	for _, j := range l.joins[e.GuildID] {
+		if now.Sub(j.time) <= settings.GetJoinInterval() {
+			joins = append(joins, j)
+		}
+	}
+	joins = append(joins, &raidJoin{e.User.ID, now})
+
+	triggered := len(joins) > settings.JoinLimit
+	if triggered {
+		delete(l.joins, e.GuildID)
+	} else {
+		l.joins[e.GuildID] = joins
+	}
+	l.mtx.Unlock()
+
+	if !triggered {
+		return false
+	}
+
+	if err = l.enableRaidMode(s, e.GuildID, settings, len(joins)); err != nil {
+		util.Log.Errorf("Failed enabling raid mode on guild '%s': %s", e.GuildID, err.Error())
+	}
+
+	for _, j := range joins {
+		l.applyRaidAction(s, e.GuildID, j.userID, settings)
+	}
+
+	return true
+}
+
+func (l *ListenerMemberAdd) enableRaidMode(s *discordgo.Session, guildID string, settings *util.AntiRaidSettings, nJoins int) error {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		if guild, err = s.Guild(guildID); err != nil {
+			return err
+		}
+	}
+
+	if err = l.db.SetGuildRaidMode(guildID, true, int(guild.VerificationLevel)); err != nil {
+		return err
+	}
+
+	if guild.VerificationLevel < discordgo.VerificationLevelHigh {
+		lvl := discordgo.VerificationLevelHigh
+		if _, err = s.GuildEdit(guildID, discordgo.GuildParams{VerificationLevel: &lvl}); err != nil {
+			util.Log.Errorf("Failed raising verification level on guild '%s': %s", guildID, err.Error())
+		}
+	}
+
+	modlogChan, err := l.db.GetGuildModLog(guildID)
+	if err != nil || modlogChan == "" {
+		return nil
+	}
+
+	_, err = s.ChannelMessageSendEmbed(modlogChan, &discordgo.MessageEmbed{
+		Color: util.ColorEmbedError,
+		Title: "Raid Detected",
+		Description: fmt.Sprintf("`%d` fresh accounts joined within `%d` seconds, so the **raid mode** was enabled.\n\n"+
+			"The verification level was raised to *high* and the anti raid action `%s` will be applied "+
+			"to all new accounts joining until the raid mode is ended with the command `antiraid end`.",
+			nJoins, settings.JoinInterval, settings.Action),
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+
+	return err
+}
+
+func (l *ListenerMemberAdd) applyRaidAction(s *discordgo.Session, guildID, userID string, settings *util.AntiRaidSettings) {
+	var err error
+	if settings.Action == util.AntiRaidActionQuarantine && settings.QuarantineRoleID != "" {
+		err = s.GuildMemberRoleAdd(guildID, userID, settings.QuarantineRoleID)
+	} else {
+		err = s.GuildMemberDeleteWithReason(guildID, userID, "[ANTI RAID] Joined during raid mode.")
+	}
+
+	if err != nil {
+		util.Log.Errorf("Failed applying anti raid action to member '%s' on guild '%s': %s", userID, guildID, err.Error())
+	}
+}
@@ -0,0 +1,250 @@
+package listeners
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type ListenerMemberLog struct {
+	db          core.Database
+	memberCache *util.MemberCache
+}
+
+func NewListenerMemberLog(db core.Database, memberCache *util.MemberCache) *ListenerMemberLog {
+	return &ListenerMemberLog{
+		db:          db,
+		memberCache: memberCache,
+	}
+}
+
+func (l *ListenerMemberLog) HandlerGuildCreate(s *discordgo.Session, e *discordgo.GuildCreate) {
+	for _, m := range e.Members {
+		l.memberCache.Set(e.ID, m)
+	}
+
+	// Large guilds only contain a part of their members
+	// on guild create, so the rest is requested and
+	// received as member chunks.
+	if e.Large || e.MemberCount > len(e.Members) {
+		if err := s.RequestGuildMembers(e.ID, "", 0); err != nil {
+			util.Log.Errorf("Failed requesting members of guild '%s': %s", e.ID, err.Error())
+		}
+	}
+}
+
+func (l *ListenerMemberLog) HandlerGuildMembersChunk(s *discordgo.Session, e *discordgo.GuildMembersChunk) {
+	for _, m := range e.Members {
+		l.memberCache.Set(e.GuildID, m)
+	}
+}
+
+func (l *ListenerMemberLog) HandlerGuildDelete(s *discordgo.Session, e *discordgo.GuildDelete) {
+	// Guilds which are only unavailable are
+	// received again on guild create.
+	if !e.Unavailable {
+		l.memberCache.RemoveGuild(e.ID)
+	}
+}
+
+func (l *ListenerMemberLog) HandlerMemberAdd(s *discordgo.Session, e *discordgo.GuildMemberAdd) {
+	l.memberCache.Set(e.GuildID, e.Member)
+
+	logChan := l.getLogChannel(e.GuildID, util.MemberLogEventJoin)
+	if logChan == "" {
+		return
+	}
+
+	ageTxt := "unknown"
+	if created, err := util.GetDiscordSnowflakeCreationTime(e.User.ID); err == nil {
+		ageTxt = fmt.Sprintf("%s *(%s)*", formatAge(time.Since(created)), created.Format(time.RFC1123))
+	}
+
+	l.send(s, logChan, e.User, util.ColorEmbedGreen, "Member Joined",
+		fmt.Sprintf("%s joined the guild.\n\n**Account age:** %s", e.User.Mention(), ageTxt))
+}
+
+func (l *ListenerMemberLog) HandlerMemberRemove(s *discordgo.Session, e *discordgo.GuildMemberRemove) {
+	if e.User.ID == s.State.User.ID ||
+		l.getLogChannel(e.GuildID, util.MemberLogEventLeave|util.MemberLogEventKick) == "" {
+		return
+	}
+
+	// Give Discord some time to create the audit log
+	// entry if the member was kicked or banned.
+	time.Sleep(auditLogDelay)
+
+	// Bans are logged by the ban add handler.
+	if _, _, ok := findAuditLogEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberBanAdd); ok {
+		return
+	}
+
+	if executorID, reason, ok := findAuditLogEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberKick); ok {
+		logChan := l.getLogChannel(e.GuildID, util.MemberLogEventKick)
+		if logChan == "" {
+			return
+		}
+		l.send(s, logChan, e.User, util.ColorEmbedOrange, "Member Kicked",
+			fmt.Sprintf("%s was kicked by <@%s>.\n\n**Reason:** %s",
+				e.User.Mention(), executorID, util.EnsureNotEmpty(reason, "*no reason specified*")))
+		return
+	}
+
+	logChan := l.getLogChannel(e.GuildID, util.MemberLogEventLeave)
+	if logChan == "" {
+		return
+	}
+
+	l.send(s, logChan, e.User, util.ColorEmbedGray, "Member Left",
+		fmt.Sprintf("%s left the guild.", e.User.Mention()))
+}
+
+func (l *ListenerMemberLog) HandlerBanAdd(s *discordgo.Session, e *discordgo.GuildBanAdd) {
+	logChan := l.getLogChannel(e.GuildID, util.MemberLogEventBan)
+	if logChan == "" {
+		return
+	}
+
+	time.Sleep(auditLogDelay)
+
+	desc := fmt.Sprintf("%s was banned.", e.User.Mention())
+	if executorID, reason, ok := findAuditLogEntry(s, e.GuildID, e.User.ID, discordgo.AuditLogActionMemberBanAdd); ok {
+		desc = fmt.Sprintf("%s was banned by <@%s>.\n\n**Reason:** %s",
+			e.User.Mention(), executorID, util.EnsureNotEmpty(reason, "*no reason specified*"))
+	}
+
+	l.send(s, logChan, e.User, util.ColorEmbedError, "Member Banned", desc)
+}
+
+func (l *ListenerMemberLog) HandlerMemberUpdate(s *discordgo.Session, e *discordgo.GuildMemberUpdate) {
+	if e.Member == nil || e.User == nil {
+		return
+	}
+
+	old := l.memberCache.Get(e.GuildID, e.User.ID)
+	l.memberCache.Set(e.GuildID, e.Member)
+
+	if old == nil {
+		return
+	}
+
+	if old.Nick != e.Nick {
+		if logChan := l.getLogChannel(e.GuildID, util.MemberLogEventNick); logChan != "" {
+			l.send(s, logChan, e.User, util.ColorEmbedCyan, "Nickname Changed",
+				fmt.Sprintf("Nickname of %s changed.\n\n**Before:** %s\n**After:** %s",
+					e.User.Mention(), util.EnsureNotEmpty(old.Nick, "*none*"), util.EnsureNotEmpty(e.Nick, "*none*")))
+		}
+	}
+
+	added, removed := diffIDLists(old.Roles, e.Roles)
+	if len(added) > 0 || len(removed) > 0 {
+		if logChan := l.getLogChannel(e.GuildID, util.MemberLogEventRoles); logChan != "" {
+			var desc strings.Builder
+			desc.WriteString(fmt.Sprintf("Roles of %s changed.\n", e.User.Mention()))
+			if len(added) > 0 {
+				desc.WriteString("\n**Added:** " + roleMentions(added))
+			}
+			if len(removed) > 0 {
+				desc.WriteString("\n**Removed:** " + roleMentions(removed))
+			}
+			l.send(s, logChan, e.User, util.ColorEmbedViolett, "Roles Changed", desc.String())
+		}
+	}
+
+	if old.User.Username != e.User.Username || old.User.Discriminator != e.User.Discriminator || old.User.Avatar != e.User.Avatar {
+		if logChan := l.getLogChannel(e.GuildID, util.MemberLogEventUser); logChan != "" {
+			var desc strings.Builder
+			desc.WriteString(fmt.Sprintf("User profile of %s changed.\n", e.User.Mention()))
+			if old.User.String() != e.User.String() {
+				desc.WriteString(fmt.Sprintf("\n**Username before:** %s\n**Username after:** %s", old.User.String(), e.User.String()))
+			}
+			if old.User.Avatar != e.User.Avatar {
+				desc.WriteString(fmt.Sprintf("\n**Avatar before:** [link](%s)\n**Avatar after:** [link](%s)",
+					old.User.AvatarURL(""), e.User.AvatarURL("")))
+			}
+			l.send(s, logChan, e.User, util.ColorEmbedYellow, "User Updated", desc.String())
+		}
+	}
+}
+
+// getLogChannel returns the ID of the member log channel
+// of the guild or an empty string, if no member log channel
+// is set or the passed event is disabled.
+func (l *ListenerMemberLog) getLogChannel(guildID string, event int) string {
+	logChan, err := l.db.GetGuildMemberLog(guildID)
+	if err != nil {
+		if !core.IsErrDatabaseNotFound(err) {
+			util.Log.Errorf("failed getting member log channel for guild %s: %s", guildID, err.Error())
+		}
+		return ""
+	}
+	if logChan == "" {
+		return ""
+	}
+
+	events, err := l.db.GetGuildMemberLogEvents(guildID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		util.Log.Errorf("failed getting member log events for guild %s: %s", guildID, err.Error())
+		return ""
+	}
+	if events&event == 0 {
+		return ""
+	}
+
+	return logChan
+}
+
+func (l *ListenerMemberLog) send(s *discordgo.Session, chanID string, user *discordgo.User, color int, title, desc string) {
+	_, err := s.ChannelMessageSendEmbed(chanID, &discordgo.MessageEmbed{
+		Color:       color,
+		Title:       title,
+		Description: desc,
+		Author:      msgLogEmbedAuthor(user),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "User ID: " + user.ID,
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		util.Log.Errorf("failed sending member log message to channel %s: %s", chanID, err.Error())
+	}
+}
+
+func diffIDLists(oldIDs, newIDs []string) (added, removed []string) {
+	for _, id := range newIDs {
+		if util.IndexOfStrArray(id, oldIDs) < 0 {
+			added = append(added, id)
+		}
+	}
+	for _, id := range oldIDs {
+		if util.IndexOfStrArray(id, newIDs) < 0 {
+			removed = append(removed, id)
+		}
+	}
+	return
+}
+
+func roleMentions(roleIDs []string) string {
+	mentions := make([]string, len(roleIDs))
+	for i, id := range roleIDs {
+		mentions[i] = "<@&" + id + ">"
+	}
+	return strings.Join(mentions, ", ")
+}
+
+func formatAge(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	if days > 0 {
+		return fmt.Sprintf("%d days", days)
+	}
+	hours := int(d.Hours())
+	if hours > 0 {
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return fmt.Sprintf("%d minutes", int(d.Minutes()))
+}
@@ -9,16 +9,21 @@ import (
 )
 
 type ListenerMemberRemove struct {
-	db core.Database
+	db          core.Database
+	memberCache *util.MemberCache
 }
 
-func NewListenerMemberRemove(db core.Database) *ListenerMemberRemove {
+func NewListenerMemberRemove(db core.Database, memberCache *util.MemberCache) *ListenerMemberRemove {
 	return &ListenerMemberRemove{
-		db: db,
+		db:          db,
+		memberCache: memberCache,
 	}
 }
 
 func (l *ListenerMemberRemove) Handler(s *discordgo.Session, e *discordgo.GuildMemberRemove) {
+	l.saveStickyRoles(e.GuildID, e.User.ID)
+	l.memberCache.Remove(e.GuildID, e.User.ID)
+
 	chanID, msg, err := l.db.GetGuildLeaveMsg(e.GuildID)
 	if err == nil && msg != "" && chanID != "" {
 		msg = strings.Replace(msg, "[user]", e.User.Username, -1)
@@ -26,3 +31,40 @@ func (l *ListenerMemberRemove) Handler(s *discordgo.Session, e *discordgo.GuildM
 		util.SendEmbed(s, chanID, msg, "", 0)
 	}
 }
+
+// saveStickyRoles saves the sticky roles of the departing
+// member from the member cache so that they can be
+// reapplied when the member rejoins the guild.
+func (l *ListenerMemberRemove) saveStickyRoles(guildID, userID string) {
+	member := l.memberCache.Get(guildID, userID)
+	if member == nil || len(member.Roles) == 0 {
+		return
+	}
+
+	settings, err := l.db.GetGuildStickyRoles(guildID)
+	if core.IsErrDatabaseNotFound(err) {
+		settings, err = util.NewDefaultStickyRoleSettings(), nil
+	}
+	if err != nil {
+		util.Log.Errorf("Failed getting sticky roles settings for guild '%s': %s", guildID, err.Error())
+		return
+	}
+
+	stickyRoles := settings.RoleIDs
+	if settings.StickyMute {
+		if muteRoleID, err := l.db.GetMuteRoleGuild(guildID); err == nil && muteRoleID != "" {
+			stickyRoles = append(stickyRoles, muteRoleID)
+		}
+	}
+
+	roleIDs := make([]string, 0)
+	for _, rID := range member.Roles {
+		if settings.RestoreAll || util.IndexOfStrArray(rID, stickyRoles) > -1 {
+			roleIDs = append(roleIDs, rID)
+		}
+	}
+
+	if err = l.db.SetMemberStickyRoles(guildID, userID, roleIDs); err != nil {
+		util.Log.Errorf("Failed saving sticky roles of member '%s': %s", userID, err.Error())
+	}
+}
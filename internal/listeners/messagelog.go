@@ -0,0 +1,249 @@
+package listeners
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type ListenerMessageLog struct {
+	db       core.Database
+	msgCache *util.MessageCache
+}
+
+func NewListenerMessageLog(db core.Database, msgCache *util.MessageCache) *ListenerMessageLog {
+	return &ListenerMessageLog{
+		db:       db,
+		msgCache: msgCache,
+	}
+}
+
+func (l *ListenerMessageLog) HandlerMessageCreate(s *discordgo.Session, e *discordgo.MessageCreate) {
+	if e.GuildID == "" || e.Author == nil || e.Author.ID == s.State.User.ID {
+		return
+	}
+
+	l.msgCache.Set(e.Message)
+}
+
+func (l *ListenerMessageLog) HandlerMessageEdit(s *discordgo.Session, e *discordgo.MessageUpdate) {
+	// Updates without author are sent when embeds
+	// of a message were resolved and can be ignored.
+	if e.Author == nil || e.Author.ID == s.State.User.ID {
+		return
+	}
+
+	oldMsg := l.msgCache.Get(e.ID)
+
+	newMsg := e.Message
+	if oldMsg != nil {
+		updated := *oldMsg
+		updated.Content = e.Content
+		updated.Mentions = e.Mentions
+		updated.Attachments = e.Attachments
+		updated.EditedTimestamp = e.EditedTimestamp
+		newMsg = &updated
+	}
+	l.msgCache.Set(newMsg)
+
+	if oldMsg != nil && oldMsg.Content == newMsg.Content {
+		return
+	}
+
+	guildID := newMsg.GuildID
+	if guildID == "" {
+		ch, err := s.State.Channel(e.ChannelID)
+		if err != nil {
+			return
+		}
+		guildID = ch.GuildID
+	}
+
+	logChan := l.getLogChannel(guildID, e.ChannelID, e.Author.ID)
+	if logChan == "" {
+		return
+	}
+
+	before := "*message not cached*"
+	if oldMsg != nil {
+		before = contentOrPlaceholder(oldMsg.Content)
+	}
+
+	s.ChannelMessageSendEmbed(logChan, &discordgo.MessageEmbed{
+		Color: util.ColorEmbedCyan,
+		Title: "Message Edited",
+		Description: fmt.Sprintf("Message by %s edited in <#%s>. [Jump to message](https://discordapp.com/channels/%s/%s/%s)",
+			e.Author.Mention(), e.ChannelID, guildID, e.ChannelID, e.ID),
+		Author: msgLogEmbedAuthor(e.Author),
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Before",
+				Value: truncate(before, 1024),
+			},
+			&discordgo.MessageEmbedField{
+				Name:  "After",
+				Value: truncate(contentOrPlaceholder(newMsg.Content), 1024),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Message ID: " + e.ID,
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+func (l *ListenerMessageLog) HandlerMessageDelete(s *discordgo.Session, e *discordgo.MessageDelete) {
+	msg := l.msgCache.Get(e.ID)
+	if msg == nil {
+		return
+	}
+
+	if msg.Author == nil || msg.Author.ID == s.State.User.ID {
+		return
+	}
+
+	logChan := l.getLogChannel(msg.GuildID, msg.ChannelID, msg.Author.ID)
+	if logChan == "" {
+		return
+	}
+
+	emb := &discordgo.MessageEmbed{
+		Color:       util.ColorEmbedOrange,
+		Title:       "Message Deleted",
+		Description: fmt.Sprintf("Message by %s deleted in <#%s>.", msg.Author.Mention(), msg.ChannelID),
+		Author:      msgLogEmbedAuthor(msg.Author),
+		Fields: []*discordgo.MessageEmbedField{
+			&discordgo.MessageEmbedField{
+				Name:  "Content",
+				Value: truncate(contentOrPlaceholder(msg.Content), 1024),
+			},
+		},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Message ID: " + msg.ID,
+		},
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	if len(msg.Attachments) > 0 {
+		attachments := make([]string, len(msg.Attachments))
+		for i, att := range msg.Attachments {
+			attachments[i] = fmt.Sprintf("[%s](%s)", att.Filename, att.ProxyURL)
+		}
+		emb.Fields = append(emb.Fields, &discordgo.MessageEmbedField{
+			Name:  "Attachments",
+			Value: truncate(strings.Join(attachments, "\n"), 1024),
+		})
+	}
+
+	s.ChannelMessageSendEmbed(logChan, emb)
+}
+
+func (l *ListenerMessageLog) HandlerMessageDeleteBulk(s *discordgo.Session, e *discordgo.MessageDeleteBulk) {
+	logChan := l.getLogChannel(e.GuildID, e.ChannelID, "")
+	if logChan == "" {
+		return
+	}
+
+	_, ignoredUsers, err := l.db.GetGuildMessageLogIgnores(e.GuildID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		util.Log.Errorf("failed getting message log ignores for guild %s: %s", e.GuildID, err.Error())
+	}
+
+	msgs := make([]*discordgo.Message, 0, len(e.Messages))
+	for _, id := range e.Messages {
+		msg := l.msgCache.Get(id)
+		if msg == nil || (msg.Author != nil && util.IndexOfStrArray(msg.Author.ID, ignoredUsers) > -1) {
+			continue
+		}
+		msgs = append(msgs, msg)
+	}
+
+	sort.Slice(msgs, func(i, j int) bool {
+		if len(msgs[i].ID) != len(msgs[j].ID) {
+			return len(msgs[i].ID) < len(msgs[j].ID)
+		}
+		return msgs[i].ID < msgs[j].ID
+	})
+
+	msgSend := &discordgo.MessageSend{
+		Embed: &discordgo.MessageEmbed{
+			Color: util.ColorEmbedOrange,
+			Title: "Messages Bulk Deleted",
+			Description: fmt.Sprintf("`%d` messages were deleted in <#%s>, `%d` of them were cached.",
+				len(e.Messages), e.ChannelID, len(msgs)),
+			Timestamp: time.Now().Format(time.RFC3339),
+		},
+	}
+
+	if len(msgs) > 0 {
+		msgSend.Files = []*discordgo.File{
+			&discordgo.File{
+				Name:        fmt.Sprintf("deleted-messages-%s.txt", e.ChannelID),
+				ContentType: "text/plain",
+				Reader:      strings.NewReader(util.MessagesTranscript(msgs)),
+			},
+		}
+	}
+
+	s.ChannelMessageSendComplex(logChan, msgSend)
+}
+
+// getLogChannel returns the ID of the message log channel
+// of the guild or an empty string, if no message log channel
+// is set or the channel or user is on the ignore list.
+func (l *ListenerMessageLog) getLogChannel(guildID, channelID, userID string) string {
+	if guildID == "" {
+		return ""
+	}
+
+	logChan, err := l.db.GetGuildMessageLog(guildID)
+	if err != nil {
+		if !core.IsErrDatabaseNotFound(err) {
+			util.Log.Errorf("failed getting message log channel for guild %s: %s", guildID, err.Error())
+		}
+		return ""
+	}
+	if logChan == "" || logChan == channelID {
+		return ""
+	}
+
+	ignoredChans, ignoredUsers, err := l.db.GetGuildMessageLogIgnores(guildID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		util.Log.Errorf("failed getting message log ignores for guild %s: %s", guildID, err.Error())
+		return ""
+	}
+	if util.IndexOfStrArray(channelID, ignoredChans) > -1 ||
+		(userID != "" && util.IndexOfStrArray(userID, ignoredUsers) > -1) {
+		return ""
+	}
+
+	return logChan
+}
+
+func msgLogEmbedAuthor(user *discordgo.User) *discordgo.MessageEmbedAuthor {
+	return &discordgo.MessageEmbedAuthor{
+		Name:    user.String(),
+		IconURL: user.AvatarURL("16x16"),
+	}
+}
+
+func contentOrPlaceholder(content string) string {
+	if content == "" {
+		return "*no text content*"
+	}
+	return content
+}
+
+func truncate(str string, maxLen int) string {
+	runes := []rune(str)
+	if len(runes) <= maxLen {
+		return str
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
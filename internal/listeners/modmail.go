@@ -0,0 +1,180 @@
+package listeners
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+	"github.com/zekroTJA/timedmap"
+)
+
+const modmailPendingLifetime = 5 * time.Minute
+
+type modmailPending struct {
+	guildIDs []string
+	msg      *discordgo.Message
+}
+
+type ListenerModmail struct {
+	db      core.Database
+	pending *timedmap.TimedMap
+	locks   *util.KeyedLocks
+}
+
+func NewListenerModmail(db core.Database) *ListenerModmail {
+	return &ListenerModmail{
+		db:      db,
+		pending: timedmap.New(time.Minute),
+		locks:   util.NewKeyedLocks(),
+	}
+}
+
+func (l *ListenerModmail) HandlerMessageCreate(s *discordgo.Session, e *discordgo.MessageCreate) {
+	if e.Author == nil || e.Author.Bot || e.GuildID != "" {
+		return
+	}
+
+	// Messages are handled one by one per user so that
+	// quickly sent messages do not open multiple threads.
+	unlock := l.locks.Lock(e.Author.ID)
+	defer unlock()
+
+	thread, err := l.db.GetModmailOpenThread(e.Author.ID)
+	if err != nil && !core.IsErrDatabaseNotFound(err) {
+		util.Log.Errorf("Failed getting modmail thread of user '%s': %s", e.Author.ID, err.Error())
+		return
+	}
+
+	if thread != nil && l.relay(s, thread, e.Message) {
+		return
+	}
+
+	var guildIDs []string
+	if pending, ok := l.pending.GetValue(e.Author.ID).(*modmailPending); ok {
+		if i, err := strconv.Atoi(strings.TrimSpace(e.Content)); err == nil && i > 0 && i <= len(pending.guildIDs) {
+			l.pending.Remove(e.Author.ID)
+			l.open(s, pending.guildIDs[i-1], pending.msg)
+			return
+		}
+		guildIDs = pending.guildIDs
+	} else {
+		guildIDs = l.getModmailGuilds(s, e.Author.ID)
+	}
+
+	switch len(guildIDs) {
+	case 0:
+		util.SendEmbedError(s, e.ChannelID,
+			"There is no guild you share with me which has modmail enabled.")
+	case 1:
+		l.open(s, guildIDs[0], e.Message)
+	default:
+		l.pending.Set(e.Author.ID, &modmailPending{guildIDs, e.Message}, modmailPendingLifetime)
+		lines := make([]string, len(guildIDs))
+		for i, guildID := range guildIDs {
+			lines[i] = fmt.Sprintf("`%d` - %s", i+1, l.guildName(s, guildID))
+		}
+		util.SendEmbed(s, e.ChannelID,
+			"To which guild's staff do you want to send your message? Please answer with the number of the guild.\n\n"+
+				strings.Join(lines, "\n"), "Modmail", 0)
+	}
+}
+
+func (l *ListenerModmail) open(s *discordgo.Session, guildID string, msg *discordgo.Message) {
+	thread, err := core.ModmailOpenThread(s, l.db, guildID, msg.Author)
+	if err != nil {
+		util.Log.Errorf("Failed opening modmail thread on guild '%s': %s", guildID, err.Error())
+		util.SendEmbedError(s, msg.ChannelID,
+			"Sorry, the modmail thread could not be opened. Please try again later.")
+		return
+	}
+
+	util.SendEmbed(s, msg.ChannelID,
+		fmt.Sprintf("Your message was sent to the staff of **%s**. All further messages you send "+
+			"me will be forwarded to them until the thread is closed.", l.guildName(s, guildID)),
+		"Modmail", util.ColorEmbedGreen)
+
+	l.relay(s, thread, msg)
+}
+
+// relay forwards the message to the thread channel. If the
+// thread channel does not exist anymore, the thread is closed
+// and false is returned.
+func (l *ListenerModmail) relay(s *discordgo.Session, thread *core.ModmailThread, msg *discordgo.Message) bool {
+	err := core.ModmailRelayToThread(s, l.db, thread, msg)
+	if core.IsErrUnknownChannel(err) {
+		l.closeDeleted(s, thread)
+		return false
+	}
+	if err != nil {
+		util.Log.Errorf("Failed relaying modmail message to thread '%s': %s", thread.ID, err.Error())
+		util.SendEmbedError(s, msg.ChannelID,
+			"Sorry, your message could not be delivered to the staff.")
+		return true
+	}
+
+	s.MessageReactionAdd(msg.ChannelID, msg.ID, "✅")
+	return true
+}
+
+// HandlerChannelDelete closes the modmail thread of
+// channels which were deleted manually.
+func (l *ListenerModmail) HandlerChannelDelete(s *discordgo.Session, e *discordgo.ChannelDelete) {
+	if e.GuildID == "" {
+		return
+	}
+
+	thread, err := l.db.GetModmailThreadByChannel(e.ID)
+	if err != nil {
+		return
+	}
+
+	unlock := l.locks.Lock(thread.UserID)
+	defer unlock()
+
+	// The thread is read again because it might have been
+	// closed while waiting for the lock.
+	thread, err = l.db.GetModmailOpenThread(thread.UserID)
+	if err != nil || thread.ChannelID != e.ID {
+		return
+	}
+
+	l.closeDeleted(s, thread)
+}
+
+func (l *ListenerModmail) closeDeleted(s *discordgo.Session, thread *core.ModmailThread) {
+	if err := core.ModmailCloseDeletedThread(s, l.db, thread); err != nil {
+		util.Log.Errorf("Failed closing modmail thread '%s' of deleted channel: %s", thread.ID, err.Error())
+	}
+}
+
+// getModmailGuilds returns the IDs of all guilds the user
+// is member of which have modmail enabled. Only the state
+// is used because requesting the member of each guild
+// would quickly exceed the rate limits.
+func (l *ListenerModmail) getModmailGuilds(s *discordgo.Session, userID string) []string {
+	guildIDs := make([]string, 0)
+
+	for _, g := range s.State.Guilds {
+		settings, err := l.db.GetGuildModmail(g.ID)
+		if err != nil || !settings.IsEnabled() {
+			continue
+		}
+		if _, err = s.State.Member(g.ID, userID); err != nil {
+			continue
+		}
+		guildIDs = append(guildIDs, g.ID)
+	}
+
+	return guildIDs
+}
+
+func (l *ListenerModmail) guildName(s *discordgo.Session, guildID string) string {
+	if g, err := s.State.Guild(guildID); err == nil {
+		return g.Name
+	}
+	return guildID
+}
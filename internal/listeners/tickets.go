@@ -0,0 +1,52 @@
+package listeners
+
+import (
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/shinpuru/internal/core"
+	"github.com/zekroTJA/shinpuru/internal/util"
+)
+
+type ListenerTickets struct {
+	db core.Database
+}
+
+func NewListenerTickets(db core.Database) *ListenerTickets {
+	return &ListenerTickets{
+		db: db,
+	}
+}
+
+func (l *ListenerTickets) HandlerMessageReactionAdd(s *discordgo.Session, e *discordgo.MessageReactionAdd) {
+	if e.GuildID == "" || e.UserID == s.State.User.ID || e.Emoji.Name != core.TicketEmoji {
+		return
+	}
+
+	settings, err := l.db.GetGuildTickets(e.GuildID)
+	if err != nil || !settings.IsEnabled() || settings.PanelMessageID != e.MessageID {
+		return
+	}
+
+	s.MessageReactionRemove(e.ChannelID, e.MessageID, e.Emoji.APIName(), e.UserID)
+
+	user, err := s.User(e.UserID)
+	if err != nil {
+		return
+	}
+
+	ticket, err := core.TicketOpen(s, l.db, e.GuildID, user)
+
+	var dmText string
+	switch err {
+	case nil:
+		dmText = "Your ticket was opened: <#" + ticket.ChannelID + ">"
+	case core.ErrTicketLimitReached:
+		dmText = "You have reached the limit of open tickets on this guild. Please close your open tickets first."
+	default:
+		util.Log.Errorf("Failed opening ticket on guild '%s': %s", e.GuildID, err.Error())
+		dmText = "Sorry, your ticket could not be opened. Please try again later."
+	}
+
+	if dmChan, err := s.UserChannelCreate(e.UserID); err == nil {
+		util.SendEmbed(s, dmChan.ID, dmText, "Tickets", 0)
+	}
+}
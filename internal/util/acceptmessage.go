@@ -19,7 +19,14 @@ type AcceptMessage struct {
 	DeleteMsgAfter bool
 	AcceptFunc     func(*discordgo.Message)
 	DeclineFunc    func(*discordgo.Message)
-	eventUnsub     func()
+	// UserFilter, if set, is called with the ID of
+	// the reacting user. The reaction is ignored if
+	// it returns false.
+	UserFilter func(userID string) bool
+	// ReactorID is the ID of the user who accepted
+	// or declined the message.
+	ReactorID  string
+	eventUnsub func()
 }
 
 func (am *AcceptMessage) Send(chanID string) (*AcceptMessage, error) {
@@ -52,6 +59,12 @@ func (am *AcceptMessage) Send(chanID string) (*AcceptMessage, error) {
 		if e.Emoji.Name != acceptMessageEmoteAccept && e.Emoji.Name != acceptMessageEmoteDecline {
 			return
 		}
+
+		if am.UserFilter != nil && !am.UserFilter(e.UserID) {
+			return
+		}
+
+		am.ReactorID = e.UserID
 		switch e.Emoji.Name {
 		case acceptMessageEmoteAccept:
 			if am.AcceptFunc != nil {
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AntiRaidActionKick       = "kick"
+	AntiRaidActionQuarantine = "quarantine"
+)
+
+type AntiRaidSettings struct {
+	JoinLimit        int    `json:"join_limit"`
+	JoinInterval     int    `json:"join_interval"`
+	MinAccountAge    int    `json:"min_account_age"`
+	Action           string `json:"action"`
+	QuarantineRoleID string `json:"quarantine_role_id"`
+}
+
+func NewDefaultAntiRaidSettings() *AntiRaidSettings {
+	return &AntiRaidSettings{
+		JoinLimit:     10,
+		JoinInterval:  60,
+		MinAccountAge: 7 * 24,
+		Action:        AntiRaidActionKick,
+	}
+}
+
+func AntiRaidSettingsUnmarshal(data string) (*AntiRaidSettings, error) {
+	res := new(AntiRaidSettings)
+	err := json.Unmarshal([]byte(data), res)
+	return res, err
+}
+
+func (a *AntiRaidSettings) Marshal() (string, error) {
+	data, err := json.Marshal(a)
+	return string(data), err
+}
+
+func (a *AntiRaidSettings) GetJoinInterval() time.Duration {
+	return time.Duration(a.JoinInterval) * time.Second
+}
+
+// IsFreshAccount returns true if the account with the passed
+// ID is younger than the minimum account age. If no minimum
+// account age is set, every account is considered fresh.
+func (a *AntiRaidSettings) IsFreshAccount(userID string) bool {
+	if a.MinAccountAge <= 0 {
+		return true
+	}
+	created, err := GetDiscordSnowflakeCreationTime(userID)
+	if err != nil {
+		return true
+	}
+	return time.Since(created) < time.Duration(a.MinAccountAge)*time.Hour
+}
@@ -0,0 +1,52 @@
+package util
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AntiSpamSettings struct {
+	RateLimit         int `json:"rate_limit"`
+	RateInterval      int `json:"rate_interval"`
+	DuplicateLimit    int `json:"duplicate_limit"`
+	DuplicateInterval int `json:"duplicate_interval"`
+	PenaltyType       int `json:"penalty_type"`
+}
+
+func NewDefaultAntiSpamSettings() *AntiSpamSettings {
+	return &AntiSpamSettings{
+		RateLimit:         6,
+		RateInterval:      5,
+		DuplicateLimit:    3,
+		DuplicateInterval: 30,
+		PenaltyType:       IndexOfStrArray("MUTE", ReportTypes),
+	}
+}
+
+func AntiSpamSettingsUnmarshal(data string) (*AntiSpamSettings, error) {
+	res := new(AntiSpamSettings)
+	err := json.Unmarshal([]byte(data), res)
+	return res, err
+}
+
+func (a *AntiSpamSettings) Marshal() (string, error) {
+	data, err := json.Marshal(a)
+	return string(data), err
+}
+
+func (a *AntiSpamSettings) GetRateInterval() time.Duration {
+	return time.Duration(a.RateInterval) * time.Second
+}
+
+func (a *AntiSpamSettings) GetDuplicateInterval() time.Duration {
+	return time.Duration(a.DuplicateInterval) * time.Second
+}
+
+// GetMaxInterval returns the longer one of both
+// sliding window durations.
+func (a *AntiSpamSettings) GetMaxInterval() time.Duration {
+	if a.GetRateInterval() > a.GetDuplicateInterval() {
+		return a.GetRateInterval()
+	}
+	return a.GetDuplicateInterval()
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type BackupSettings struct {
+	Interval   int `json:"interval"`
+	KeepLast   int `json:"keep_last"`
+	KeepDaily  int `json:"keep_daily"`
+	KeepWeekly int `json:"keep_weekly"`
+}
+
+func NewDefaultBackupSettings() *BackupSettings {
+	return &BackupSettings{
+		Interval: 12,
+		KeepLast: 10,
+	}
+}
+
+func BackupSettingsUnmarshal(data string) (*BackupSettings, error) {
+	res := new(BackupSettings)
+	err := json.Unmarshal([]byte(data), res)
+	return res, err
+}
+
+func (b *BackupSettings) Marshal() (string, error) {
+	data, err := json.Marshal(b)
+	return string(data), err
+}
+
+func (b *BackupSettings) GetInterval() time.Duration {
+	return time.Duration(b.Interval) * time.Hour
+}
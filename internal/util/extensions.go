@@ -61,7 +61,7 @@ func GetDiscordSnowflakeCreationTime(snowflake string) (time.Time, error) {
 		return time.Time{}, err
 	}
 	timestamp := (sfI >> 22) + 1420070400000
-	return time.Unix(timestamp/1000, timestamp), nil
+	return time.Unix(timestamp/1000, (timestamp%1000)*int64(time.Millisecond)), nil
 }
 
 // RolePosDiff : m1 position - m2 position
@@ -0,0 +1,44 @@
+package util
+
+import "encoding/json"
+
+const (
+	InviteBlockActionDelete     = "delete"
+	InviteBlockActionDeleteWarn = "warn"
+	InviteBlockActionMute       = "mute"
+	InviteBlockActionKick       = "kick"
+)
+
+var InviteBlockActions = []string{
+	InviteBlockActionDelete,
+	InviteBlockActionDeleteWarn,
+	InviteBlockActionMute,
+	InviteBlockActionKick,
+}
+
+type InviteBlockSettings struct {
+	Whitelist []string `json:"whitelist"`
+	Action    string   `json:"action"`
+}
+
+func NewDefaultInviteBlockSettings() *InviteBlockSettings {
+	return &InviteBlockSettings{
+		Whitelist: []string{},
+		Action:    InviteBlockActionDelete,
+	}
+}
+
+func InviteBlockSettingsUnmarshal(data string) (*InviteBlockSettings, error) {
+	res := new(InviteBlockSettings)
+	err := json.Unmarshal([]byte(data), res)
+	return res, err
+}
+
+func (i *InviteBlockSettings) Marshal() (string, error) {
+	data, err := json.Marshal(i)
+	return string(data), err
+}
+
+func (i *InviteBlockSettings) IsWhitelisted(guildID string) bool {
+	return IndexOfStrArray(guildID, i.Whitelist) > -1
+}
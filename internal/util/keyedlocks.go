@@ -0,0 +1,45 @@
+package util
+
+import "sync"
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// KeyedLocks provides a mutex for each key which
+// exists only as long as the key is locked.
+type KeyedLocks struct {
+	mtx   sync.Mutex
+	locks map[string]*keyedLock
+}
+
+func NewKeyedLocks() *KeyedLocks {
+	return &KeyedLocks{
+		locks: make(map[string]*keyedLock),
+	}
+}
+
+// Lock locks the mutex of the passed key and
+// returns the function to unlock it.
+func (k *KeyedLocks) Lock(key string) func() {
+	k.mtx.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = new(keyedLock)
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mtx.Unlock()
+
+	l.Lock()
+
+	return func() {
+		l.Unlock()
+		k.mtx.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mtx.Unlock()
+	}
+}
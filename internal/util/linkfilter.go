@@ -0,0 +1,60 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	LinkFilterModeDeny  = "deny"
+	LinkFilterModeAllow = "allow"
+)
+
+type LinkFilterSettings struct {
+	Enabled           bool     `json:"enabled"`
+	Mode              string   `json:"mode"`
+	Domains           []string `json:"domains"`
+	ChannelExceptions []string `json:"channel_exceptions"`
+	BypassPermLvl     int      `json:"bypass_perm_lvl"`
+}
+
+func NewDefaultLinkFilterSettings() *LinkFilterSettings {
+	return &LinkFilterSettings{
+		Mode:              LinkFilterModeDeny,
+		Domains:           []string{},
+		ChannelExceptions: []string{},
+		BypassPermLvl:     5,
+	}
+}
+
+func LinkFilterSettingsUnmarshal(data string) (*LinkFilterSettings, error) {
+	res := new(LinkFilterSettings)
+	err := json.Unmarshal([]byte(data), res)
+	return res, err
+}
+
+func (l *LinkFilterSettings) Marshal() (string, error) {
+	data, err := json.Marshal(l)
+	return string(data), err
+}
+
+// MatchesDomain returns true if the passed host equals
+// or is a subdomain of any of the listed domains.
+func (l *LinkFilterSettings) MatchesDomain(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, d := range l.Domains {
+		if host == d || strings.HasSuffix(host, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAllowed returns true if links to the passed host
+// are allowed by the current filter mode.
+func (l *LinkFilterSettings) IsAllowed(host string) bool {
+	if l.Mode == LinkFilterModeAllow {
+		return l.MatchesDomain(host)
+	}
+	return !l.MatchesDomain(host)
+}
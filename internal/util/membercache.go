@@ -0,0 +1,110 @@
+package util
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// MemberCache holds snapshots of guild members. Because
+// the state is updated before event handlers are called,
+// it is used to get the previous state of a member on
+// member update and remove events. The number of cached
+// members is limited per guild. If the limit is exceeded,
+// the least recently updated members are removed first.
+type MemberCache struct {
+	mtx          sync.Mutex
+	guilds       map[string]*memberCacheGuild
+	maxGuildSize int
+}
+
+type memberCacheGuild struct {
+	members map[string]*list.Element
+	order   *list.List
+}
+
+func NewMemberCache(maxGuildSize int) *MemberCache {
+	return &MemberCache{
+		guilds:       make(map[string]*memberCacheGuild),
+		maxGuildSize: maxGuildSize,
+	}
+}
+
+// Set saves a copy of the passed member for the guild.
+func (c *MemberCache) Set(guildID string, member *discordgo.Member) {
+	if member == nil || member.User == nil {
+		return
+	}
+
+	snapshot := *member
+	user := *member.User
+	snapshot.User = &user
+	snapshot.GuildID = guildID
+	snapshot.Roles = make([]string, len(member.Roles))
+	copy(snapshot.Roles, member.Roles)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	guild, ok := c.guilds[guildID]
+	if !ok {
+		guild = &memberCacheGuild{
+			members: make(map[string]*list.Element),
+			order:   list.New(),
+		}
+		c.guilds[guildID] = guild
+	}
+
+	if elem, ok := guild.members[user.ID]; ok {
+		elem.Value = &snapshot
+		guild.order.MoveToBack(elem)
+		return
+	}
+
+	guild.members[user.ID] = guild.order.PushBack(&snapshot)
+
+	for guild.order.Len() > c.maxGuildSize {
+		oldest := guild.order.Remove(guild.order.Front()).(*discordgo.Member)
+		delete(guild.members, oldest.User.ID)
+	}
+}
+
+// Remove deletes the snapshot of the member.
+func (c *MemberCache) Remove(guildID, userID string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	guild, ok := c.guilds[guildID]
+	if !ok {
+		return
+	}
+	if elem, ok := guild.members[userID]; ok {
+		guild.order.Remove(elem)
+		delete(guild.members, userID)
+	}
+}
+
+// RemoveGuild deletes the snapshots of all
+// members of the guild.
+func (c *MemberCache) RemoveGuild(guildID string) {
+	c.mtx.Lock()
+	delete(c.guilds, guildID)
+	c.mtx.Unlock()
+}
+
+// Get returns the last saved snapshot of the member
+// or nil, if the member is not cached.
+func (c *MemberCache) Get(guildID, userID string) *discordgo.Member {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	guild, ok := c.guilds[guildID]
+	if !ok {
+		return nil
+	}
+	if elem, ok := guild.members[userID]; ok {
+		return elem.Value.(*discordgo.Member)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package util
+
+const (
+	MemberLogEventJoin = 1 << iota
+	MemberLogEventLeave
+	MemberLogEventKick
+	MemberLogEventBan
+	MemberLogEventNick
+	MemberLogEventRoles
+	MemberLogEventUser
+
+	MemberLogEventsAll = MemberLogEventJoin |
+		MemberLogEventLeave |
+		MemberLogEventKick |
+		MemberLogEventBan |
+		MemberLogEventNick |
+		MemberLogEventRoles |
+		MemberLogEventUser
+)
+
+// MemberLogEvents maps the names of member log
+// events to their flags.
+var MemberLogEvents = map[string]int{
+	"join":  MemberLogEventJoin,
+	"leave": MemberLogEventLeave,
+	"kick":  MemberLogEventKick,
+	"ban":   MemberLogEventBan,
+	"nick":  MemberLogEventNick,
+	"roles": MemberLogEventRoles,
+	"user":  MemberLogEventUser,
+}
+
+// MemberLogEventNames contains the names of all
+// member log events in a fixed order.
+var MemberLogEventNames = []string{"join", "leave", "kick", "ban", "nick", "roles", "user"}
@@ -0,0 +1,52 @@
+package util
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/zekroTJA/timedmap"
+)
+
+// MessageCache caches messages by their ID for a specified
+// lifetime. If the cache exceeds the set maximum size, the
+// oldest messages are removed first.
+type MessageCache struct {
+	mtx      sync.Mutex
+	tm       *timedmap.TimedMap
+	order    []string
+	maxSize  int
+	lifetime time.Duration
+}
+
+func NewMessageCache(maxSize int, lifetime time.Duration) *MessageCache {
+	return &MessageCache{
+		tm:       timedmap.New(5 * time.Minute),
+		order:    make([]string, 0),
+		maxSize:  maxSize,
+		lifetime: lifetime,
+	}
+}
+
+// Set adds or replaces the passed message in the cache.
+func (c *MessageCache) Set(msg *discordgo.Message) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.tm.Contains(msg.ID) {
+		c.order = append(c.order, msg.ID)
+	}
+	c.tm.Set(msg.ID, msg, c.lifetime)
+
+	for len(c.order) > c.maxSize {
+		c.tm.Remove(c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// Get returns the cached message by its ID or nil,
+// if the message is not cached.
+func (c *MessageCache) Get(msgID string) *discordgo.Message {
+	msg, _ := c.tm.GetValue(msgID).(*discordgo.Message)
+	return msg
+}
@@ -0,0 +1,27 @@
+package util
+
+import "encoding/json"
+
+type ModmailSettings struct {
+	CategoryID   string `json:"category_id"`
+	LogChannelID string `json:"log_channel_id"`
+}
+
+func NewDefaultModmailSettings() *ModmailSettings {
+	return &ModmailSettings{}
+}
+
+func ModmailSettingsUnmarshal(data string) (*ModmailSettings, error) {
+	res := new(ModmailSettings)
+	err := json.Unmarshal([]byte(data), res)
+	return res, err
+}
+
+func (m *ModmailSettings) Marshal() (string, error) {
+	data, err := json.Marshal(m)
+	return string(data), err
+}
+
+func (m *ModmailSettings) IsEnabled() bool {
+	return m.CategoryID != ""
+}
@@ -6,6 +6,11 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+const (
+	MuteTextPermissions  = discordgo.PermissionSendMessages | discordgo.PermissionAddReactions
+	MuteVoicePermissions = discordgo.PermissionVoiceSpeak | discordgo.PermissionVoiceConnect
+)
+
 func MuteSetupChannels(s *discordgo.Session, guildID, roleID string) error {
 	guild, err := s.Guild(guildID)
 	if err != nil {
@@ -22,12 +27,104 @@ func MuteSetupChannels(s *discordgo.Session, guildID, roleID string) error {
 		return errors.New("role does not exist on guild")
 	}
 
+	// The denied permissions must be collected before editing
+	// any channel because editing a category changes if its
+	// channels are synced with it.
+	denies := make(map[*discordgo.Channel]int)
 	for _, c := range guild.Channels {
-		if c.Type != discordgo.ChannelTypeGuildText {
+		denies[c] = MuteChannelDeny(guild, c)
+	}
+
+	for c, deny := range denies {
+		if deny == 0 {
 			continue
 		}
-		err = s.ChannelPermissionSet(c.ID, roleID, "role", 0, 0x00000800)
+		if cErr := MuteSetupChannel(s, c, roleID, deny); cErr != nil {
+			err = cErr
+		}
 	}
 
 	return err
 }
+
+// MuteSetupChannel adds the passed denied permissions to the
+// permission overwrite of the mute role in the channel while
+// keeping other permissions of an existing overwrite.
+func MuteSetupChannel(s *discordgo.Session, c *discordgo.Channel, roleID string, deny int) error {
+	var allow int
+	if po := getRoleOverwrite(c, roleID); po != nil {
+		allow = po.Allow &^ deny
+		deny |= po.Deny
+	}
+	return s.ChannelPermissionSet(c.ID, roleID, "role", allow, deny)
+}
+
+// MuteChannelDeny returns the permissions which need to be
+// denied for the mute role in the passed channel. Channels
+// which are synced with their category get the same
+// permissions denied as the category to keep them synced.
+func MuteChannelDeny(guild *discordgo.Guild, c *discordgo.Channel) int {
+	if c.ParentID != "" {
+		for _, parent := range guild.Channels {
+			if parent.ID == c.ParentID && overwritesEqual(parent.PermissionOverwrites, c.PermissionOverwrites) {
+				return MuteTextPermissions | MuteVoicePermissions
+			}
+		}
+	}
+
+	switch c.Type {
+	case discordgo.ChannelTypeGuildText:
+		return MuteTextPermissions
+	case discordgo.ChannelTypeGuildVoice:
+		return MuteVoicePermissions
+	case discordgo.ChannelTypeGuildCategory:
+		return MuteTextPermissions | MuteVoicePermissions
+	}
+
+	return 0
+}
+
+// MuteGetUnsetChannels returns all channels of the guild
+// where the permission overwrite of the mute role does
+// not deny all required permissions.
+func MuteGetUnsetChannels(guild *discordgo.Guild, roleID string) []*discordgo.Channel {
+	unset := make([]*discordgo.Channel, 0)
+	for _, c := range guild.Channels {
+		deny := MuteChannelDeny(guild, c)
+		if deny == 0 {
+			continue
+		}
+		if po := getRoleOverwrite(c, roleID); po == nil || po.Deny&deny != deny {
+			unset = append(unset, c)
+		}
+	}
+	return unset
+}
+
+func getRoleOverwrite(c *discordgo.Channel, roleID string) *discordgo.PermissionOverwrite {
+	for _, po := range c.PermissionOverwrites {
+		if po.ID == roleID {
+			return po
+		}
+	}
+	return nil
+}
+
+func overwritesEqual(a, b []*discordgo.PermissionOverwrite) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, poA := range a {
+		var found bool
+		for _, poB := range b {
+			if poA.ID == poB.ID && poA.Allow == poB.Allow && poA.Deny == poB.Deny {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
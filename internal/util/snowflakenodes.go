@@ -6,6 +6,9 @@ var NodesReport []*snowflake.Node
 var NodeBackup *snowflake.Node
 var NodeLCHandler *snowflake.Node
 var NodeTags *snowflake.Node
+var NodeFlags *snowflake.Node
+var NodeModmail *snowflake.Node
+var NodeTickets *snowflake.Node
 
 func SetupSnowflakeNodes() error {
 	NodesReport = make([]*snowflake.Node, len(ReportTypes))
@@ -20,6 +23,9 @@ func SetupSnowflakeNodes() error {
 	NodeBackup, err = snowflake.NewNode(100)
 	NodeLCHandler, err = snowflake.NewNode(110)
 	NodeTags, err = snowflake.NewNode(120)
+	NodeFlags, err = snowflake.NewNode(130)
+	NodeModmail, err = snowflake.NewNode(140)
+	NodeTickets, err = snowflake.NewNode(150)
 
 	return err
 }
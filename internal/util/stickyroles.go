@@ -0,0 +1,27 @@
+package util
+
+import "encoding/json"
+
+type StickyRoleSettings struct {
+	RoleIDs    []string `json:"role_ids"`
+	StickyMute bool     `json:"sticky_mute"`
+	RestoreAll bool     `json:"restore_all"`
+}
+
+func NewDefaultStickyRoleSettings() *StickyRoleSettings {
+	return &StickyRoleSettings{
+		RoleIDs:    []string{},
+		StickyMute: true,
+	}
+}
+
+func StickyRoleSettingsUnmarshal(data string) (*StickyRoleSettings, error) {
+	res := new(StickyRoleSettings)
+	err := json.Unmarshal([]byte(data), res)
+	return res, err
+}
+
+func (s *StickyRoleSettings) Marshal() (string, error) {
+	data, err := json.Marshal(s)
+	return string(data), err
+}
@@ -0,0 +1,34 @@
+package util
+
+import "encoding/json"
+
+type TicketSettings struct {
+	CategoryID     string   `json:"category_id"`
+	LogChannelID   string   `json:"log_channel_id"`
+	StaffRoleIDs   []string `json:"staff_role_ids"`
+	MaxOpen        int      `json:"max_open"`
+	PanelChannelID string   `json:"panel_channel_id"`
+	PanelMessageID string   `json:"panel_message_id"`
+}
+
+func NewDefaultTicketSettings() *TicketSettings {
+	return &TicketSettings{
+		StaffRoleIDs: []string{},
+		MaxOpen:      1,
+	}
+}
+
+func TicketSettingsUnmarshal(data string) (*TicketSettings, error) {
+	res := new(TicketSettings)
+	err := json.Unmarshal([]byte(data), res)
+	return res, err
+}
+
+func (t *TicketSettings) Marshal() (string, error) {
+	data, err := json.Marshal(t)
+	return string(data), err
+}
+
+func (t *TicketSettings) IsEnabled() bool {
+	return t.CategoryID != ""
+}
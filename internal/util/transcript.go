@@ -0,0 +1,70 @@
+package util
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// MessagesTranscript creates a plain text transcript of
+// the passed messages including author, time and the
+// URLs of attachments for each message.
+func MessagesTranscript(msgs []*discordgo.Message) string {
+	var sb strings.Builder
+
+	for _, msg := range msgs {
+		created, _ := GetDiscordSnowflakeCreationTime(msg.ID)
+
+		author := "unknown"
+		if msg.Author != nil {
+			author = fmt.Sprintf("%s (%s)", msg.Author.String(), msg.Author.ID)
+		}
+
+		sb.WriteString(fmt.Sprintf("[%s] %s: %s\n", created.Format(time.RFC3339), author, msg.Content))
+		for _, att := range msg.Attachments {
+			sb.WriteString(fmt.Sprintf("    [attachment] %s\n", att.URL))
+		}
+	}
+
+	return sb.String()
+}
+
+// MessagesTranscriptHTML creates a HTML document with the
+// passed title containing the passed messages including
+// author, time and attachments for each message.
+func MessagesTranscriptHTML(title string, msgs []*discordgo.Message) string {
+	var sb strings.Builder
+
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	sb.WriteString("<style>body{font-family:sans-serif;background:#36393f;color:#dcddde}" +
+		".msg{margin:8px 0}.author{font-weight:bold;color:#fff}.time{color:#72767d;font-size:.8em;margin-left:6px}" +
+		".content{white-space:pre-wrap}a{color:#00b0f4}</style>\n")
+	sb.WriteString("</head>\n<body>\n<h2>" + html.EscapeString(title) + "</h2>\n")
+
+	for _, msg := range msgs {
+		created, _ := GetDiscordSnowflakeCreationTime(msg.ID)
+
+		author := "unknown"
+		if msg.Author != nil {
+			author = fmt.Sprintf("%s (%s)", msg.Author.String(), msg.Author.ID)
+		}
+
+		sb.WriteString("<div class=\"msg\">\n")
+		sb.WriteString(fmt.Sprintf("<span class=\"author\">%s</span><span class=\"time\">%s</span>\n",
+			html.EscapeString(author), created.Format(time.RFC3339)))
+		sb.WriteString("<div class=\"content\">" + html.EscapeString(msg.Content) + "</div>\n")
+		for _, att := range msg.Attachments {
+			sb.WriteString(fmt.Sprintf("<div><a href=\"%s\">%s</a></div>\n",
+				html.EscapeString(att.URL), html.EscapeString(att.Filename)))
+		}
+		sb.WriteString("</div>\n")
+	}
+
+	sb.WriteString("</body>\n</html>\n")
+
+	return sb.String()
+}
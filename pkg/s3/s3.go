@@ -0,0 +1,319 @@
+// Package s3 implements a minimal client for S3 compatible
+// object storages (like AWS S3 or MinIO) supporting putting,
+// getting, deleting and listing objects of a single bucket.
+// Requests are signed using AWS Signature Version 4 and
+// path-style addressing is used.
+package s3
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	amzDateFormat   = "20060102T150405Z"
+	amzShortFormat  = "20060102"
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	emptyBodySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	requestTimeout  = 5 * time.Minute
+)
+
+// Client is a client for a single bucket of
+// an S3 compatible object storage.
+type Client struct {
+	endpoint  *url.URL
+	region    string
+	accessKey string
+	secretKey string
+	bucket    string
+	client    *http.Client
+}
+
+// Error is returned if the object storage
+// responds with a non-success status code.
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3: request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3: %s: %s (status %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// IsNotFound returns true if the passed error is an
+// Error with the status code 404.
+func IsNotFound(err error) bool {
+	e, ok := err.(*Error)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// New creates a new Client for the passed bucket. The endpoint
+// must be a base URL like "https://s3.amazonaws.com" or
+// "http://localhost:9000".
+func New(endpoint, region, accessKey, secretKey, bucket string) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("s3: invalid endpoint '%s'", endpoint)
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &Client{
+		endpoint:  u,
+		region:    region,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		bucket:    bucket,
+		client:    &http.Client{Timeout: requestTimeout},
+	}, nil
+}
+
+// EnsureBucket creates the bucket of the client,
+// if it does not exist yet.
+func (c *Client) EnsureBucket() error {
+	resp, err := c.do("HEAD", "", nil, nil)
+	if err == nil {
+		resp.Body.Close()
+		return nil
+	}
+	if !IsNotFound(err) {
+		return err
+	}
+
+	resp, err = c.do("PUT", "", nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PutObject uploads the passed data as object with the
+// passed key. Existing objects are overwritten.
+func (c *Client) PutObject(key string, data []byte) error {
+	resp, err := c.do("PUT", key, nil, data)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// GetObject returns a reader of the data of the object
+// with the passed key, which must be closed after reading.
+func (c *Client) GetObject(key string) (io.ReadCloser, error) {
+	resp, err := c.do("GET", key, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// DeleteObject removes the object with the passed key.
+func (c *Client) DeleteObject(key string) error {
+	resp, err := c.do("DELETE", key, nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// ListObjects returns the keys of all objects
+// with the passed prefix.
+func (c *Client) ListObjects(prefix string) ([]string, error) {
+	keys := make([]string, 0)
+	var token string
+
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		if prefix != "" {
+			query.Set("prefix", prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := c.do("GET", "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var res listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range res.Contents {
+			keys = append(keys, obj.Key)
+		}
+
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+// do executes a signed request against the object with the
+// passed key or, if key is empty, against the bucket. If the
+// response has no success status code, an *Error is returned.
+func (c *Client) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	u := *c.endpoint
+	u.Path = c.endpoint.Path + "/" + c.bucket
+	if key != "" {
+		u.Path += "/" + key
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+
+	c.sign(req, body, time.Now().UTC())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		sErr := &Error{StatusCode: resp.StatusCode}
+		if data, err := ioutil.ReadAll(resp.Body); err == nil && len(data) > 0 {
+			xml.Unmarshal(data, sErr)
+		}
+		return nil, sErr
+	}
+
+	return resp, nil
+}
+
+// sign adds the AWS Signature Version 4 authorization
+// headers to the passed request.
+func (c *Client) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := emptyBodySHA256
+	if len(body) > 0 {
+		payloadHash = sha256Hex(body)
+	}
+
+	amzDate := now.Format(amzDateFormat)
+	shortDate := now.Format(amzShortFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		req.URL.Host, payloadHash, amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{shortDate, c.region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		signAlgorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := deriveSigningKey(c.secretKey, shortDate, c.region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, c.accessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+// deriveSigningKey derives the key used to sign requests
+// for the passed date, region and service from the secret key.
+func deriveSigningKey(secretKey, shortDate, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), []byte(shortDate))
+	key = hmacSHA256(key, []byte(region))
+	key = hmacSHA256(key, []byte(service))
+	return hmacSHA256(key, []byte("aws4_request"))
+}
+
+// canonicalQuery returns the URI encoded query
+// string with keys sorted alphabetically.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// uriEncode encodes all characters of s except unreserved
+// characters. If encodeSlash is false, '/' is not encoded.
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for _, b := range []byte(s) {
+		switch {
+		case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9',
+			b == '-', b == '.', b == '_', b == '~':
+			sb.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			sb.WriteByte(b)
+		default:
+			sb.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return sb.String()
+}
+
+func sha256Hex(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(data)
+	return h.Sum(nil)
+}
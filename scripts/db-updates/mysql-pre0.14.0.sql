@@ -0,0 +1,20 @@
+ALTER TABLE `guilds`
+    ADD `antiSpam` text NOT NULL,
+    ADD `antiRaid` text NOT NULL,
+    ADD `raidMode` text NOT NULL,
+    ADD `lockdown` text NOT NULL,
+    ADD `msglogchanID` text NOT NULL,
+    ADD `msgLogIgnores` text NOT NULL,
+    ADD `memberlogchanID` text NOT NULL,
+    ADD `memberLogEvents` text NOT NULL,
+    ADD `banlistPublished` text NOT NULL,
+    ADD `stickyRoles` text NOT NULL,
+    ADD `inviteBlockSettings` text NOT NULL,
+    ADD `linkFilter` text NOT NULL,
+    ADD `flagQueueChanID` text NOT NULL,
+    ADD `modmail` text NOT NULL,
+    ADD `tickets` text NOT NULL,
+    ADD `backupSettings` text NOT NULL;
+
+ALTER TABLE `backups`
+    ADD `checksum` text NOT NULL;
@@ -71,5 +71,6 @@ CREATE TABLE IF NOT EXISTS `backups` (
   `guildID` text NOT NULL,
   `timestamp` bigint(20) NOT NULL,
   `fileID` text NOT NULL,
+  `checksum` text NOT NULL,
   PRIMARY KEY (`iid`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -60,5 +60,6 @@ CREATE TABLE IF NOT EXISTS `backups` (
   `iid` INTEGER PRIMARY KEY AUTOINCREMENT,
   `guildID` text NOT NULL DEFAULT '',
   `timestamp` bigint(20) NOT NULL DEFAULT 0,
-  `fileID` text NOT NULL DEFAULT ''
+  `fileID` text NOT NULL DEFAULT '',
+  `checksum` text NOT NULL DEFAULT ''
 );
\ No newline at end of file